@@ -16,214 +16,784 @@
 package ahocorasick
 
 import (
-	"container/list"
+	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 )
 
-// node represents a node in the trie tree, operating on runes
+// node represents a node in the trie tree while the automaton is being built,
+// operating on runes
+// output metadata is kept in a side array indexed by node ID, see buildTrie
 type node struct {
-	root    bool   // whether this is the root node
-	output  bool   // whether this is the end node of a pattern string
-	index   int    // if this is an output node, the index of the pattern in the dictionary
-	counter uint64 // counter used for deduplication
-
-	// child node mapping, key is rune character, value is corresponding child node
-	// using rune instead of byte ensures correct handling of multi-byte characters
-	child map[rune]*node
-
 	// suffix points to the longest proper suffix that is also a word in the dictionary
 	// used to quickly find other possible matches when current node matches
-	suffix *node
+	suffix uint32
 
 	// fail points to the failure function, the node to jump to when current character fails to match
 	// this is the core of AC algorithm, enabling efficient pattern matching
-	fail *node
+	fail uint32
+}
+
+// transition is an entry of the build-time goto function: consuming label in
+// node parent leads to node child
+// using rune instead of byte ensures correct handling of multi-byte characters
+type transition struct {
+	parent uint32
+	label  rune
+	child  uint32
+}
+
+// transitionKey packs a (node ID, rune) pair into the key of the shared transition table
+func transitionKey(n uint32, r rune) uint64 {
+	return uint64(n)<<32 | uint64(uint32(r))
 }
 
+// state is a node of the compiled automaton
+// all links are state IDs (offsets into Matcher.states) rather than pointers, so
+// the automaton can be used directly from a serialized byte slice
+// the root is always state 0, which is also used as "no link" by suffix
+// output metadata lives in side arrays of Matcher so states stay small and
+// fail-chain walks touch fewer cache lines
+type state struct {
+	edges  uint32 // offset of the first outgoing transition in Matcher.edges
+	nedges uint32 // number of outgoing transitions, sorted by label
+	fail   uint32 // state to continue from when no transition matches
+	suffix uint32 // nearest output state on the fail chain, 0 if there is none
+}
+
+// edge is a transition of the compiled automaton
+type edge struct {
+	label rune   // input rune consumed by the transition
+	next  uint32 // target state
+}
+
+// root is the ID of the root state
+const root = 0
+
 // Matcher contains the main structure of the Aho-Corasick automaton
 // returned by NewMatcher, contains the complete matching automaton
 type Matcher struct {
 	counter uint64    // global counter for thread-safe deduplication
-	trie    []node    // array storing all nodes, improving memory locality
-	extent  int       // number of nodes currently used
-	root    *node     // root node pointer
+	states  []state   // all states, indexed by state ID, improving memory locality
+	edges   []edge    // transitions of all states, grouped per state
+	outputs []int32   // index of the pattern ending in each state, -1 if none
+	seen    []uint64  // per state counter used for deduplication by MatchString, lazily allocated
 	heap    sync.Pool // memory pool used for thread-safe matching
-}
 
-// getFreeNode gets a new node from the pre-allocated node array
-// this design avoids frequent memory allocations and improves performance
-func (m *Matcher) getFreeNode() *node {
-	m.extent++
-	if m.extent == 1 {
-		// initialize root node on first call
-		m.root = &m.trie[0]
-		m.root.root = true
-	}
-	newNode := &m.trie[m.extent-1]
-	// note: child map is lazily initialized when needed to save memory
-	return newNode
+	// backend indexes the transitions for matching, nil to search the
+	// sorted lists of edges directly, see WithBackend
+	backend backend
+
+	// cache memoizes transitions resolved through the fail chain, nil unless
+	// enabled with WithTransitionCache
+	cache *transitionCache
+
+	// lazy holds the fail and suffix links computed on first use, nil unless
+	// enabled with WithLazyLinks; states then carry no links
+	lazy *lazyLinks
+
+	// prefilter skips input that cannot start a match, nil when the
+	// dictionary does not allow it
+	prefilter  *prefilter
+	startRunes *startRunes // used instead when prefilter is not applicable
+
+	// fold holds the per-pattern case flags, nil unless some patterns were
+	// declared case-insensitive with WithCaseInsensitive; the automaton is
+	// then built over case-folded patterns
+	fold *caseFold
+
+	// norm rewrites the input before it is matched, nil unless a
+	// normalization option was given; the patterns were rewritten the same way
+	norm      pipeline
+	normKinds []string // what every stage of norm does, for Fingerprint
+
+	// equiv holds the rune equivalence classes compiled into the transitions,
+	// nil unless given with WithEquivalence
+	equiv *equivalence
+
+	npatterns int           // number of patterns of a matcher that was compiled
+	ids       []int32       // ID reported for every pattern, nil to report indices
+	overlap   OverlapPolicy // resolution of overlapping occurrences
+
+	// categories holds the category mask of every pattern, nil unless
+	// enabled with WithCategories
+	categories *categories
+	info       []PatternInfo // metadata of the patterns, see WithPatternInfo
+
+	// validators holds the validators of every pattern, nil unless some
+	// were attached with WithValidator
+	validators     [][]Validator
+	validatorKinds []string // what every validator checks and where, for Fingerprint
+
+	// positions holds where the occurrences of every pattern may lie, nil
+	// unless some were given with WithPosition
+	positions *positions
+
+	// disabled holds the patterns switched off with DisablePattern, nil when
+	// all are enabled; maskMu serializes its updates
+	disabled atomic.Pointer[patternMask]
+	maskMu   sync.Mutex
+
+	// bloom screens out texts that cannot match, nil unless enabled with
+	// WithBloomFilter
+	bloom *bloomFilter
+
+	// hits counts the reported patterns, nil unless enabled with WithHitCounters
+	hits *HitCounters
+
+	// depth of every state in runes, computed on the first positional match
+	depthOnce sync.Once
+	depth     []uint32
+	maxDepth  int // depth of the deepest state, the longest pattern in runes
+	minDepth  int // depth of the shallowest output, the shortest pattern in runes
+	ringMask  int // size of the ring of rune offsets used by find, minus one
+
+	// fingerprint is the content hash computed by the first Fingerprint call
+	fingerprintOnce sync.Once
+	fingerprint     string
+
+	// data is the buffer states and edges alias when the matcher was loaded
+	// without copying, it must not be modified while the matcher is in use
+	data []byte
+
+	// unmap releases the file mapping data lives in, set by Open
+	unmap func() error
+
+	// builder is the build-time storage kept between calls to Rebuild, nil
+	// for matchers that were never rebuilt
+	builder *trieBuilder
+
+	// build measures the build, nil unless enabled with WithBuildStats
+	build *buildRecorder
 }
 
 // buildTrie builds the AC automaton from a dictionary of strings
 // this method implements the core of AC algorithm: building trie tree and computing failure function
-func (m *Matcher) buildTrie(dictionary []string) {
-	// estimate the number of trie nodes needed
-	// for rune-based implementation, calculate total number of runes
-	maxNodes := 1
-	for _, word := range dictionary {
-		for range word { // iterating over a string yields runes
-			maxNodes++
-		}
+// it only fails when the context of the options is done
+func (m *Matcher) buildTrie(dictionary []string, o *options) error {
+	b := m.builder
+	if b != nil {
+		b.reset()
+	} else {
+		// count the exact number of trie nodes needed, shared prefixes
+		// included, so the trie and the transition table are allocated once
+		// at their final size rather than for every rune of every word
+		b = newTrieBuilder(countNodes(sortedCopy(dictionary)))
 	}
-	m.trie = make([]node, maxNodes)
-
-	m.getFreeNode() // allocate root node
 
 	// phase 1: build basic trie tree structure
 	// insert all pattern strings into the trie
+	progress := newProgress(o, PhaseInsert, len(dictionary))
 	for i, word := range dictionary {
-		n := m.root
-		// process rune by rune to ensure correctness with multi-byte characters
-		for _, r := range word {
-			if n.child == nil {
-				n.child = make(map[rune]*node)
-			}
-			c, ok := n.child[r]
-			if !ok {
-				// if child node for current rune doesn't exist, create new node
-				c = m.getFreeNode()
-				n.child[r] = c
-			}
-			n = c
+		b.insert(word, int32(i))
+		m.build.count(word)
+		if err := progress.step(i + 1); err != nil {
+			return err
 		}
-		// mark the end node of pattern string
-		n.output = true
-		n.index = i
 	}
+	progress.end(len(dictionary))
+	m.build.lap(phaseTrie)
+	return b.finish(m, o)
+}
+
+// trieBuilder holds the trie while patterns are inserted
+type trieBuilder struct {
+	trie []node
+
+	// output[n] is the index of the pattern ending at node n, -1 if none
+	output []int32
 
-	// phase 2: build failure function and suffix links
-	// use breadth-first search (BFS) to compute fail pointers
-	l := new(list.List)
+	// all transitions live in one table keyed by (node ID, rune) instead of a
+	// small map per node, whose fixed overhead dominates for big dictionaries
+	goTo map[uint64]uint32
+
+	// storage of finish and freeze, kept for the next build by Rebuild
+	transitions []transition
+	first       []uint32
+	order, ids  []uint32
+}
+
+// newTrieBuilder returns a builder holding the root, sized for nodes nodes
+func newTrieBuilder(nodes int) *trieBuilder {
+	b := &trieBuilder{
+		trie:   make([]node, 1, max(nodes, 1)), // allocate root node
+		output: make([]int32, 1, max(nodes, 1)),
+		goTo:   make(map[uint64]uint32, max(nodes-1, 0)),
+	}
+	b.output[root] = -1
+	return b
+}
+
+// reset empties the builder down to the root, keeping its storage
+func (b *trieBuilder) reset() {
+	b.trie = append(b.trie[:0], node{})
+	b.output = append(b.output[:0], -1)
+	clear(b.goTo)
+}
+
+// resize returns s with length n and zeroed, reusing its storage when it is
+// large enough
+func resize[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	s = s[:n]
+	clear(s)
+	return s
+}
+
+// insert adds the path of word to the trie and marks its end with pattern i
+// a previous mark is replaced, so duplicates are reported under their last index
+func (b *trieBuilder) insert(word string, i int32) {
+	n := uint32(root)
+	// process rune by rune to ensure correctness with multi-byte characters
+	for _, r := range word {
+		k := transitionKey(n, r)
+		c, ok := b.goTo[k]
+		if !ok {
+			// if child node for current rune doesn't exist, create new node
+			c = uint32(len(b.trie))
+			b.trie = append(b.trie, node{})
+			b.output = append(b.output, -1)
+			b.goTo[k] = c
+		}
+		n = c
+	}
+	// mark the end node of pattern string
+	b.output[n] = i
+}
+
+// finish computes the links of the trie and freezes it into m
+// it only fails when the context of the options is done
+func (b *trieBuilder) finish(m *Matcher, o *options) error {
+	trie, output, goTo := b.trie, b.output, b.goTo
+
+	// group the transitions by parent and sort them by rune, the children of
+	// node n are edges[first[n]:first[n+1]]
+	edges := b.transitions[:0]
+	for k, c := range goTo {
+		edges = append(edges, transition{parent: uint32(k >> 32), label: rune(uint32(k)), child: c})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].parent != edges[j].parent {
+			return edges[i].parent < edges[j].parent
+		}
+		return edges[i].label < edges[j].label
+	})
+	first := resize(b.first, len(trie)+1)
+	for _, e := range edges {
+		first[e.parent+1]++
+	}
+	for i := 1; i < len(first); i++ {
+		first[i] += first[i-1]
+	}
+
+	// phase 2: build failure function and suffix links, unless they are
+	// computed on first use
+	b.order = resize(b.order, len(trie))
+	if !o.lazyLinks {
+		progress := newProgress(o, PhaseLinks, len(trie)-1)
+		if err := buildLinks(trie, output, goTo, edges, first, b.order[:0], progress); err != nil {
+			return err
+		}
+	}
+
+	// phase 3: freeze the trie into the flat, pointer-free layout used for matching
+	b.order = resize(b.order, len(trie))[:1] // root first
+	b.ids = resize(b.ids, len(trie))
+	m.freeze(trie, output, edges, first, b.order, b.ids)
+	if o.lazyLinks {
+		m.lazy = newLazyLinks(m)
+	}
+	b.transitions, b.first = edges, first
+	return nil
+}
+
+// buildLinks computes the fail and suffix links of every node of the trie,
+// queue being storage with room for every node, and reports every linked
+// node to progress, giving up with its error
+func buildLinks(trie []node, output []int32, goTo map[uint64]uint32, edges []transition, first []uint32, queue []uint32, progress progressReporter) error {
+	// use breadth-first search (BFS) to compute fail pointers, every node
+	// is queued once so the queue is never shifted
 
 	// initialize fail pointers of first level nodes to point to root
-	for _, c := range m.root.child {
-		c.fail = m.root
-		l.PushBack(c)
+	for _, e := range edges[first[root]:first[root+1]] {
+		trie[e.child].fail = root
+		queue = append(queue, e.child)
+		if err := progress.step(len(queue)); err != nil {
+			return err
+		}
 	}
 
 	// BFS traversal to build fail pointers
-	for l.Len() > 0 {
-		n := l.Remove(l.Front()).(*node)
-		for r, childNode := range n.child {
-			l.PushBack(childNode)
-
-			// compute fail pointer for childNode
-			f := n.fail
+	for head := 0; head < len(queue); head++ {
+		n := queue[head]
+		for _, e := range edges[first[n]:first[n+1]] {
+			c := e.child
+			queue = append(queue, c)
+
+			// compute fail pointer for child node
+			f := trie[n].fail
 			for {
-				failChild, ok := f.child[r]
+				failChild, ok := goTo[transitionKey(f, e.label)]
 				if ok {
 					// found matching character, set fail pointer
-					childNode.fail = failChild
+					trie[c].fail = failChild
 					break
 				}
-				if f.root {
+				if f == root {
 					// reached root node, fail pointer points to root
-					childNode.fail = m.root
+					trie[c].fail = root
 					break
 				}
 				// continue searching up the fail chain
-				f = f.fail
+				f = trie[f].fail
 			}
 
 			// compute suffix pointer: points to longest output suffix
-			if childNode.fail.output {
-				childNode.suffix = childNode.fail
+			if fc := trie[c].fail; output[fc] >= 0 && fc != root {
+				trie[c].suffix = fc
 			} else {
-				childNode.suffix = childNode.fail.suffix
+				trie[c].suffix = trie[fc].suffix
+			}
+			if err := progress.step(len(queue)); err != nil {
+				return err
+			}
+		}
+	}
+	progress.end(len(queue))
+	return nil
+}
+
+// freeze converts the build-time trie into exactly sized state and edge arrays
+// this is the post-build compaction step: the transition table and node
+// slices are dropped with the build, leaves own no child storage at all and
+// the arrays kept by the matcher are immutable and carry no spare capacity
+// states are numbered in breadth-first order with children sorted by rune, so
+// IDs are deterministic and every fail or suffix link points to a smaller ID
+// order and ids are zeroed storage for order[new ID] = build ID, holding the
+// root, and ids[build ID] = new ID; the arrays of m are reused when large
+// enough, which only Rebuild leaves in place
+func (m *Matcher) freeze(trie []node, output []int32, edges []transition, first []uint32, order, ids []uint32) {
+	m.states = resize(m.states, len(trie))
+	m.outputs = resize(m.outputs, len(trie))
+	m.edges = resize(m.edges, len(edges))[:0]
+	for i := 0; i < len(order); i++ {
+		n := order[i]
+		s := &m.states[i]
+		s.edges = uint32(len(m.edges))
+		s.nedges = first[n+1] - first[n]
+		// transitions are already sorted so they can be binary searched
+		for _, e := range edges[first[n]:first[n+1]] {
+			ids[e.child] = uint32(len(order))
+			order = append(order, e.child)
+			m.edges = append(m.edges, edge{label: e.label, next: ids[e.child]})
+		}
+	}
+
+	// links can only be translated once every node has its final ID
+	for i, b := range order {
+		n := &trie[b]
+		s := &m.states[i]
+		s.fail = ids[n.fail]
+		s.suffix = ids[n.suffix]
+		m.outputs[i] = output[b]
+	}
+}
+
+// next returns the state reached from state s by consuming rune r, if any
+// transitions are sorted by label: short lists are scanned, longer ones binary searched
+func (m *Matcher) next(s uint32, r rune) (uint32, bool) {
+	if m.backend != nil {
+		return m.backend.next(s, r)
+	}
+	st := &m.states[s]
+	out := m.edges[st.edges : st.edges+st.nedges]
+	if len(out) <= 8 {
+		for _, e := range out {
+			if e.label == r {
+				return e.next, true
 			}
 		}
+		return 0, false
+	}
+	lo, hi := 0, len(out)
+	for lo < hi {
+		h := int(uint(lo+hi) >> 1)
+		if out[h].label < r {
+			lo = h + 1
+		} else {
+			hi = h
+		}
+	}
+	if lo < len(out) && out[lo].label == r {
+		return out[lo].next, true
 	}
+	return 0, false
+}
 
-	// root node's suffix points to itself
-	m.root.suffix = m.root
-	// compress trie array, release unused space
-	m.trie = m.trie[:m.extent]
+// step moves the automaton from state s over rune r, following the fail
+// chain until a transition is found or the root is reached
+func (m *Matcher) step(s uint32, r rune) uint32 {
+	if c, ok := m.next(s, r); ok || s == root {
+		return c
+	}
+	if m.cache == nil {
+		return m.resolve(m.failLink(s), r)
+	}
+	if c, ok := m.cache.get(s, r); ok {
+		return c
+	}
+	c := m.resolve(m.failLink(s), r)
+	m.cache.put(s, r, c)
+	return c
+}
+
+// resolve walks the fail chain from state s until a transition over rune r is
+// found or the root is reached
+func (m *Matcher) resolve(s uint32, r rune) uint32 {
+	for {
+		if c, ok := m.next(s, r); ok || s == root {
+			return c
+		}
+		s = m.failLink(s)
+	}
 }
 
 // NewMatcher creates a matcher from a dictionary of byte slices
 // assumes UTF-8 encoding, converts byte slices to strings
-func NewMatcher(dictionary [][]byte) *Matcher {
+func NewMatcher(dictionary [][]byte, opts ...Option) *Matcher {
 	sDict := make([]string, len(dictionary))
 	for i, b := range dictionary {
 		sDict[i] = string(b)
 	}
-	return NewStringMatcher(sDict)
+	return NewStringMatcher(sDict, opts...)
 }
 
 // NewStringMatcher is an alias for NewMatcher for backward compatibility
-func NewStringMatcher(dictionary []string) *Matcher {
-	m := new(Matcher)
-	m.buildTrie(dictionary)
+// it panics if the dictionary violates a limit set by the options, use
+// Compile to get an error instead
+func NewStringMatcher(dictionary []string, opts ...Option) *Matcher {
+	m, err := Compile(dictionary, opts...)
+	if err != nil {
+		panic(err)
+	}
 	return m
 }
 
+// Compile creates a matcher from a dictionary of strings like NewStringMatcher
+// but reports an error when the dictionary violates a limit set by the
+// options, which makes it the constructor to use for user-supplied dictionaries
+func Compile(dictionary []string, opts ...Option) (*Matcher, error) {
+	return compile(dictionary, nil, len(dictionary), opts)
+}
+
+// compile builds a matcher reporting ids[i] for pattern i, or i itself when
+// ids is nil; nids is the number of reported IDs, which per-pattern options
+// such as categories refer to
+func compile(dictionary []string, ids []int32, nids int, opts []Option) (*Matcher, error) {
+	m := new(Matcher)
+	if err := m.compile(dictionary, ids, nids, opts); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// compile builds the automaton of dictionary into m like the function of
+// the same name; every error but the cancellation of the build is reported
+// before m is modified, and the storage m keeps from a previous build is
+// reused
+func (m *Matcher) compile(dictionary []string, ids []int32, nids int, opts []Option) error {
+	o := newOptions(opts)
+	rec := newBuildRecorder(&o)
+	if err := checkLimits(dictionary, &o); err != nil {
+		return err
+	}
+	var norm pipeline
+	words := dictionary
+	if len(o.normalizers) > 0 {
+		norm = pipeline(o.normalizers)
+		words = make([]string, len(dictionary))
+		for i, word := range dictionary {
+			words[i] = norm.apply(word)
+		}
+	}
+	var fold *caseFold
+	if len(o.caseInsensitive) > 0 {
+		f, folded, err := newCaseFold(words, o.caseInsensitive)
+		if err != nil {
+			return err
+		}
+		f.norm = norm
+		fold, words = f, folded
+	}
+	var equiv *equivalence
+	if len(o.equivalence) > 0 {
+		e, err := newEquivalence(o.equivalence)
+		if err != nil {
+			return err
+		}
+		if e != nil {
+			equiv = e
+			canonical := make([]string, len(words))
+			for i, word := range words {
+				canonical[i] = e.canonical(word)
+			}
+			words = canonical
+		}
+	}
+	if err := checkMemory(words, &o); err != nil {
+		return err
+	}
+	po, err := newPatternOptions(&o, nids)
+	if err != nil {
+		return err
+	}
+
+	// the arrays of a loaded matcher alias its buffer and cannot be reused;
+	// the counter keeps increasing so stale deduplication marks never match
+	states, edges, outputs := m.states, m.edges, m.outputs
+	if m.data != nil {
+		states, edges, outputs = nil, nil, nil
+	}
+	*m = Matcher{
+		counter: m.counter,
+		states:  states[:0],
+		edges:   edges[:0],
+		outputs: outputs[:0],
+		seen:    m.seen,
+		unmap:   m.unmap,
+		builder: m.builder,
+		build:   rec,
+
+		npatterns: max(len(dictionary), nids),
+		ids:       ids,
+		norm:      norm,
+		normKinds: o.normalizerKinds,
+		fold:      fold,
+		equiv:     equiv,
+	}
+	rec.lap(phasePrepare)
+	if err := m.buildTrie(words, &o); err != nil {
+		return err
+	}
+	if m.fold != nil {
+		m.fold.link(m, words)
+	}
+	if m.equiv != nil {
+		m.addAliases()
+	}
+	rec.lap(phaseLinks)
+	// the filter holds the q-grams of the canonical patterns, which the
+	// input of equivalent runes does not share
+	if o.bloomFilter && m.norm == nil && m.equiv == nil {
+		m.bloom = newBloomFilter(words, m.fold != nil)
+	}
+	fitBackend(m, &o)
+	m.setup(&o, po, nids)
+	rec.done(m)
+	return nil
+}
+
+// patternOptions are the options referring to patterns, checked against
+// the number of pattern IDs
+type patternOptions struct {
+	info           []PatternInfo
+	categories     *categories
+	validators     [][]Validator
+	validatorKinds []string
+	positions      *positions
+}
+
+// newPatternOptions checks the options referring to patterns against the
+// nids reported pattern IDs
+func newPatternOptions(o *options, nids int) (patternOptions, error) {
+	var po patternOptions
+	if len(o.info) > nids {
+		return po, fmt.Errorf("ahocorasick: metadata for %d patterns, the dictionary has %d", len(o.info), nids)
+	}
+	po.info = o.info
+	if o.categories != nil {
+		c, err := newCategories(o.categories, nids)
+		if err != nil {
+			return po, err
+		}
+		po.categories = c
+	}
+	if o.validators != nil {
+		v, err := newValidators(o.validators, nids)
+		if err != nil {
+			return po, err
+		}
+		po.validators = v
+		for _, pv := range o.validators {
+			po.validatorKinds = append(po.validatorKinds, fmt.Sprintf("%s %v", pv.kind, pv.patterns))
+		}
+	}
+	if o.positions != nil {
+		p, err := newPositions(o.positions, nids)
+		if err != nil {
+			return po, err
+		}
+		po.positions = p
+	}
+	return po, nil
+}
+
+// setup applies the options that do not depend on the words once the
+// automaton is built, nids being the number of reported pattern IDs
+func (m *Matcher) setup(o *options, po patternOptions, nids int) {
+	if o.minimize {
+		m.minimize()
+	}
+	m.backend = newBackend(m, o.backend)
+	m.initSkip()
+	if o.transitionCache > 0 {
+		m.cache = newTransitionCache(len(m.states), o.transitionCache)
+	}
+	m.overlap = o.overlap
+	m.info, m.categories, m.validators = po.info, po.categories, po.validators
+	m.validatorKinds, m.positions = po.validatorKinds, po.positions
+	if o.hitCounters {
+		m.hits = &HitCounters{counts: make([]atomic.Uint64, nids)}
+	}
+	if n := m.dedupKeys(); cap(m.seen) >= n {
+		m.seen = m.seen[:n]
+	} else {
+		m.seen = nil
+	}
+}
+
+// NewMatcherFromSet creates a matcher from a set of words
+// pattern indices follow the sorted order of the words, which is returned so
+// matched indices can be mapped back to the words
+func NewMatcherFromSet(set map[string]struct{}) (*Matcher, []string) {
+	dictionary := make([]string, 0, len(set))
+	for word := range set {
+		dictionary = append(dictionary, word)
+	}
+	sort.Strings(dictionary)
+	return NewStringMatcher(dictionary), dictionary
+}
+
+// NewMatcherFromMap creates a matcher from words associated with payloads
+// pattern indices follow the sorted order of the words, the returned slice
+// holds the payload of every pattern so matched indices index it directly
+func NewMatcherFromMap[T any](dictionary map[string]T) (*Matcher, []T) {
+	words := make([]string, 0, len(dictionary))
+	for word := range dictionary {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	payloads := make([]T, len(words))
+	for i, word := range words {
+		payloads[i] = dictionary[word]
+	}
+	return NewStringMatcher(words), payloads
+}
+
 // Match searches input byte slice for all matching dictionary words, returns indices of matches in dictionary
 // uses simple counter mechanism to prevent duplicate reporting of same match
 func (m *Matcher) Match(text []byte) []int {
-	return m.MatchString(string(text))
+	return m.MatchString(bytesToString(text))
 }
 
 // MatchString searches input string for all matching dictionary words, returns indices of matches in dictionary
 // uses simple counter mechanism to prevent duplicate reporting of same match
 func (m *Matcher) MatchString(text string) []int {
+	return m.AppendMatchString(make([]int, 0, 8), text)
+}
+
+// AppendMatch is like Match but appends the indices to dst and returns the
+// extended slice, so callers can reuse one buffer across calls and avoid
+// allocating a result for every text
+func (m *Matcher) AppendMatch(dst []int, text []byte) []int {
+	return m.AppendMatchString(dst, bytesToString(text))
+}
+
+// AppendMatchString is like MatchString but appends the indices to dst
+func (m *Matcher) AppendMatchString(dst []int, text string) []int {
 	m.counter++
-	return match(text, m.root, func(f *node) bool {
-		if f.counter != m.counter {
-			f.counter = m.counter
+	if m.seen == nil {
+		// allocated on first use so loading a matcher stays cheap
+		m.seen = make([]uint64, m.dedupKeys())
+	}
+	return m.match(dst, text, func(s uint32, _ int32) bool {
+		if m.seen[s] != m.counter {
+			m.seen[s] = m.counter
 			return true
 		}
 		return false
 	})
 }
 
-// match is the core matching logic, operating on runes
+// match is the core matching logic, operating on runes, hits are appended to dst
 // unique function is used for deduplication, preventing same match from being reported multiple times
-func match(text string, n *node, unique func(f *node) bool) []int {
-	hits := make([]int, 0, 8)
+func (m *Matcher) match(dst []int, text string, unique func(s uint32, index int32) bool) []int {
+	hits := dst
+	if m.tooShort(text) || m.screened(text) {
+		return hits
+	}
+	if m.overlap != ReportAll {
+		base := uint32(len(m.states))
+		for _, o := range m.resolveOverlaps(m.collect(text)) {
+			if unique(base+uint32(o.Pattern), int32(o.Pattern)) {
+				m.hits.add(int32(o.Pattern))
+				hits = append(hits, o.Pattern)
+			}
+		}
+		return hits
+	}
+	if m.generic() {
+		// patterns sharing a folded form share their state, so the
+		// deduplication keys of patterns follow the states
+		base := uint32(len(m.states))
+		m.find(text, func(out int32, _, _ int) bool {
+			if unique(base+uint32(out), out) {
+				m.hits.add(out)
+				hits = append(hits, int(out))
+			}
+			return true
+		})
+		return hits
+	}
+	off := m.disabledPatterns()
+	n := uint32(root)
 
 	// process input text rune by rune
-	for _, r := range text {
-		child, ok := n.child[r]
-
-		// if current node doesn't have child for this rune, follow fail chain
-		for !ok && !n.root {
-			n = n.fail
-			child, ok = n.child[r]
+	for i := 0; i < len(text); {
+		// at the root, jump to the next byte that can start a pattern
+		if i = m.skip(text, i, n); i == len(text) {
+			break
 		}
+		r, size := decodeRune(text, i)
+		i += size
 
-		// if found matching child node, move to that node
-		if ok {
-			n = child
-		}
+		// move to the child for this rune, following the fail chain if needed
+		n = m.step(n, r)
 
 		// check if current node is an output node (complete pattern match)
-		if n.output {
-			if unique(n) {
-				hits = append(hits, n.index)
+		if out := m.outputs[n]; out >= 0 {
+			if unique(n, out) && !off.has(out) {
+				m.hits.add(out)
+				hits = append(hits, int(out))
 			}
 		}
 
 		// check all possible suffix matches
 		// suffix chain contains all patterns ending at current position
-		f := n.suffix
-		for f != nil && !f.root {
-			if unique(f) {
-				hits = append(hits, f.index)
+		f := m.suffixLink(n)
+		for f != root {
+			if out := m.outputs[f]; unique(f, out) {
+				if !off.has(out) {
+					m.hits.add(out)
+					hits = append(hits, int(out))
+				}
 			} else {
 				break // if this suffix already reported, no need to check subsequent ones
 			}
-			f = f.suffix
+			f = m.suffixLink(f)
 		}
 	}
 	return hits
@@ -232,31 +802,40 @@ func match(text string, n *node, unique func(f *node) bool) []int {
 // MatchThreadSafe is the thread-safe version of Match, searches input byte slice
 // uses atomic operations and thread-local storage to ensure concurrency safety
 func (m *Matcher) MatchThreadSafe(text []byte) []int {
-	return m.MatchThreadSafeString(string(text))
+	return m.MatchThreadSafeString(bytesToString(text))
 }
 
 // MatchThreadSafeString is the thread-safe version of MatchString, searches input string
 // uses atomic operations and thread-local storage to ensure concurrency safety
 func (m *Matcher) MatchThreadSafeString(text string) []int {
-	var heap map[int]uint64
+	return m.AppendMatchThreadSafeString(make([]int, 0, 8), text)
+}
+
+// AppendMatchThreadSafe is the thread-safe version of AppendMatch
+func (m *Matcher) AppendMatchThreadSafe(dst []int, text []byte) []int {
+	return m.AppendMatchThreadSafeString(dst, bytesToString(text))
+}
+
+// AppendMatchThreadSafeString is the thread-safe version of AppendMatchString
+func (m *Matcher) AppendMatchThreadSafeString(dst []int, text string) []int {
+	var heap map[int32]uint64
 
 	// use atomic operation to get unique generation identifier
 	generation := atomic.AddUint64(&m.counter, 1)
-	n := m.root
 
 	// get or create deduplication map from memory pool
 	item := m.heap.Get()
 	if item == nil {
-		heap = make(map[int]uint64, len(m.trie))
+		heap = make(map[int32]uint64, len(m.states))
 	} else {
-		heap = item.(map[int]uint64)
+		heap = item.(map[int32]uint64)
 	}
 
 	// use thread-local heap for deduplication
-	hits := match(text, n, func(f *node) bool {
-		g := heap[f.index]
+	hits := m.match(dst, text, func(_ uint32, index int32) bool {
+		g := heap[index]
 		if g != generation {
-			heap[f.index] = generation
+			heap[index] = generation
 			return true
 		}
 		return false
@@ -270,29 +849,103 @@ func (m *Matcher) MatchThreadSafeString(text string) []int {
 // Contains checks if any dictionary word exists in the input byte slice
 // more efficient than Match as it only needs to determine existence without collecting all matches
 func (m *Matcher) Contains(text []byte) bool {
-	return m.ContainsString(string(text))
+	return m.ContainsString(bytesToString(text))
 }
 
 // ContainsString checks if any dictionary word exists in the input string
 // more efficient than Match as it only needs to determine existence without collecting all matches
 func (m *Matcher) ContainsString(text string) bool {
-	n := m.root
-	for _, r := range text {
-		child, ok := n.child[r]
+	return m.firstEnd(text) >= 0
+}
+
+// firstEnd returns the end offset of the occurrence completed first in
+// text, -1 if there is none
+func (m *Matcher) firstEnd(text string) int {
+	if m.tooShort(text) || m.screened(text) {
+		return -1
+	}
+	if m.generic() {
+		first := -1
+		m.find(text, func(_ int32, _, end int) bool {
+			first = end
+			return false
+		})
+		return first
+	}
+	off := m.disabledPatterns()
+	n := uint32(root)
+	for i := 0; i < len(text); {
+		if i = m.skip(text, i, n); i == len(text) {
+			break
+		}
+		r, size := decodeRune(text, i)
+		i += size
 
 		// follow fail chain to find match
-		for !ok && !n.root {
-			n = n.fail
-			child, ok = n.child[r]
+		n = m.step(n, r)
+
+		// check if match found (current node or any suffix)
+		if (m.outputs[n] >= 0 || m.suffixLink(n) != root) && m.enabledAt(n, off) {
+			return i
 		}
-		if ok {
-			n = child
+	}
+	return -1
+}
+
+// ContainsAll checks if every one of the given dictionary patterns occurs in the input byte slice
+// returns as soon as the last required pattern is found, for rules requiring co-occurrence of terms
+func (m *Matcher) ContainsAll(text []byte, patterns []int) bool {
+	return m.ContainsAllString(bytesToString(text), patterns)
+}
+
+// ContainsAllString checks if every one of the given dictionary patterns occurs in the input string
+// returns as soon as the last required pattern is found, for rules requiring co-occurrence of terms
+func (m *Matcher) ContainsAllString(text string, patterns []int) bool {
+	missing := make(map[int32]bool, len(patterns))
+	for _, p := range patterns {
+		missing[int32(p)] = true
+	}
+	// found removes a pattern from the missing set, reporting whether none is left
+	off := m.disabledPatterns()
+	found := func(out int32) bool {
+		if off.has(out) {
+			return false
+		}
+		delete(missing, out)
+		return len(missing) == 0
+	}
+	if len(missing) == 0 {
+		return true
+	}
+	if m.screened(text) {
+		return false
+	}
+	if m.generic() {
+		done := false
+		m.find(text, func(out int32, _, _ int) bool {
+			done = found(out)
+			return !done
+		})
+		return done
+	}
+
+	n := uint32(root)
+	for i := 0; i < len(text); {
+		if i = m.skip(text, i, n); i == len(text) {
+			break
 		}
+		r, size := decodeRune(text, i)
+		i += size
 
-		// check if match found (current node or any suffix)
-		if n.output || (n.suffix != nil && !n.suffix.root) {
+		n = m.step(n, r)
+		if out := m.outputs[n]; out >= 0 && found(out) {
 			return true
 		}
+		for f := m.suffixLink(n); f != root; f = m.suffixLink(f) {
+			if found(m.outputs[f]) {
+				return true
+			}
+		}
 	}
 	return false
 }
@@ -301,38 +954,52 @@ func (m *Matcher) ContainsString(text string) bool {
 // returns index of matching word in dictionary and boolean indicating if match was found
 // returns immediately upon finding first match, more efficient than Match()
 func (m *Matcher) MatchFirst(text []byte) (index int, ok bool) {
-	return m.MatchFirstString(string(text))
+	return m.MatchFirstString(bytesToString(text))
 }
 
 // MatchFirstString searches input string for the first matching dictionary word
 // returns index of matching word in dictionary and boolean indicating if match was found
 // returns immediately upon finding first match, more efficient than Match()
 func (m *Matcher) MatchFirstString(text string) (index int, ok bool) {
-	n := m.root
-	for _, r := range text {
-		child, exists := n.child[r]
+	if m.screened(text) {
+		return -1, false
+	}
+	if m.generic() {
+		index = -1
+		m.find(text, func(out int32, _, _ int) bool {
+			m.hits.add(out)
+			index, ok = int(out), true
+			return false
+		})
+		return index, ok
+	}
+	off := m.disabledPatterns()
+	n := uint32(root)
+	for i := 0; i < len(text); {
+		if i = m.skip(text, i, n); i == len(text) {
+			break
+		}
+		r, size := decodeRune(text, i)
+		i += size
 
 		// follow fail chain to find match
-		for !exists && !n.root {
-			n = n.fail
-			child, exists = n.child[r]
-		}
-		if exists {
-			n = child
-		}
+		n = m.step(n, r)
 
 		// check if current node is a complete match
-		if n.output {
-			return n.index, true // found match, exit immediately!
+		if out := m.outputs[n]; out >= 0 && !off.has(out) {
+			m.hits.add(out)
+			return int(out), true // found match, exit immediately!
 		}
 
 		// check for suffix match
-		f := n.suffix
-		if f != nil && !f.root {
-			// note: we only need to check first suffix, as it represents
-			// the longest possible suffix match at this position
-			// suffix chain is already flattened during build
-			return f.index, true // found suffix match, exit immediately!
+		// note: without disabled patterns we only need to check the first
+		// suffix, as it represents the longest possible suffix match at this
+		// position; suffix chain is already flattened during build
+		for f := m.suffixLink(n); f != root; f = m.suffixLink(f) {
+			if out := m.outputs[f]; !off.has(out) {
+				m.hits.add(out)
+				return int(out), true // found suffix match, exit immediately!
+			}
 		}
 	}
 
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 	"testing"
+
+	"github.com/itgcl/ahocorasick/gen"
 )
 
 func assert(t *testing.T, b bool) {
@@ -533,3 +535,94 @@ func BenchmarkLargeMatchThreadSafeWorks(b *testing.B) {
 		precomputed6.MatchThreadSafe(bytes2)
 	}
 }
+
+func TestNewMatcherFromSet(t *testing.T) {
+	m, words := NewMatcherFromSet(map[string]struct{}{"Steel": {}, "Man": {}, "Super": {}})
+	assert(t, len(words) == 3)
+	assert(t, words[0] == "Man")
+	assert(t, words[1] == "Steel")
+	assert(t, words[2] == "Super")
+
+	hits := m.MatchString("The Man Of Steel")
+	assert(t, len(hits) == 2)
+	assert(t, words[hits[0]] == "Man")
+	assert(t, words[hits[1]] == "Steel")
+}
+
+func TestNewMatcherFromMap(t *testing.T) {
+	m, payloads := NewMatcherFromMap(map[string]int{"Steel": 7, "Man": 3, "中文": 42})
+	hits := m.MatchString("The Man Of Steel 中文")
+	assert(t, len(hits) == 3)
+	assert(t, payloads[hits[0]] == 3)
+	assert(t, payloads[hits[1]] == 7)
+	assert(t, payloads[hits[2]] == 42)
+}
+
+func BenchmarkBuildLarge(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewStringMatcher(dictionary6)
+	}
+}
+
+func BenchmarkSyntheticCJK(b *testing.B) {
+	g := gen.New(gen.Config{Seed: 1, Words: 10000, CJKRatio: 0.5, CJKRange: 200})
+	dict := g.Dictionary()
+	text := g.Corpus(100000, dict, 0.05)
+	m := NewStringMatcher(dict)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.MatchString(text)
+	}
+}
+
+func TestCompactStorage(t *testing.T) {
+	m := NewStringMatcher(dictionary6)
+	assert(t, cap(m.states) == len(m.states))
+	assert(t, cap(m.outputs) == len(m.states))
+	assert(t, cap(m.edges) == len(m.edges))
+	assert(t, len(m.edges) == len(m.states)-1)
+
+	// leaves own no transitions
+	for i := range m.states {
+		s := &m.states[i]
+		if s.nedges == 0 {
+			assert(t, m.outputs[i] >= 0)
+		}
+	}
+}
+
+func TestAppendMatch(t *testing.T) {
+	m := NewStringMatcher([]string{"Superman", "uperman", "perman", "erman"})
+	buf := make([]int, 0, 16)
+	hits := m.AppendMatch(buf, []byte("The Man Of Steel: Superman"))
+	assert(t, len(hits) == 4)
+	assert(t, &hits[0] == &buf[:1][0])
+
+	// existing content is preserved
+	hits = m.AppendMatchThreadSafeString(hits, "Superman")
+	assert(t, len(hits) == 8)
+	assert(t, hits[4] == 0)
+	assert(t, hits[7] == 3)
+
+	hits = m.AppendMatchString(hits[:0], "nothing")
+	assert(t, len(hits) == 0)
+}
+
+func BenchmarkLargeAppendMatch(b *testing.B) {
+	b.ReportAllocs()
+	hits := make([]int, 0, 128)
+	for i := 0; i < b.N; i++ {
+		hits = precomputed6.AppendMatchThreadSafe(hits[:0], bytes2)
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	m := NewStringMatcher(dictionary)
+	assert(t, m.ContainsAll(bytes, []int{0, 2, 3}))
+	assert(t, m.ContainsAll(bytes, []int{1, 1}))
+	assert(t, !m.ContainsAll(bytes, []int{0, 4}))
+	assert(t, m.ContainsAllString("anything", nil))
+	assert(t, !m.ContainsAllString("Mac", []int{2}))
+	assert(t, m.ContainsAllString("Macintosh", []int{1, 2}))
+}
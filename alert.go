@@ -0,0 +1,114 @@
+// alert.go: aggregating occurrences into rate-based alerts, for log
+// monitoring.
+
+package ahocorasick
+
+import (
+	"sync"
+	"time"
+)
+
+// AlertRule tells an Aggregator when to raise an alert
+type AlertRule struct {
+	Threshold  int           // occurrences within Window raising an alert
+	Window     time.Duration // sliding window the occurrences are counted in
+	ByCategory bool          // count per category of the patterns, not per pattern
+}
+
+// Alert is raised by an Aggregator when a pattern or a category reaches the
+// threshold of its rule
+type Alert struct {
+	Pattern  int       // pattern counted, -1 when counting categories
+	Category string    // category counted, empty when counting patterns
+	First    time.Time // time of the first occurrence counted
+	Last     time.Time // time of the occurrence reaching the threshold
+}
+
+// Aggregator consumes a stream of occurrences and raises an alert when a
+// pattern, or a category with AlertRule.ByCategory, occurs Threshold times
+// within Window, so a monitor pages once for a burst instead of once per
+// log line; the count then restarts, so a sustained burst raises an alert
+// every Threshold occurrences
+// occurrences must be observed in time order; alerts are raised
+// synchronously, outside the lock of the aggregator, which is safe for
+// concurrent use
+type Aggregator struct {
+	m     *Matcher
+	rule  AlertRule
+	alert func(Alert)
+
+	mu     sync.Mutex
+	counts map[alertKey][]time.Time // times of the occurrences in the window
+	now    func() time.Time         // clock, replaced in tests
+}
+
+// alertKey is what occurrences are counted by
+type alertKey struct {
+	pattern  int
+	category string
+}
+
+// NewAggregator creates an aggregator of the occurrences of the patterns of
+// m, calling alert whenever rule is met; a threshold below 1 counts as 1
+func NewAggregator(m *Matcher, rule AlertRule, alert func(Alert)) *Aggregator {
+	rule.Threshold = max(rule.Threshold, 1)
+	return &Aggregator{m: m, rule: rule, alert: alert, counts: make(map[alertKey][]time.Time), now: time.Now}
+}
+
+// Observe counts an occurrence reported now, such as one passed to the
+// callback of FindReader
+func (a *Aggregator) Observe(o Match) {
+	a.ObserveAt(o.Pattern, a.now())
+}
+
+// Audit counts the occurrence of an audit event, so an aggregator can be the
+// hook of an Auditor
+func (a *Aggregator) Audit(e AuditEvent) {
+	a.ObserveAt(e.Pattern, a.now())
+}
+
+// ObserveString matches text and counts every occurrence found, returning
+// them
+func (a *Aggregator) ObserveString(text string) []Match {
+	matches := a.m.FindAllString(text)
+	at := a.now()
+	for _, o := range matches {
+		a.ObserveAt(o.Pattern, at)
+	}
+	return matches
+}
+
+// ObserveAt counts an occurrence of pattern at time at, for events carrying
+// their own timestamps
+func (a *Aggregator) ObserveAt(pattern int, at time.Time) {
+	var alerts []Alert
+	a.mu.Lock()
+	if a.rule.ByCategory {
+		for _, name := range a.m.PatternCategories(pattern) {
+			alerts = a.count(alertKey{pattern: -1, category: name}, at, alerts)
+		}
+	} else {
+		alerts = a.count(alertKey{pattern: pattern}, at, alerts)
+	}
+	a.mu.Unlock()
+	for _, alert := range alerts {
+		a.alert(alert)
+	}
+}
+
+// count records an occurrence under key, appending to alerts the alert it
+// raises, if any
+func (a *Aggregator) count(key alertKey, at time.Time, alerts []Alert) []Alert {
+	times := a.counts[key]
+	expired := 0
+	for expired < len(times) && at.Sub(times[expired]) >= a.rule.Window {
+		expired++
+	}
+	times = append(times[expired:], at)
+	if len(times) < a.rule.Threshold {
+		a.counts[key] = times
+		return alerts
+	}
+	delete(a.counts, key)
+	return append(alerts, Alert{Pattern: key.pattern, Category: key.category, First: times[0], Last: at})
+}
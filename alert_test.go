@@ -0,0 +1,54 @@
+// alert_test.go: tests for rate-based alerts
+
+package ahocorasick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	m := NewStringMatcher([]string{"error", "panic", "timeout"})
+	var alerts []Alert
+	a := NewAggregator(m, AlertRule{Threshold: 3, Window: time.Minute}, func(al Alert) {
+		alerts = append(alerts, al)
+	})
+	start := time.Unix(1700000000, 0)
+	a.now = func() time.Time { return start }
+
+	assert(t, len(a.ObserveString("error: timeout, error")) == 3 && len(alerts) == 0)
+	a.ObserveAt(0, start.Add(30*time.Second))
+	assert(t, len(alerts) == 1 && alerts[0].Pattern == 0 && alerts[0].Category == "")
+	assert(t, alerts[0].First.Equal(start) && alerts[0].Last.Equal(start.Add(30*time.Second)))
+
+	// the count restarts after an alert, and old occurrences expire
+	a.ObserveAt(0, start.Add(40*time.Second))
+	a.ObserveAt(0, start.Add(50*time.Second))
+	a.ObserveAt(0, start.Add(100*time.Second))
+	assert(t, len(alerts) == 1)
+	a.ObserveAt(0, start.Add(105*time.Second))
+	assert(t, len(alerts) == 2 && alerts[1].First.Equal(start.Add(50*time.Second)))
+
+	// streaming sources and audit hooks
+	a.now = func() time.Time { return start.Add(time.Hour) }
+	a.Observe(Match{Pattern: 1})
+	a.Audit(AuditEvent{Pattern: 1})
+	NewAuditor(m, a.Audit).FindAllString("log", "panic")
+	assert(t, len(alerts) == 3 && alerts[2].Pattern == 1)
+}
+
+func TestAggregatorByCategory(t *testing.T) {
+	m := NewStringMatcher([]string{"error", "panic", "timeout"},
+		WithCategories(map[string][]int{"crash": {1}, "failure": {0, 1}}))
+	var alerts []Alert
+	a := NewAggregator(m, AlertRule{Threshold: 2, Window: time.Minute, ByCategory: true}, func(al Alert) {
+		alerts = append(alerts, al)
+	})
+
+	a.ObserveString("timeout timeout error")
+	assert(t, len(alerts) == 0)
+	a.ObserveString("panic")
+	assert(t, len(alerts) == 1 && alerts[0].Pattern == -1 && alerts[0].Category == "failure")
+	a.ObserveString("panic")
+	assert(t, len(alerts) == 2 && alerts[1].Category == "crash")
+}
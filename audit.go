@@ -0,0 +1,87 @@
+// audit.go: a hook observing every reported occurrence, for compliance logs.
+
+package ahocorasick
+
+// AuditEvent describes an occurrence reported to a caller
+type AuditEvent struct {
+	TextID  string // identifier of the text given by the caller
+	Pattern int    // index of the pattern in the dictionary
+	Start   int    // byte offset of the first byte of the occurrence
+	End     int    // byte offset just after the last byte of the occurrence
+}
+
+// Auditor wraps a matcher so that every occurrence it reports is also passed
+// to a hook, together with an identifier of the text, which centralizes
+// compliance logging instead of scattering it across call sites
+// the hook is called synchronously, in the order occurrences are returned,
+// before the call returns; it must be safe for concurrent use if the
+// auditor is used concurrently
+type Auditor struct {
+	m    *Matcher
+	hook func(AuditEvent)
+}
+
+// NewAuditor wraps m with hook
+func NewAuditor(m *Matcher, hook func(AuditEvent)) *Auditor {
+	return &Auditor{m: m, hook: hook}
+}
+
+// NewChanAuditor wraps m with a hook sending the events to ch, which should
+// be buffered so a slow consumer only delays matching once the buffer is full
+// events are never dropped
+func NewChanAuditor(m *Matcher, ch chan<- AuditEvent) *Auditor {
+	return NewAuditor(m, func(e AuditEvent) { ch <- e })
+}
+
+// Matcher returns the wrapped matcher
+func (a *Auditor) Matcher() *Matcher {
+	return a.m
+}
+
+// FindAll is like FindAll of the matcher, auditing every occurrence under id
+func (a *Auditor) FindAll(id string, text []byte) []Match {
+	return a.FindAllString(id, bytesToString(text))
+}
+
+// FindAllString is like FindAll for a string input
+func (a *Auditor) FindAllString(id string, text string) []Match {
+	matches := a.m.FindAllString(text)
+	a.audit(id, matches)
+	return matches
+}
+
+// Match returns the patterns found in text like MatchThreadSafe of the
+// matcher, auditing every occurrence under id, repeated ones included
+func (a *Auditor) Match(id string, text []byte) []int {
+	return a.MatchString(id, bytesToString(text))
+}
+
+// MatchString is like Match for a string input
+func (a *Auditor) MatchString(id string, text string) []int {
+	var seen PatternSet
+	var hits []int
+	for _, o := range a.FindAllString(id, text) {
+		if !seen.Has(o.Pattern) {
+			seen.Add(o.Pattern)
+			hits = append(hits, o.Pattern)
+		}
+	}
+	return hits
+}
+
+// RedactString is like RedactString of the matcher, auditing every masked
+// occurrence under id
+func (a *Auditor) RedactString(id string, text string) (string, []Redaction) {
+	clean, report := a.m.RedactString(text)
+	for _, r := range report {
+		a.hook(AuditEvent{TextID: id, Pattern: r.Pattern, Start: r.Start, End: r.End})
+	}
+	return clean, report
+}
+
+// audit passes matches to the hook
+func (a *Auditor) audit(id string, matches []Match) {
+	for _, o := range matches {
+		a.hook(AuditEvent{TextID: id, Pattern: o.Pattern, Start: o.Start, End: o.End})
+	}
+}
@@ -0,0 +1,43 @@
+// audit_test.go: tests for the audit hook
+
+package ahocorasick
+
+import "testing"
+
+func TestAuditor(t *testing.T) {
+	var events []AuditEvent
+	a := NewAuditor(precomputed, func(e AuditEvent) { events = append(events, e) })
+
+	matches := a.FindAll("ua-1", bytes)
+	assert(t, len(matches) == len(events) && len(events) == 5)
+	for i, e := range events {
+		assert(t, e.TextID == "ua-1" && e.Pattern == matches[i].Pattern && e.Start == matches[i].Start)
+	}
+
+	events = nil
+	hits := a.MatchString("ua-2", sbytes)
+	assert(t, len(hits) == 4 && hits[0] == 0 && hits[3] == 3)
+	assert(t, len(events) == 5 && events[0].TextID == "ua-2")
+
+	events = nil
+	clean, report := a.RedactString("msg", "Mac and Safari")
+	assert(t, clean == "*** and ******" && len(report) == 2 && len(events) == 2)
+	assert(t, events[1] == AuditEvent{TextID: "msg", Pattern: 3, Start: 8, End: 14})
+
+	events = nil
+	assert(t, a.MatchString("clean", "nothing") == nil && events == nil)
+	assert(t, a.Matcher() == precomputed)
+}
+
+func TestChanAuditor(t *testing.T) {
+	ch := make(chan AuditEvent, 16)
+	a := NewChanAuditor(precomputed, ch)
+	a.Match("id", []byte("Mac Mac"))
+	close(ch)
+	n := 0
+	for e := range ch {
+		assert(t, e.TextID == "id" && e.Pattern == 1)
+		n++
+	}
+	assert(t, n == 2)
+}
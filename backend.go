@@ -0,0 +1,322 @@
+// backend.go: alternative representations of the goto function.
+//
+// The compiled automaton always keeps its transitions as per-state lists
+// sorted by rune, which is what is saved and what the walks over the trie
+// read. A backend is an index built over those lists once the automaton is
+// frozen, trading memory for cheaper lookups on the matching hot path.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Backend selects how the matcher looks up its transitions
+type Backend int
+
+const (
+	// BackendSorted searches the sorted transition lists of the states:
+	// scanned when short, binary searched otherwise; it needs no memory
+	// beyond the automaton and is the default
+	BackendSorted Backend = iota
+
+	// BackendMap looks transitions up in a hash table keyed by state and rune
+	BackendMap
+
+	// BackendDoubleArray stores the transitions in a double-array trie,
+	// finding any of them in constant time for little more memory than
+	// the lists, whatever the fan-out of the states
+	BackendDoubleArray
+
+	// BackendDense stores a full table of states by alphabet, the fastest
+	// lookup and by far the largest; only fit for small automata over small
+	// alphabets
+	BackendDense
+
+	// BackendAuto picks a backend from the shape of the automaton, see
+	// ChooseBackend
+	BackendAuto
+)
+
+// String returns the name of b
+func (b Backend) String() string {
+	switch b {
+	case BackendSorted:
+		return "sorted"
+	case BackendMap:
+		return "map"
+	case BackendDoubleArray:
+		return "double-array"
+	case BackendDense:
+		return "dense"
+	case BackendAuto:
+		return "auto"
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+// WithBackend selects the representation of the transitions used while
+// matching; all backends report the same matches
+// the backend is rebuilt from the transition lists, so it is not saved and
+// a loaded matcher uses BackendSorted
+func WithBackend(b Backend) Option {
+	return func(o *options) {
+		o.backend = b
+	}
+}
+
+// Backend returns the backend m uses, the one picked when BackendAuto was
+// requested
+func (m *Matcher) Backend() Backend {
+	if m.backend == nil {
+		return BackendSorted
+	}
+	return m.backend.kind()
+}
+
+// denseLimit is the largest table in bytes ChooseBackend picks BackendDense for
+const denseLimit = 1 << 20
+
+// ChooseBackend returns the backend suited to m: BackendDense when its
+// table stays under a mebibyte, BackendDoubleArray when branching states
+// hold more transitions on average than are scanned without a binary
+// search, BackendSorted otherwise
+// BackendMap is never chosen, a double array being faster for similar memory
+func ChooseBackend(m *Matcher) Backend {
+	return chooseBackend(m, denseLimit)
+}
+
+// chooseBackend is ChooseBackend picking BackendDense for tables of at most
+// dense bytes
+func chooseBackend(m *Matcher, dense int) Backend {
+	if len(m.edges) == 0 {
+		return BackendSorted
+	}
+	alpha := newAlphabet(m.edges)
+	if len(m.states)*(alpha.size+1)*int(unsafe.Sizeof(uint32(0))) <= dense {
+		return BackendDense
+	}
+	branching, fanout := 0, 0
+	for _, s := range m.states {
+		if s.nedges > 1 {
+			branching++
+			fanout += int(s.nedges)
+		}
+	}
+	if branching > 0 && fanout > 8*branching {
+		return BackendDoubleArray
+	}
+	return BackendSorted
+}
+
+// fitBackend resolves BackendAuto in o for m, only picking a dense table
+// that fits in the memory limit beside the automaton; an explicit
+// BackendDense is held to the limit before the build, see checkMemory
+func fitBackend(m *Matcher, o *options) {
+	if o.backend != BackendAuto || o.maxMemory <= 0 {
+		return
+	}
+	room := o.maxMemory - estimateMemory(len(m.states), len(m.edges))
+	o.backend = chooseBackend(m, min(denseLimit, room))
+}
+
+// backend is an index over the transition lists of a matcher
+type backend interface {
+	// next returns the state reached from state s by consuming rune r, if any
+	next(s uint32, r rune) (uint32, bool)
+
+	kind() Backend
+	bytes() int // memory held, for MemoryBytes
+}
+
+// newBackend returns the index of kind over the transitions of m, nil for
+// BackendSorted which needs none
+func newBackend(m *Matcher, kind Backend) backend {
+	if kind == BackendAuto {
+		kind = ChooseBackend(m)
+	}
+	switch kind {
+	case BackendMap:
+		return newMapBackend(m)
+	case BackendDoubleArray:
+		return newDoubleArray(m)
+	case BackendDense:
+		return newDenseTable(m)
+	}
+	return nil
+}
+
+// alphabet numbers the runes labelling transitions from 1, 0 standing for
+// runes no transition consumes
+type alphabet struct {
+	ascii [128]uint32
+	other map[rune]uint32
+	size  int
+}
+
+// newAlphabet returns the alphabet of edges
+func newAlphabet(edges []edge) *alphabet {
+	a := &alphabet{other: make(map[rune]uint32)}
+	for _, e := range edges {
+		if a.code(e.label) != 0 {
+			continue
+		}
+		a.size++
+		if e.label >= 0 && e.label < 128 {
+			a.ascii[e.label] = uint32(a.size)
+		} else {
+			a.other[e.label] = uint32(a.size)
+		}
+	}
+	return a
+}
+
+// code returns the number of r, 0 when no transition consumes it
+func (a *alphabet) code(r rune) uint32 {
+	if r >= 0 && r < 128 {
+		return a.ascii[r]
+	}
+	return a.other[r]
+}
+
+// bytes returns the memory held by a
+func (a *alphabet) bytes() int {
+	return int(unsafe.Sizeof(*a)) + len(a.other)*int(unsafe.Sizeof(rune(0))+unsafe.Sizeof(uint32(0)))
+}
+
+// mapBackend holds every transition in one hash table
+type mapBackend map[uint64]uint32
+
+func newMapBackend(m *Matcher) mapBackend {
+	t := make(mapBackend, len(m.edges))
+	for s, st := range m.states {
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			t[transitionKey(uint32(s), e.label)] = e.next
+		}
+	}
+	return t
+}
+
+func (t mapBackend) next(s uint32, r rune) (uint32, bool) {
+	c, ok := t[transitionKey(s, r)]
+	return c, ok
+}
+
+func (t mapBackend) kind() Backend { return BackendMap }
+
+func (t mapBackend) bytes() int {
+	return len(t) * int(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(uint32(0)))
+}
+
+// doubleArray places the transitions of state s over rune r at slot
+// base[s] + code(r), the slot recording its owner so lookups of absent
+// transitions fail; slots of different states interleave to keep the
+// arrays little larger than the number of transitions
+type doubleArray struct {
+	alpha  *alphabet
+	base   []uint32 // first slot of every state, less one
+	check  []uint32 // owner of every slot plus one, 0 when free
+	target []uint32 // state every slot leads to
+}
+
+func newDoubleArray(m *Matcher) *doubleArray {
+	da := &doubleArray{alpha: newAlphabet(m.edges), base: make([]uint32, len(m.states))}
+	var codes []uint32
+	free := 1 // first slot that may be free
+	end := 0  // slots in use
+	for s, st := range m.states {
+		out := m.edges[st.edges : st.edges+st.nedges]
+		if len(out) == 0 {
+			continue
+		}
+		codes = codes[:0]
+		for _, e := range out {
+			codes = append(codes, da.alpha.code(e.label))
+		}
+		for free < len(da.check) && da.check[free] != 0 {
+			free++
+		}
+		// the first transition goes to the first free slot past the base
+		b := max(free-int(codes[0]), 0)
+		for !da.fits(b, codes) {
+			b++
+		}
+		da.base[s] = uint32(b)
+		for k, c := range codes {
+			t := b + int(c)
+			if t >= len(da.check) {
+				n := max(2*len(da.check), t+1)
+				da.check = append(da.check, make([]uint32, n-len(da.check))...)
+				da.target = append(da.target, make([]uint32, n-len(da.target))...)
+			}
+			da.check[t] = uint32(s) + 1
+			da.target[t] = out[k].next
+			end = max(end, t+1)
+		}
+	}
+	da.check, da.target = exact(da.check[:end]), exact(da.target[:end])
+	return da
+}
+
+// fits reports whether the slots of codes past base b are all free
+func (da *doubleArray) fits(b int, codes []uint32) bool {
+	for _, c := range codes {
+		if t := b + int(c); t < len(da.check) && da.check[t] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (da *doubleArray) next(s uint32, r rune) (uint32, bool) {
+	c := da.alpha.code(r)
+	if c == 0 {
+		return 0, false
+	}
+	t := da.base[s] + c
+	if int(t) < len(da.check) && da.check[t] == s+1 {
+		return da.target[t], true
+	}
+	return 0, false
+}
+
+func (da *doubleArray) kind() Backend { return BackendDoubleArray }
+
+func (da *doubleArray) bytes() int {
+	return da.alpha.bytes() + (len(da.base)+len(da.check)+len(da.target))*int(unsafe.Sizeof(uint32(0)))
+}
+
+// denseTable holds the target of every state over every rune of the
+// alphabet, 0 when there is no transition, the root being no target
+type denseTable struct {
+	alpha *alphabet
+	table []uint32
+}
+
+func newDenseTable(m *Matcher) *denseTable {
+	d := &denseTable{alpha: newAlphabet(m.edges)}
+	d.table = make([]uint32, len(m.states)*d.alpha.size)
+	for s, st := range m.states {
+		row := d.table[s*d.alpha.size:]
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			row[d.alpha.code(e.label)-1] = e.next
+		}
+	}
+	return d
+}
+
+func (d *denseTable) next(s uint32, r rune) (uint32, bool) {
+	c := d.alpha.code(r)
+	if c == 0 {
+		return 0, false
+	}
+	t := d.table[int(s)*d.alpha.size+int(c)-1]
+	return t, t != root
+}
+
+func (d *denseTable) kind() Backend { return BackendDense }
+
+func (d *denseTable) bytes() int {
+	return d.alpha.bytes() + len(d.table)*int(unsafe.Sizeof(uint32(0)))
+}
@@ -0,0 +1,95 @@
+// backend_test.go: tests for the transition backends
+
+package ahocorasick
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var backends = []Backend{BackendSorted, BackendMap, BackendDoubleArray, BackendDense}
+
+func TestBackends(t *testing.T) {
+	// a root with many children, so sorted lists are binary searched
+	var wide []string
+	for r := 'a'; r <= 'z'; r++ {
+		wide = append(wide, string(r)+"x", "q"+string(r))
+	}
+	cases := []struct {
+		dict []string
+		text string
+		opts []Option
+	}{
+		{dictionary, sbytes, nil},
+		{dictionary6, sbytes2, nil},
+		{wide, "the quick brown fox jumps over the lazy dog qzx", nil},
+		{[]string{"中文", "文本", "本"}, "中文文本，日本", nil},
+		{[]string{"Mac", "SAFARI"}, sbytes, []Option{WithCaseInsensitive(0, 1)}},
+		{[]string{"café"}, "cafe café cafè", []Option{WithEquivalence("eéè")}},
+	}
+	for _, c := range cases {
+		want := NewStringMatcher(c.dict, c.opts...).FindAllString(c.text)
+		for _, b := range backends {
+			m := NewStringMatcher(c.dict, append(c.opts, WithBackend(b))...)
+			assert(t, m.Backend() == b)
+			got := m.FindAllString(c.text)
+			assert(t, len(got) == len(want))
+			for i := range got {
+				assert(t, got[i] == want[i])
+			}
+		}
+	}
+
+	// backends are rebuilt with the automaton and not saved
+	m := NewStringMatcher(dictionary, WithBackend(BackendDoubleArray))
+	assert(t, m.Rebuild(dictionary2, WithBackend(BackendMap)) == nil && m.Backend() == BackendMap)
+	assert(t, m.MatchString("bingbot Yandex")[1] == 3)
+	var sb strings.Builder
+	assert(t, precomputedWith(BackendDense).Save(&sb) == nil)
+	loaded, err := LoadBytes([]byte(sb.String()))
+	assert(t, err == nil && loaded.Backend() == BackendSorted && len(loaded.FindAllString(sbytes)) == 5)
+	assert(t, BackendDense.String() == "dense" && Backend(9).String() == "Backend(9)")
+}
+
+func precomputedWith(b Backend) *Matcher {
+	return NewStringMatcher(dictionary, WithBackend(b))
+}
+
+func TestChooseBackend(t *testing.T) {
+	assert(t, ChooseBackend(precomputed) == BackendDense)
+	assert(t, NewStringMatcher(dictionary, WithBackend(BackendAuto)).Backend() == BackendDense)
+	assert(t, ChooseBackend(NewStringMatcher(nil)) == BackendSorted)
+
+	// a big alphabet rules out a table, fan-out decides between the others
+	var wide, narrow []string
+	for i := range 3000 {
+		wide = append(wide, string(rune(0x4e00+i))+string(rune(0x4e00+i%50)))
+	}
+	for i := range 20000 {
+		// binary numbers over two runes, so states have at most two children
+		narrow = append(narrow, strings.Map(func(r rune) rune { return r - '0' + 0x4e00 }, fmt.Sprintf("%b", 1<<24|i*7919)))
+	}
+	assert(t, ChooseBackend(NewStringMatcher(wide)) == BackendDoubleArray)
+	assert(t, ChooseBackend(NewStringMatcher(narrow)) == BackendSorted)
+
+	// the index is counted by MemoryBytes
+	assert(t, precomputedWith(BackendDense).MemoryBytes() > precomputed.MemoryBytes())
+}
+
+func BenchmarkBackends(b *testing.B) {
+	var dict []string
+	for i := range 2000 {
+		dict = append(dict, fmt.Sprintf("w%03dx", i))
+	}
+	text := strings.Repeat(sbytes2+" w123x w999x ", 4)
+	for _, be := range backends {
+		m := NewStringMatcher(dict, WithBackend(be))
+		b.Run(be.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				m.FindAllString(text)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// bloom.go: an optional probabilistic screen answering "definitely no match"
+// before the automaton runs.
+//
+// Every occurrence of a pattern starts with the first q runes of that
+// pattern, q being the length of the shortest pattern capped at maxGram. The
+// filter holds these leading q-grams; a text none of whose q-grams is in the
+// filter cannot contain any pattern. False positives only cost the exact scan
+// that would have run anyway.
+
+package ahocorasick
+
+import "unicode/utf8"
+
+// maxGram caps the length in runes of the q-grams stored in the filter
+const maxGram = 4
+
+// bloomBitsPerGram sizes the filter, giving about 2% false positives with
+// bloomHashes probes
+const (
+	bloomBitsPerGram = 10
+	bloomHashes      = 4
+)
+
+// WithBloomFilter screens every input with a bloom filter over the leading
+// q-grams of the patterns and skips the automaton for texts that definitely
+// contain no pattern, which speeds up the common case of clean text when
+// matches are rare; the filter is not saved by Save and is not used when
+// the input is normalized
+func WithBloomFilter() Option {
+	return func(o *options) {
+		o.bloomFilter = true
+	}
+}
+
+// bloomFilter is a set of q-grams with false positives
+type bloomFilter struct {
+	fold bool // input runes are case-folded like the patterns
+	q    int
+	mask uint64
+	bits []uint64
+}
+
+// newBloomFilter returns the filter of the dictionary, nil when it is empty or
+// holds the empty pattern, which matches everywhere; fold tells whether the
+// dictionary is case-folded
+func newBloomFilter(dictionary []string, fold bool) *bloomFilter {
+	q := maxGram
+	for _, word := range dictionary {
+		q = min(q, utf8.RuneCountInString(word))
+	}
+	if len(dictionary) == 0 || q == 0 {
+		return nil
+	}
+	size := uint64(64)
+	for size < uint64(len(dictionary))*bloomBitsPerGram {
+		size <<= 1
+	}
+	b := &bloomFilter{fold: fold, q: q, mask: size - 1, bits: make([]uint64, size/64)}
+	for _, word := range dictionary {
+		var gram [maxGram]rune
+		i := 0
+		for _, r := range word {
+			if i == q {
+				break
+			}
+			gram[i] = r
+			i++
+		}
+		b.add(gramHash(gram[:q]))
+	}
+	return b
+}
+
+// gramHash hashes a q-gram of runes
+func gramHash(gram []rune) uint64 {
+	h := uint64(14695981039346656037)
+	for _, r := range gram {
+		h ^= uint64(uint32(r))
+		h *= 1099511628211
+	}
+	// final mix so the low bits used for probing depend on every rune
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h
+}
+
+// add inserts the q-gram with hash h
+func (b *bloomFilter) add(h uint64) {
+	step := h>>32 | 1
+	for i := 0; i < bloomHashes; i++ {
+		b.bits[(h&b.mask)/64] |= 1 << (h & 63)
+		h += step
+	}
+}
+
+// has reports whether the q-gram with hash h may be in the filter
+func (b *bloomFilter) has(h uint64) bool {
+	step := h>>32 | 1
+	for i := 0; i < bloomHashes; i++ {
+		if b.bits[(h&b.mask)/64]&(1<<(h&63)) == 0 {
+			return false
+		}
+		h += step
+	}
+	return true
+}
+
+// mayMatch reports whether text holds a q-gram of the filter, decoding runes
+// like the automaton does so invalid bytes are screened consistently
+func (b *bloomFilter) mayMatch(text string) bool {
+	var ring [maxGram]rune
+	var gram [maxGram]rune
+	n := 0
+	for _, r := range text {
+		if b.fold {
+			r = foldRune(r)
+		}
+		ring[n%maxGram] = r
+		n++
+		if n < b.q {
+			continue
+		}
+		for i := 0; i < b.q; i++ {
+			gram[i] = ring[(n-b.q+i)%maxGram]
+		}
+		if b.has(gramHash(gram[:b.q])) {
+			return true
+		}
+	}
+	return false
+}
+
+// screened reports whether the bloom filter proves that text contains no
+// pattern, always false without a filter
+func (m *Matcher) screened(text string) bool {
+	return m.bloom != nil && !m.bloom.mayMatch(text)
+}
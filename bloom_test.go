@@ -0,0 +1,46 @@
+// bloom_test.go: tests for the bloom filter screen
+
+package ahocorasick
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	m := NewStringMatcher(dictionary6, WithBloomFilter())
+	assert(t, m.bloom != nil)
+	assert(t, m.bloom.q == 1) // "9" and "a" are single runes
+
+	m = NewStringMatcher([]string{"Mozilla", "Safari", "中文测试"}, WithBloomFilter())
+	assert(t, m.bloom.q == 4)
+	assert(t, m.screened("Firefox and Chrome"))
+	assert(t, !m.screened("I use Safari"))
+	assert(t, !m.screened("中文测试"))
+	assert(t, m.screened("Moz"))
+	assert(t, len(m.MatchString("Firefox and Chrome")) == 0)
+	assert(t, len(m.MatchString("I use Safari")) == 1)
+
+	// the empty pattern matches everything, nothing can be screened
+	assert(t, NewStringMatcher([]string{"", "a"}, WithBloomFilter()).bloom == nil)
+	assert(t, NewStringMatcher(nil, WithBloomFilter()).bloom == nil)
+}
+
+func TestBloomFilterExact(t *testing.T) {
+	dict := []string{"abc", "bcd", "中文x", "\xffab", "dab"}
+	m := NewStringMatcher(dict, WithBloomFilter())
+	plain := NewStringMatcher(dict)
+	alphabet := []string{"a", "b", "c", "d", "中", "文", "x", "\xff"}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		text := ""
+		for j := rng.Intn(16); j > 0; j-- {
+			text += alphabet[rng.Intn(len(alphabet))]
+		}
+		got, want := m.FindAllString(text), plain.FindAllString(text)
+		assert(t, len(got) == len(want))
+		assert(t, m.ContainsString(text) == plain.ContainsString(text))
+		assert(t, len(m.MatchString(text)) == len(plain.MatchString(text)))
+		assert(t, m.ContainsAllString(text, []int{0, 1}) == plain.ContainsAllString(text, []int{0, 1}))
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !ahocorasick_unsafe
+
+// bridge.go: conversion of []byte input to the string form the matcher
+// scans. This default version copies; build with -tags ahocorasick_unsafe to
+// use the zero-copy version in bridge_unsafe.go instead.
+
+package ahocorasick
+
+// bytesToString converts b for the duration of a matching call
+func bytesToString(b []byte) string {
+	return string(b)
+}
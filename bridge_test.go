@@ -0,0 +1,21 @@
+// bridge_test.go: tests for the []byte input conversion, run them with and
+// without the ahocorasick_unsafe build tag
+
+package ahocorasick
+
+import "testing"
+
+func TestBytesToString(t *testing.T) {
+	assert(t, bytesToString(nil) == "")
+	assert(t, bytesToString([]byte{}) == "")
+	assert(t, bytesToString([]byte("中文 text")) == "中文 text")
+
+	// results must not depend on the input buffer after the call returns
+	buf := []byte("The Man Of Steel: Superman")
+	m := NewStringMatcher([]string{"Superman", "Steel"})
+	hits := m.Match(buf)
+	copy(buf, "xxxxxxxxxxxxxxxxxxxxxxxxxx")
+	assert(t, len(hits) == 2)
+	assert(t, hits[0] == 1)
+	assert(t, hits[1] == 0)
+}
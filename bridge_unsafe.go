@@ -0,0 +1,15 @@
+//go:build ahocorasick_unsafe
+
+// bridge_unsafe.go: zero-copy conversion of []byte input, selected with the
+// ahocorasick_unsafe build tag. The matcher never retains its input, so this
+// is safe as long as the caller does not modify the buffer while a matching
+// call is running.
+
+package ahocorasick
+
+import "unsafe"
+
+// bytesToString aliases b as a string without copying it
+func bytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
@@ -0,0 +1,33 @@
+// budget.go: bounded matching reporting whether results were truncated.
+
+package ahocorasick
+
+// Budget bounds the work and the results of a matching call, 0 meaning
+// unlimited
+type Budget struct {
+	MaxMatches int // occurrences returned, further ones are only counted
+	MaxBytes   int // bytes of input scanned, the rest is left unscanned
+}
+
+// Result holds the occurrences found within a budget, with what it cost
+// a clean result and one where matching gave up early differ by Truncated,
+// which matters when the absence of matches drives a decision
+type Result struct {
+	Matches   []Match
+	Truncated bool // occurrences were dropped or input was left unscanned
+	Dropped   int  // occurrences found beyond MaxMatches
+	Scanned   int  // bytes of input scanned
+}
+
+// FindAllBudget is like FindAll within budget b: only the occurrences ending
+// in the first MaxBytes bytes of text, moved back to a rune start, are
+// looked for and at most MaxMatches of them are returned; the overlap policy
+// is applied before dropping occurrences
+func (m *Matcher) FindAllBudget(text []byte, b Budget) Result {
+	return m.FindAllBudgetString(bytesToString(text), b)
+}
+
+// FindAllBudgetString is like FindAllBudget for a string input
+func (m *Matcher) FindAllBudgetString(text string, b Budget) Result {
+	return m.FindAllWithString(text, MatchOptions{Budget: b})
+}
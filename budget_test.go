@@ -0,0 +1,39 @@
+// budget_test.go: tests for bounded matching
+
+package ahocorasick
+
+import "testing"
+
+func TestFindAllBudget(t *testing.T) {
+	all := precomputed6.FindAll(bytes2)
+
+	r := precomputed6.FindAllBudget(bytes2, Budget{})
+	assert(t, !r.Truncated && r.Dropped == 0 && r.Scanned == len(bytes2))
+	assert(t, len(r.Matches) == len(all))
+
+	r = precomputed6.FindAllBudget(bytes2, Budget{MaxMatches: 10})
+	assert(t, r.Truncated && len(r.Matches) == 10 && r.Dropped == len(all)-10)
+	for i := range r.Matches {
+		assert(t, r.Matches[i] == all[i])
+	}
+
+	r = precomputed6.FindAllBudget(bytes2, Budget{MaxMatches: len(all)})
+	assert(t, !r.Truncated && len(r.Matches) == len(all))
+
+	// the scan stops at a rune start
+	text := "Mac中Mac"
+	r = precomputed.FindAllBudgetString(text, Budget{MaxBytes: 5})
+	assert(t, r.Truncated && r.Scanned == 3 && len(r.Matches) == 1)
+	r = precomputed.FindAllBudgetString(text, Budget{MaxBytes: 100})
+	assert(t, !r.Truncated && r.Scanned == len(text) && len(r.Matches) == 2)
+
+	// clean text within budget
+	r = precomputed.FindAllBudgetString("nothing here", Budget{MaxMatches: 1, MaxBytes: 100})
+	assert(t, !r.Truncated && len(r.Matches) == 0)
+
+	// the overlap policy applies before dropping
+	m := NewStringMatcher([]string{"Mac", "Macintosh"}, WithOverlapPolicy(LongestWins))
+	r = m.FindAllBudgetString("Macintosh Mac Mac", Budget{MaxMatches: 2})
+	assert(t, r.Truncated && r.Dropped == 1 && len(r.Matches) == 2)
+	assert(t, r.Matches[0].Pattern == 1 && r.Matches[1].Start == 10)
+}
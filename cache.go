@@ -0,0 +1,55 @@
+// cache.go: memoization of transitions resolved through the fail chain.
+
+package ahocorasick
+
+import "sync/atomic"
+
+// transitionCache memoizes, per state, transitions that are not in the goto
+// function and had to be resolved by walking the fail chain
+// every state holds an immutable list replaced by copy-on-write, so lookups
+// are a single atomic load and concurrent matching needs no lock
+type transitionCache struct {
+	limit  int
+	states []atomic.Pointer[[]edge]
+}
+
+// newTransitionCache creates an empty cache for n states keeping at most
+// limit transitions per state
+func newTransitionCache(n, limit int) *transitionCache {
+	return &transitionCache{limit: limit, states: make([]atomic.Pointer[[]edge], n)}
+}
+
+// get returns the memoized target of consuming r in state s
+func (c *transitionCache) get(s uint32, r rune) (uint32, bool) {
+	p := c.states[s].Load()
+	if p == nil {
+		return 0, false
+	}
+	for _, e := range *p {
+		if e.label == r {
+			return e.next, true
+		}
+	}
+	return 0, false
+}
+
+// put memoizes that consuming r in state s leads to next, unless the memo of
+// s is already full
+func (c *transitionCache) put(s uint32, r rune, next uint32) {
+	for {
+		p := c.states[s].Load()
+		var old []edge
+		if p != nil {
+			old = *p
+		}
+		if len(old) >= c.limit {
+			return
+		}
+		memo := make([]edge, len(old), len(old)+1)
+		copy(memo, old)
+		memo = append(memo, edge{label: r, next: next})
+		if c.states[s].CompareAndSwap(p, &memo) {
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// cache_test.go: tests for the transition cache
+
+package ahocorasick
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTransitionCache(t *testing.T) {
+	m := NewStringMatcher(dictionary6, WithTransitionCache(4))
+	for i := 0; i < 3; i++ {
+		hits := m.Match(bytes2)
+		assert(t, len(hits) == 105)
+	}
+
+	cached := 0
+	for i := range m.cache.states {
+		if p := m.cache.states[i].Load(); p != nil {
+			assert(t, len(*p) <= 4)
+			cached += len(*p)
+		}
+	}
+	assert(t, cached > 0)
+
+	m = NewStringMatcher([]string{"a", "ab", "bc", "bca", "c", "caa"}, WithTransitionCache(16))
+	hits := m.Match([]byte("abccab"))
+	assert(t, len(hits) == 4)
+	assert(t, hits[0] == 0)
+	assert(t, hits[1] == 1)
+	assert(t, hits[2] == 2)
+	assert(t, hits[3] == 4)
+	assert(t, m.ContainsString("xxbca"))
+	assert(t, !m.ContainsString("xxyz"))
+}
+
+func TestTransitionCacheConcurrently(t *testing.T) {
+	m := NewStringMatcher(dictionary6, WithTransitionCache(8))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert(t, len(m.MatchThreadSafe(bytes2)) == 105)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkLargeMatchTransitionCache(b *testing.B) {
+	m := NewStringMatcher(dictionary6, WithTransitionCache(16))
+	for i := 0; i < b.N; i++ {
+		m.Match(bytes2)
+	}
+}
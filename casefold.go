@@ -0,0 +1,129 @@
+// casefold.go: per-pattern case sensitivity within a single automaton.
+//
+// When some patterns are declared case-insensitive the automaton is built
+// over the case-folded form of every pattern and the input is folded rune by
+// rune as it is scanned. An occurrence of a case-sensitive pattern is only
+// reported when the original input bytes equal the pattern exactly. Patterns
+// that only differ by case share their final state, so every state outputs
+// a chain of patterns rather than a single one.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithCaseInsensitive declares the patterns with the given indices
+// case-insensitive, the other patterns keep matching exactly
+// folding is done rune by rune with simple Unicode case folding, so "straße"
+// does not match "STRASSE"
+func WithCaseInsensitive(patterns ...int) Option {
+	return func(o *options) {
+		o.caseInsensitive = append(o.caseInsensitive, patterns...)
+	}
+}
+
+// caseFold holds the per-pattern case flags of a folding matcher
+type caseFold struct {
+	words     []string // the patterns, normalized but not folded
+	norm      pipeline // normalization applied to the input before comparing
+	sensitive []bool   // whether pattern i only matches exactly
+	next      []int32  // next pattern with the same folded form, -1 at the end
+}
+
+// foldRune maps r to the representative of its case folding orbit
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return r
+	}
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+// foldString folds every rune of s, keeping the number of runes
+func foldString(s string) string {
+	b := make([]rune, 0, len(s))
+	for _, r := range s {
+		b = append(b, foldRune(r))
+	}
+	return string(b)
+}
+
+// newCaseFold validates the case flags and returns the folded dictionary to
+// build the automaton from
+func newCaseFold(dictionary []string, insensitive []int) (*caseFold, []string, error) {
+	f := &caseFold{
+		words:     dictionary,
+		sensitive: make([]bool, len(dictionary)),
+		next:      make([]int32, len(dictionary)),
+	}
+	for i := range f.sensitive {
+		f.sensitive[i] = true
+	}
+	for _, i := range insensitive {
+		if i < 0 || i >= len(dictionary) {
+			return nil, nil, fmt.Errorf("ahocorasick: case flag for pattern %d out of range", i)
+		}
+		f.sensitive[i] = false
+	}
+	folded := make([]string, len(dictionary))
+	for i, word := range dictionary {
+		folded[i] = foldString(word)
+	}
+	return f, folded, nil
+}
+
+// link chains the patterns sharing a folded form in ascending order and
+// makes the first one the output of their state
+func (f *caseFold) link(m *Matcher, folded []string) {
+	groups := make(map[string][]int32)
+	for i, word := range folded {
+		groups[word] = append(groups[word], int32(i))
+	}
+	for _, g := range groups {
+		sort.Slice(g, func(i, j int) bool { return g[i] < g[j] })
+		for k, p := range g {
+			f.next[p] = -1
+			if k+1 < len(g) {
+				f.next[p] = g[k+1]
+			}
+		}
+	}
+	for s, out := range m.outputs {
+		if out >= 0 {
+			m.outputs[s] = groups[folded[out]][0]
+		}
+	}
+}
+
+// dedupKeys returns the number of keys used to deduplicate hits: states, and
+// when hits are not reported per state one key per pattern after them
+func (m *Matcher) dedupKeys() int {
+	if m.generic() || m.overlap != ReportAll {
+		return len(m.states) + m.npatterns
+	}
+	return len(m.states)
+}
+
+// emit reports the patterns of the chain starting at out that accept the
+// occurrence text[start:end], until emit returns false
+func (f *caseFold) emit(out int32, text string, start, end int, emit func(pattern int32, start, end int) bool) bool {
+	span := text[start:end]
+	if f.norm != nil {
+		span = f.norm.apply(span)
+	}
+	for p := out; p >= 0; p = f.next[p] {
+		if f.sensitive[p] && span != f.words[p] {
+			continue
+		}
+		if !emit(p, start, end) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+// casefold_test.go: tests for per-pattern case sensitivity
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaseInsensitive(t *testing.T) {
+	dict := []string{"Mozilla", "safari", "Mac", "MAC", "ſtraße"}
+	m := NewStringMatcher(dict, WithCaseInsensitive(1, 4))
+
+	hits := m.MatchString("MOZILLA, Safari, SAFARI, mac, MAC, STRASSE, Straße")
+	assert(t, len(hits) == 3)
+	assert(t, hits[0] == 1)
+	assert(t, hits[1] == 3)
+	assert(t, hits[2] == 4)
+
+	// patterns sharing a folded form are reported independently
+	hits = m.MatchString("Mac and MAC")
+	assert(t, len(hits) == 2)
+	assert(t, hits[0] == 2)
+	assert(t, hits[1] == 3)
+	assert(t, len(m.MatchThreadSafeString("Mac and MAC")) == 2)
+	assert(t, len(m.MatchScratchString(m.NewScratch(), "Mac and MAC")) == 2)
+
+	matches := m.FindAllString("SAFARI mozilla Mozilla")
+	assert(t, len(matches) == 2)
+	assert(t, matches[0] == Match{Pattern: 1, Start: 0, End: 6})
+	assert(t, matches[1] == Match{Pattern: 0, Start: 15, End: 22})
+
+	assert(t, !m.ContainsString("mozilla mac"))
+	assert(t, m.ContainsString("mozilla SaFaRi"))
+	i, ok := m.MatchFirstString("mac MAC")
+	assert(t, ok && i == 3)
+	assert(t, m.ContainsAllString("SAFARI on a Mac", []int{1, 2}))
+	assert(t, !m.ContainsAllString("SAFARI on a mac", []int{1, 2}))
+	assert(t, m.MatchSetString("safari MAC").Len() == 2)
+
+	// the input is folded before the skip loop and the bloom filter
+	m = NewStringMatcher([]string{"kelvin"}, WithCaseInsensitive(0), WithBloomFilter())
+	assert(t, m.ContainsString("KELVIN"))
+
+	_, err := Compile(dict, WithCaseInsensitive(5))
+	assert(t, err != nil)
+	assert(t, m.Save(&strings.Builder{}) == ErrNotSerializable)
+}
@@ -0,0 +1,105 @@
+// category.go: named groups of patterns that can be switched off per call.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"sort"
+)
+
+// maxCategories is the number of categories a matcher can hold, one bit of
+// a category mask each
+const maxCategories = 64
+
+// categories holds the category mask of every pattern
+type categories struct {
+	names []string       // category names in sorted order, bit i is names[i]
+	bits  map[string]int // bit of every name
+	masks []uint64       // categories of every pattern
+}
+
+// WithCategories groups patterns into named categories, such as
+// "mild-profanity", mapping every name to pattern indices; a pattern may
+// belong to several categories, and at most 64 categories are supported
+// categories can then be switched off per call with WithoutCategories
+func WithCategories(categories map[string][]int) Option {
+	return func(o *options) {
+		o.categories = categories
+	}
+}
+
+// newCategories computes the category mask of each of the npatterns patterns
+func newCategories(byName map[string][]int, npatterns int) (*categories, error) {
+	if len(byName) > maxCategories {
+		return nil, fmt.Errorf("ahocorasick: %d categories, at most %d are supported", len(byName), maxCategories)
+	}
+	c := &categories{bits: make(map[string]int, len(byName)), masks: make([]uint64, npatterns)}
+	for name := range byName {
+		c.names = append(c.names, name)
+	}
+	sort.Strings(c.names)
+	for bit, name := range c.names {
+		c.bits[name] = bit
+		for _, p := range byName[name] {
+			if p < 0 || p >= npatterns {
+				return nil, fmt.Errorf("ahocorasick: pattern %d of category %q out of range", p, name)
+			}
+			c.masks[p] |= 1 << uint(bit)
+		}
+	}
+	return c, nil
+}
+
+// mask returns the mask of the named categories, unknown names are ignored
+func (c *categories) mask(names []string) uint64 {
+	var mask uint64
+	if c == nil {
+		return 0
+	}
+	for _, name := range names {
+		if bit, ok := c.bits[name]; ok {
+			mask |= 1 << uint(bit)
+		}
+	}
+	return mask
+}
+
+// Categories returns the names of the categories of the matcher in sorted
+// order, nil unless it was built with WithCategories
+func (m *Matcher) Categories() []string {
+	if m.categories == nil {
+		return nil
+	}
+	return append([]string(nil), m.categories.names...)
+}
+
+// PatternCategories returns the names of the categories pattern i belongs
+// to, in sorted order
+func (m *Matcher) PatternCategories(i int) []string {
+	c := m.categories
+	if c == nil || i < 0 || i >= len(c.masks) {
+		return nil
+	}
+	var names []string
+	for bit, name := range c.names {
+		if c.masks[i]&(1<<uint(bit)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// WithoutCategories returns a view of the matcher ignoring every pattern
+// that belongs to one of the named categories, e.g. to allow mild profanity
+// on adult channels; uncategorized patterns are always reported
+// creating the view costs nothing, occurrences are tested against the
+// category mask of their pattern as they are reported
+func (m *Matcher) WithoutCategories(names ...string) *Subset {
+	return &Subset{m: m, excluded: m.categories.mask(names)}
+}
+
+// WithoutCategories returns a view of s that also ignores the patterns of
+// the named categories
+func (s *Subset) WithoutCategories(names ...string) *Subset {
+	return &Subset{m: s.m, allowed: s.allowed, excluded: s.excluded | s.m.categories.mask(names)}
+}
@@ -0,0 +1,61 @@
+// category_test.go: tests for pattern categories
+
+package ahocorasick
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	words := []string{"damn", "heck", "scam", "free money", "hello"}
+	m := NewStringMatcher(words, WithCategories(map[string][]int{
+		"mild-profanity": {0, 1},
+		"spam":           {2, 3},
+		"fraud":          {2},
+	}))
+	cats := m.Categories()
+	assert(t, len(cats) == 3 && cats[0] == "fraud" && cats[1] == "mild-profanity" && cats[2] == "spam")
+
+	text := "hello, damn this scam, free money"
+	assert(t, len(m.MatchString(text)) == 4)
+
+	hits := m.WithoutCategories("mild-profanity").MatchString(text)
+	assert(t, len(hits) == 3)
+	assert(t, hits[0] == 4 && hits[1] == 2 && hits[2] == 3)
+
+	// a pattern is ignored when any of its categories is
+	hits = m.WithoutCategories("fraud", "unknown").MatchString(text)
+	assert(t, len(hits) == 3)
+	for _, h := range hits {
+		assert(t, h != 2)
+	}
+	assert(t, !m.WithoutCategories("mild-profanity", "spam").ContainsString("damn scam"))
+	assert(t, m.WithoutCategories().ContainsString("damn"))
+
+	// combined with an index restriction
+	hits = m.Subset([]int{0, 2, 4}).WithoutCategories("spam").MatchString(text)
+	assert(t, len(hits) == 2 && hits[0] == 4 && hits[1] == 0)
+
+	assert(t, NewStringMatcher(words).Categories() == nil)
+	assert(t, len(NewStringMatcher(words).WithoutCategories("spam").MatchString(text)) == 4)
+}
+
+func TestCategoriesErrors(t *testing.T) {
+	_, err := Compile([]string{"a"}, WithCategories(map[string][]int{"x": {1}}))
+	assert(t, err != nil)
+
+	many := make(map[string][]int)
+	for i := 0; i <= maxCategories; i++ {
+		many[fmt.Sprint(i)] = []int{0}
+	}
+	_, err = Compile([]string{"a"}, WithCategories(many))
+	assert(t, err != nil)
+}
+
+func TestPatternCategories(t *testing.T) {
+	m := NewStringMatcher([]string{"a", "b"}, WithCategories(map[string][]int{"x": {0}, "y": {0}}))
+	assert(t, len(m.PatternCategories(0)) == 2 && m.PatternCategories(0)[0] == "x")
+	assert(t, m.PatternCategories(1) == nil && m.PatternCategories(2) == nil)
+	assert(t, precomputed.PatternCategories(0) == nil)
+}
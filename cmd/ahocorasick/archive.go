@@ -0,0 +1,169 @@
+// archive.go: reading the members of zip, tar and gzip inputs.
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math"
+	"os"
+)
+
+// archiveSep separates the name of an archive from the path of a member,
+// as in "bundle.zip!docs/readme.txt"
+const archiveSep = "!"
+
+// errTooLarge is returned when an input expands to more than the limit
+var errTooLarge = errors.New("decompressed size over the limit, see -max-size")
+
+// openInput calls fn with every file of input name read from r: the input
+// itself, or the members of the archives it is, down to depth nested
+// archives; one gzip stream per level is decompressed without counting as
+// a level, so the members of "logs.tar.gz" are named like those of
+// "logs.tar", while gzip streams nested in it count as archives
+// members that are archives beyond depth are passed to fn as they are
+// at most maxSize bytes are decompressed or extracted from r, 0 for no
+// limit, so archive bombs fail with errTooLarge
+func openInput(name string, r io.Reader, depth int, maxSize int64, fn func(name string, r io.Reader) error) error {
+	o := &opener{fn: fn, left: maxSize}
+	if maxSize <= 0 {
+		o.left = math.MaxInt64
+	}
+	return o.open(name, r, depth, false)
+}
+
+// opener expands the archives of one input within its size budget
+type opener struct {
+	fn   func(name string, r io.Reader) error
+	left int64 // bytes that may still be expanded
+}
+
+// open calls fn with the files of input name read from r, gunzipped
+// telling whether r was already decompressed at this level
+func (o *opener) open(name string, r io.Reader, depth int, gunzipped bool) error {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(512) // shorter inputs are not archives, errors resurface on read
+	switch {
+	case isGzip(head) && !gunzipped:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return o.open(name, o.limit(zr), depth, true)
+	case depth > 0 && isGzip(head):
+		// a stream decompressing to another one is a nested archive, which
+		// bounds gzip quines decompressing to themselves
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return o.open(name, o.limit(zr), depth-1, true)
+	case depth > 0 && isZip(head):
+		return o.openZip(name, r, br, depth)
+	case depth > 0 && isTar(head):
+		tr := tar.NewReader(br)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if h.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := o.open(name+archiveSep+h.Name, tr, depth-1, false); err != nil {
+				return err
+			}
+		}
+	}
+	return o.fn(name, br)
+}
+
+// openZip calls open with the files of the zip archive read from br, which
+// is read into memory as zip archives are indexed at their end, unless r,
+// which br buffers, is a file that can be read at any offset
+func (o *opener) openZip(name string, r io.Reader, br *bufio.Reader, depth int) error {
+	var zr *zip.Reader
+	var err error
+	if f, ok := r.(*os.File); ok {
+		var fi os.FileInfo
+		if fi, err = f.Stat(); err == nil {
+			zr, err = zip.NewReader(f, fi.Size())
+		}
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(o.limit(br)); err == nil {
+			zr, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = o.open(name+archiveSep+f.Name, o.limit(rc), depth-1, false)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limit returns r counting what is read from it against the budget of o
+func (o *opener) limit(r io.Reader) io.Reader {
+	return &budgetReader{r: r, o: o}
+}
+
+// budgetReader fails with errTooLarge once its opener is over budget
+type budgetReader struct {
+	r io.Reader
+	o *opener
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	if b.o.left <= 0 {
+		// the input may end right at the limit
+		var one [1]byte
+		if n, err := b.r.Read(one[:]); n == 0 {
+			return 0, err
+		}
+		return 0, errTooLarge
+	}
+	if int64(len(p)) > b.o.left {
+		p = p[:b.o.left]
+	}
+	n, err := b.r.Read(p)
+	b.o.left -= int64(n)
+	return n, err
+}
+
+// isGzip reports whether head starts a gzip stream
+func isGzip(head []byte) bool {
+	return len(head) >= 3 && head[0] == 0x1f && head[1] == 0x8b && head[2] == 8
+}
+
+// isZip reports whether head starts a zip archive, empty ones included
+func isZip(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PK\x03\x04")) || bytes.HasPrefix(head, []byte("PK\x05\x06"))
+}
+
+// isTar reports whether head is the header block of a POSIX or GNU tar file
+func isTar(head []byte) bool {
+	return len(head) >= 262 && bytes.Equal(head[257:262], []byte("ustar"))
+}
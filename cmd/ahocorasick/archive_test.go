@@ -0,0 +1,134 @@
+// archive_test.go: tests for reading archive members
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTarGz returns a gzip compressed tar archive of files, by name
+func makeTarGz(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for i := 0; i < len(files); i += 2 {
+		assert(t, tw.WriteHeader(&tar.Header{Name: files[i], Mode: 0o644, Size: int64(len(files[i+1])), Typeflag: tar.TypeReg}) == nil)
+		_, err := tw.Write([]byte(files[i+1]))
+		assert(t, err == nil)
+	}
+	assert(t, tw.Close() == nil && zw.Close() == nil)
+	return buf.Bytes()
+}
+
+// makeZip returns a zip archive of files, by name
+func makeZip(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		assert(t, err == nil)
+		_, err = w.Write([]byte(files[i+1]))
+		assert(t, err == nil)
+	}
+	assert(t, zw.Close() == nil)
+	return buf.Bytes()
+}
+
+func TestOpenInput(t *testing.T) {
+	inner := makeTarGz(t, "logs/a.txt", "alpha", "logs/b.txt", "beta")
+	bundle := makeZip(t, "readme.txt", "hello", "logs.tar.gz", string(inner))
+
+	read := func(data []byte, depth int) map[string]string {
+		files := make(map[string]string)
+		err := openInput("bundle.zip", bytes.NewReader(data), depth, 0, func(name string, r io.Reader) error {
+			b, err := io.ReadAll(r)
+			files[name] = string(b)
+			return err
+		})
+		assert(t, err == nil)
+		return files
+	}
+
+	files := read(bundle, 2)
+	assert(t, len(files) == 3)
+	assert(t, files["bundle.zip!readme.txt"] == "hello")
+	assert(t, files["bundle.zip!logs.tar.gz!logs/a.txt"] == "alpha")
+	assert(t, files["bundle.zip!logs.tar.gz!logs/b.txt"] == "beta")
+
+	// archives beyond the depth are passed as they are, decompressed
+	files = read(bundle, 1)
+	assert(t, len(files) == 2 && files["bundle.zip!readme.txt"] == "hello")
+	tarData := files["bundle.zip!logs.tar.gz"]
+	assert(t, len(tarData) > 0 && isTar([]byte(tarData)))
+
+	files = read(bundle, 0)
+	assert(t, len(files) == 1 && files["bundle.zip"] == string(bundle))
+	files = read([]byte("plain text"), 3)
+	assert(t, len(files) == 1 && files["bundle.zip"] == "plain text")
+
+	// corrupt archives are reported
+	err := openInput("bad.zip", bytes.NewReader(bundle[:40]), 1, 0, func(string, io.Reader) error { return nil })
+	assert(t, err != nil)
+}
+
+// gzipped returns data compressed by layers nested gzip streams
+func gzipped(t *testing.T, data []byte, layers int) []byte {
+	for range layers {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write(data)
+		assert(t, err == nil && zw.Close() == nil)
+		data = buf.Bytes()
+	}
+	return data
+}
+
+func TestOpenInputLimits(t *testing.T) {
+	read := func(name string, r io.Reader, depth int, maxSize int64) (map[string]string, error) {
+		files := make(map[string]string)
+		err := openInput(name, r, depth, maxSize, func(name string, r io.Reader) error {
+			b, err := io.ReadAll(r)
+			files[name] = string(b)
+			return err
+		})
+		return files, err
+	}
+
+	// nested gzip streams count as archives, so a stream decompressing to
+	// itself cannot recurse forever
+	nested := gzipped(t, []byte("secret"), 10)
+	files, err := read("x.gz", bytes.NewReader(nested), 3, 0)
+	assert(t, err == nil && len(files) == 1)
+	assert(t, isGzip([]byte(files["x.gz"])) && bytes.Equal([]byte(files["x.gz"]), gzipped(t, []byte("secret"), 6)))
+	files, err = read("x.gz", bytes.NewReader(nested), 9, 0)
+	assert(t, err == nil && files["x.gz"] == "secret")
+	files, err = read("x.gz", bytes.NewReader(nested), 0, 0)
+	assert(t, err == nil && isGzip([]byte(files["x.gz"])))
+
+	// decompression bombs stop at the limit, inputs of its size pass
+	bomb := gzipped(t, make([]byte, 1<<20), 1)
+	_, err = read("bomb.gz", bytes.NewReader(bomb), 1, 1<<16)
+	assert(t, errors.Is(err, errTooLarge))
+	_, err = read("bomb.gz", bytes.NewReader(bomb), 1, 1<<20)
+	assert(t, err == nil)
+	zipBomb := makeZip(t, "zeros", string(make([]byte, 1<<20)))
+	_, err = read("bomb.zip", bytes.NewReader(zipBomb), 1, 1<<16)
+	assert(t, errors.Is(err, errTooLarge))
+
+	// zip files are read in place
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	assert(t, os.WriteFile(path, makeZip(t, "a.txt", "alpha", "b.txt", "beta"), 0o644) == nil)
+	f, err := os.Open(path)
+	assert(t, err == nil)
+	defer f.Close()
+	files, err = read(path, f, 1, 16)
+	assert(t, err == nil && len(files) == 2 && files[path+"!b.txt"] == "beta")
+}
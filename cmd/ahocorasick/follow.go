@@ -0,0 +1,176 @@
+// follow.go: the follow mode of match, scanning files as they grow.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itgcl/ahocorasick"
+)
+
+// notifyContext returns the context stopping follow mode, done on interrupt
+var notifyContext = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// follow scans the files at paths like tail -f until interrupted: each is
+// read to its end, then polled every interval for appended data, which is
+// fed to FindReader so occurrences split across writes are found; results
+// are written as soon as found, and the exit status is that of scan
+// files replaced or truncated, as by log rotation, are not reopened
+func (s *scanner) follow(rw *resultWriter, interval time.Duration) int {
+	paths := s.flags.Args()
+	if len(paths) == 0 {
+		fmt.Fprintln(s.stderr, "ahocorasick: -follow needs files to follow")
+		return 2
+	}
+	ctx, stop := notifyContext()
+	defer stop()
+
+	var mu sync.Mutex
+	found, failed := false, false
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			mu.Lock()
+			fmt.Fprintln(s.stderr, "ahocorasick:", err)
+			failed = true
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer f.Close()
+			var werr error
+			span := int64(s.m.MaxPatternLen() * utf8.UTFMax)
+			lines := &lineTracker{
+				r:      &followReader{ctx: ctx, r: f, interval: interval},
+				starts: []int64{0},
+				keep:   span + utf8.UTFMax, // and a rune FindReader left incomplete
+			}
+			err := s.m.FindReader(lines, func(o ahocorasick.Match) bool {
+				e := s.entries[o.Pattern]
+				line, column := lines.position(int64(o.Start), span)
+				r := result{
+					File:     path,
+					Line:     line,
+					Column:   column,
+					Start:    int64(o.Start),
+					End:      int64(o.End),
+					Pattern:  e.word,
+					Category: category(e.file),
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				found = true
+				if werr = rw.write(r); werr == nil {
+					werr = rw.flush()
+				}
+				return werr == nil
+			})
+			if err == nil {
+				err = werr
+			}
+			if err != nil {
+				mu.Lock()
+				fmt.Fprintf(s.stderr, "ahocorasick: %s: %v\n", path, err)
+				failed = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	switch {
+	case failed:
+		return 2
+	case found:
+		return 0
+	}
+	return 1
+}
+
+// followReader reads a file that is being appended to: at its end, Read
+// waits for more data instead of returning io.EOF, until ctx is done
+type followReader struct {
+	ctx      context.Context
+	r        io.Reader
+	interval time.Duration
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(f.interval):
+		}
+	}
+}
+
+// lineTracker records where the lines of the data read through it start,
+// so the occurrences FindReader reports can be given a line and column
+// lines are forgotten once behind the occurrences still to be reported,
+// so following a quiet file for days does not grow its memory
+type lineTracker struct {
+	r      io.Reader
+	offset int64   // bytes read
+	starts []int64 // offsets of the lines still needed
+	first  int     // number of the line starting at starts[0], less one
+
+	// keep is how many bytes before the unread input occurrences not yet
+	// reported can start, FindReader reporting those ending in a read
+	// before the next one; 0 keeps every line
+	keep int64
+}
+
+func (t *lineTracker) Read(p []byte) (int, error) {
+	if t.keep > 0 {
+		t.forget(t.offset - t.keep)
+	}
+	n, err := t.r.Read(p)
+	for i, c := range p[:n] {
+		if c == '\n' {
+			t.starts = append(t.starts, t.offset+int64(i)+1)
+		}
+	}
+	t.offset += int64(n)
+	return n, err
+}
+
+// position returns the line and the byte column, both counted from 1, of
+// offset, then forgets the lines ending more than span bytes before it,
+// which later occurrences cannot start in as they end after this one
+func (t *lineTracker) position(offset, span int64) (line, column int) {
+	at := func(off int64) int {
+		return sort.Search(len(t.starts), func(i int) bool { return t.starts[i] > off }) - 1
+	}
+	k := at(offset)
+	line, column = t.first+k+1, int(offset-t.starts[k])+1
+	t.forget(offset - span)
+	return line, column
+}
+
+// forget drops the lines ending before offset
+func (t *lineTracker) forget(offset int64) {
+	drop := sort.Search(len(t.starts), func(i int) bool { return t.starts[i] > offset }) - 1
+	if drop > 0 {
+		// copied down so the dropped lines do not pin the array
+		t.starts = t.starts[:copy(t.starts, t.starts[drop:])]
+		t.first += drop
+	}
+}
@@ -0,0 +1,108 @@
+// follow_test.go: tests for the follow mode of match
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// syncBuilder is a strings.Builder safe for concurrent use
+type syncBuilder struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *syncBuilder) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuilder) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestFollow(t *testing.T) {
+	dir := t.TempDir()
+	dict := filepath.Join(dir, "words.txt")
+	assert(t, os.WriteFile(dict, []byte("password\nsecret\n"), 0o644) == nil)
+	log := filepath.Join(dir, "app.log")
+	assert(t, os.WriteFile(log, []byte("start\nthe secret\n"), 0o644) == nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func(f func() (context.Context, context.CancelFunc)) { notifyContext = f }(notifyContext)
+	notifyContext = func() (context.Context, context.CancelFunc) { return ctx, cancel }
+
+	var out, errs syncBuilder
+	status := make(chan int)
+	go func() {
+		status <- run([]string{"match", "-dict", dict, "-follow", "-poll", "1ms", log}, nil, &out, &errs)
+	}()
+	wait := func(want string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for out.String() != want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		assert(t, out.String() == want)
+	}
+	wait(log + ":2:5: secret [10-16]\n")
+
+	// an occurrence split across two appends is found once it completes
+	f, err := os.OpenFile(log, os.O_APPEND|os.O_WRONLY, 0)
+	assert(t, err == nil)
+	defer f.Close()
+	_, err = f.WriteString("x\nuser pass")
+	assert(t, err == nil)
+	time.Sleep(20 * time.Millisecond)
+	_, err = f.WriteString("word\n")
+	assert(t, err == nil)
+	wait(log + ":2:5: secret [10-16]\n" + log + ":4:6: password [24-32]\n")
+
+	cancel()
+	assert(t, <-status == 0 && errs.String() == "")
+
+	var plain strings.Builder
+	assert(t, run([]string{"match", "-dict", dict, "-follow"}, strings.NewReader(""), &plain, &plain) == 2)
+}
+
+func TestLineTracker(t *testing.T) {
+	lt := &lineTracker{r: strings.NewReader("ab\ncd\n\nefgh\n"), starts: []int64{0}}
+	buf := make([]byte, 3)
+	for {
+		if _, err := lt.Read(buf); err != nil {
+			break
+		}
+	}
+	for _, c := range []struct {
+		offset       int64
+		line, column int
+	}{{0, 1, 1}, {4, 2, 2}, {6, 3, 1}, {7, 4, 1}, {10, 4, 4}} {
+		line, column := lt.position(c.offset, 2)
+		assert(t, line == c.line && column == c.column)
+	}
+	assert(t, len(lt.starts) == 2 && lt.first == 3)
+}
+
+func TestLineTrackerQuiet(t *testing.T) {
+	// lines without occurrences are forgotten as the input is read
+	text := strings.Repeat("quiet line\n", 10000) + "x alert\n"
+	lt := &lineTracker{r: iotest.HalfReader(strings.NewReader(text)), starts: []int64{0}, keep: 8}
+	buf := make([]byte, 64)
+	for {
+		if _, err := lt.Read(buf); err != nil {
+			break
+		}
+		assert(t, len(lt.starts) <= 10)
+	}
+	line, column := lt.position(int64(len(text)-6), 8)
+	assert(t, line == 10001 && column == 3)
+}
@@ -0,0 +1,116 @@
+// format.go: printing match results as text, JSON or CSV.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// formats lists the values of the -format flag of match
+var formats = []string{"text", "json", "ndjson", "csv"}
+
+// result is an occurrence found by match
+type result struct {
+	File     string `json:"file"`   // input, with the path of the archive member
+	Line     int    `json:"line"`   // counted from 1
+	Column   int    `json:"column"` // in bytes, counted from 1
+	Start    int64  `json:"start"`  // byte offsets in the file or member
+	End      int64  `json:"end"`
+	Pattern  string `json:"pattern"`
+	Category string `json:"category"` // dictionary file name without extension
+}
+
+// csvHeader is the first record of the csv format
+var csvHeader = []string{"file", "line", "column", "start", "end", "pattern", "category"}
+
+// resultWriter prints results in one of formats
+type resultWriter struct {
+	format string
+	w      *bufio.Writer
+	csv    *csv.Writer
+	n      int // results written
+}
+
+// newResultWriter returns a writer of results to w in format
+func newResultWriter(format string, w io.Writer) (*resultWriter, error) {
+	rw := &resultWriter{format: format, w: bufio.NewWriter(w)}
+	switch format {
+	case "text", "json", "ndjson":
+	case "csv":
+		rw.csv = csv.NewWriter(rw.w)
+		if err := rw.csv.Write(csvHeader); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q, want one of %s", format, strings.Join(formats, ", "))
+	}
+	return rw, nil
+}
+
+// write prints r
+func (rw *resultWriter) write(r result) error {
+	defer func() { rw.n++ }()
+	switch rw.format {
+	case "json", "ndjson":
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if rw.format == "json" {
+			sep := ",\n"
+			if rw.n == 0 {
+				sep = "[\n"
+			}
+			rw.w.WriteString(sep)
+		}
+		rw.w.Write(b)
+		if rw.format == "ndjson" {
+			rw.w.WriteByte('\n')
+		}
+	case "csv":
+		return rw.csv.Write([]string{r.File, strconv.Itoa(r.Line), strconv.Itoa(r.Column),
+			strconv.FormatInt(r.Start, 10), strconv.FormatInt(r.End, 10), r.Pattern, r.Category})
+	default:
+		fmt.Fprintf(rw.w, "%s:%d:%d: %s [%d-%d]\n", r.File, r.Line, r.Column, r.Pattern, r.Start, r.End)
+	}
+	return nil
+}
+
+// flush writes the buffered results
+func (rw *resultWriter) flush() error {
+	if rw.csv != nil {
+		rw.csv.Flush()
+		if err := rw.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return rw.w.Flush()
+}
+
+// close ends the output, the JSON array included, and flushes it
+func (rw *resultWriter) close() error {
+	switch rw.format {
+	case "json":
+		if rw.n == 0 {
+			rw.w.WriteString("[")
+		}
+		rw.w.WriteString("\n]\n")
+	}
+	return rw.flush()
+}
+
+// category returns the category of the words of dictionary file name
+func category(name string) string {
+	base := filepath.Base(name)
+	if stem := strings.TrimSuffix(base, filepath.Ext(base)); stem != "" {
+		return stem
+	}
+	return base
+}
@@ -0,0 +1,60 @@
+// format_test.go: tests for the output formats of match
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchFormats(t *testing.T) {
+	dir := t.TempDir()
+	secrets, names := filepath.Join(dir, "secrets.txt"), filepath.Join(dir, "names.list")
+	assert(t, os.WriteFile(secrets, []byte("password\n"), 0o644) == nil)
+	assert(t, os.WriteFile(names, []byte("Ré\"mi, Jr\n"), 0o644) == nil)
+	input := "password: x\nRé\"mi, Jr password\n"
+	want := []result{
+		{File: "<stdin>", Line: 1, Column: 1, Start: 0, End: 8, Pattern: "password", Category: "secrets"},
+		{File: "<stdin>", Line: 2, Column: 1, Start: 12, End: 22, Pattern: "Ré\"mi, Jr", Category: "names"},
+		{File: "<stdin>", Line: 2, Column: 12, Start: 23, End: 31, Pattern: "password", Category: "secrets"},
+	}
+	match := func(format string) string {
+		var out, errs strings.Builder
+		status := run([]string{"match", "-dict", secrets, "-dict", names, "-format", format}, strings.NewReader(input), &out, &errs)
+		assert(t, status == 0 && errs.Len() == 0)
+		return out.String()
+	}
+
+	var got []result
+	assert(t, json.Unmarshal([]byte(match("json")), &got) == nil)
+	assert(t, len(got) == len(want))
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(match("ndjson")))
+	var n int
+	for ; sc.Scan(); n++ {
+		var r result
+		assert(t, json.Unmarshal(sc.Bytes(), &r) == nil && r == want[n])
+	}
+	assert(t, n == len(want))
+
+	records, err := csv.NewReader(strings.NewReader(match("csv"))).ReadAll()
+	assert(t, err == nil && len(records) == 4 && strings.Join(records[0], ",") == strings.Join(csvHeader, ","))
+	assert(t, strings.Join(records[2], "|") == "<stdin>|2|1|12|22|Ré\"mi, Jr|names")
+
+	assert(t, strings.HasPrefix(match("text"), "<stdin>:1:1: password [0-8]\n"))
+
+	// no results still make a valid JSON document
+	var out, errs strings.Builder
+	assert(t, run([]string{"match", "-dict", secrets, "-format", "json"}, strings.NewReader("none"), &out, &errs) == 1)
+	assert(t, json.Unmarshal([]byte(out.String()), &got) == nil && len(got) == 0)
+	assert(t, run([]string{"match", "-dict", secrets, "-format", "xml"}, nil, &out, &errs) == 2)
+	assert(t, strings.Contains(errs.String(), "unknown format"))
+}
@@ -0,0 +1,122 @@
+// lint.go: the lint command, checking dictionaries for likely mistakes.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/itgcl/ahocorasick"
+)
+
+// issue is a problem found by lint, printed as a JSON line with -json
+type issue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"` // duplicate, variant, shadowed, utf8 or short
+	Pattern string `json:"pattern"`
+	Related string `json:"related,omitempty"` // file:line of the conflicting entry
+	Message string `json:"message"`
+
+	index int // entry the issue is about, for ordering
+}
+
+// runLint reports duplicates, case and width variants, entries containing
+// another entry, invalid UTF-8 and short entries; the exit status is 1 when
+// an issue is found
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print one JSON object per issue")
+	minRunes := flags.Int("min-runes", 3, "report entries shorter than this many runes")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: ahocorasick lint [-json] [-min-runes n] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	entries, err := readDictionaries(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, "ahocorasick:", err)
+		return 2
+	}
+
+	issues := lint(entries, *minRunes)
+	enc := json.NewEncoder(stdout)
+	for _, is := range issues {
+		if *asJSON {
+			if err := enc.Encode(is); err != nil {
+				fmt.Fprintln(stderr, "ahocorasick:", err)
+				return 2
+			}
+			continue
+		}
+		fmt.Fprintf(stdout, "%s:%d: %s: %q %s\n", is.File, is.Line, is.Kind, is.Pattern, is.Message)
+	}
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// lint returns the issues of entries in entry order
+func lint(entries []entry, minRunes int) []issue {
+	var issues []issue
+	report := func(i int, kind string, related int, format string, a ...any) {
+		e := entries[i]
+		is := issue{File: e.file, Line: e.line, Kind: kind, Pattern: e.word, Message: fmt.Sprintf(format, a...), index: i}
+		if related >= 0 {
+			is.Related = entries[related].location()
+		}
+		issues = append(issues, is)
+	}
+
+	// malformed entries are left out of the other checks, their pattern
+	// index in words mapping back to their entry
+	var words []string
+	var index []int
+	for i, e := range entries {
+		if !utf8.ValidString(e.word) {
+			report(i, "utf8", -1, "is not valid UTF-8")
+			continue
+		}
+		if n := utf8.RuneCountInString(e.word); n < minRunes {
+			report(i, "short", -1, "has %d runes, fewer than %d", n, minRunes)
+		}
+		words = append(words, e.word)
+		index = append(index, i)
+	}
+
+	a := ahocorasick.Analyze(words)
+	for _, group := range a.Duplicates {
+		for _, p := range group[1:] {
+			report(index[p], "duplicate", index[group[0]], "duplicates %s", entries[index[group[0]]].location())
+		}
+	}
+	for _, group := range a.Variants {
+		for _, p := range group[1:] {
+			report(index[p], "variant", index[group[0]], "differs from %s only by case or width", entries[index[group[0]]].location())
+		}
+	}
+
+	// an entry containing another one never changes whether a text matches
+	pruned, mapping := ahocorasick.PruneWithMapping(words)
+	first := make(map[string]int, len(pruned))
+	for p := len(words) - 1; p >= 0; p-- {
+		first[words[p]] = p
+	}
+	for p, word := range words {
+		if cover := pruned[mapping[p]]; cover != word {
+			c := first[cover]
+			report(index[p], "shadowed", index[c], "contains %q of %s", cover, entries[index[c]].location())
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].index < issues[j].index })
+	return issues
+}
@@ -0,0 +1,54 @@
+// lint_test.go: tests for the lint command
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func assert(t *testing.T, b bool) {
+	if !b {
+		t.Fail()
+	}
+}
+
+func TestLint(t *testing.T) {
+	dict := "Mozilla\nMac\n\nMacintosh\nmac\nSafari\nMac\nox\n\xff\xfe\n"
+	var out, errs strings.Builder
+	assert(t, run([]string{"lint"}, strings.NewReader(dict), &out, &errs) == 1 && errs.Len() == 0)
+	assert(t, out.String() == `<stdin>:4: shadowed: "Macintosh" contains "Mac" of <stdin>:2
+<stdin>:5: variant: "mac" differs from <stdin>:2 only by case or width
+<stdin>:7: duplicate: "Mac" duplicates <stdin>:2
+<stdin>:8: short: "ox" has 2 runes, fewer than 3
+<stdin>:9: utf8: "\xff\xfe" is not valid UTF-8
+`)
+}
+
+func TestLintJSON(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	assert(t, os.WriteFile(a, []byte("Safari\nSausage\n"), 0o644) == nil)
+	assert(t, os.WriteFile(b, []byte("Sausage\n"), 0o644) == nil)
+
+	var out, errs strings.Builder
+	assert(t, run([]string{"lint", "-json", "-min-runes", "1", a, b}, nil, &out, &errs) == 1)
+	sc := bufio.NewScanner(strings.NewReader(out.String()))
+	var issues []issue
+	for sc.Scan() {
+		var is issue
+		assert(t, json.Unmarshal(sc.Bytes(), &is) == nil)
+		issues = append(issues, is)
+	}
+	assert(t, len(issues) == 1)
+	assert(t, issues[0].File == b && issues[0].Line == 1 && issues[0].Kind == "duplicate" && issues[0].Related == a+":2")
+
+	out.Reset()
+	assert(t, run([]string{"lint", a}, nil, &out, &errs) == 0 && out.Len() == 0)
+	assert(t, run([]string{"lint", filepath.Join(dir, "missing")}, nil, &out, &errs) == 2)
+	assert(t, run([]string{"frobnicate"}, nil, &out, &errs) == 2)
+}
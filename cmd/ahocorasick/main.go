@@ -0,0 +1,121 @@
+// main.go: command dispatch of the ahocorasick tool
+//
+// Command ahocorasick works with dictionaries from the shell.
+//
+// Usage:
+//
+//	ahocorasick <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	lint    check dictionary files for duplicates, shadowed and malformed entries
+//	match   print the occurrences of dictionary words in files
+//	filter  print the lines of files containing dictionary words
+//
+// Dictionary files hold one word per line; spaces around a word are trimmed
+// and blank lines skipped. Commands read the standard input when given no
+// file. The match and filter commands descend into zip and tar archives,
+// gzip compressed or not, naming their members like "bundle.zip!dir/file".
+// The match command prints text, JSON, JSON lines or CSV, see -format, and
+// follows growing files with -follow.
+// The exit status is 2 on usage or input errors.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command is a subcommand of the tool
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+// commands lists the subcommands in the order of the usage message
+var commands = []command{
+	{"lint", "check dictionary files for duplicates, shadowed and malformed entries", runLint},
+	{"match", "print the occurrences of dictionary words in files", runMatch},
+	{"filter", "print the lines of files containing dictionary words", runFilter},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run dispatches args to their subcommand and returns the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		usage(stderr)
+		return 2
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "ahocorasick: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// usage prints the list of subcommands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: ahocorasick <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s%s\n", c.name, c.summary)
+	}
+}
+
+// entry is a word of a dictionary file with its location
+type entry struct {
+	word string
+	file string
+	line int
+}
+
+// location returns the file:line position of e
+func (e entry) location() string {
+	return fmt.Sprintf("%s:%d", e.file, e.line)
+}
+
+// readDictionaries reads the words of the files at paths, of stdin when
+// there are none, in order
+func readDictionaries(paths []string, stdin io.Reader) ([]entry, error) {
+	if len(paths) == 0 {
+		return readDictionary("<stdin>", stdin, nil)
+	}
+	var entries []entry
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		entries, err = readDictionary(path, f, entries)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// readDictionary appends to entries the words of r, read from file name
+func readDictionary(name string, r io.Reader, entries []entry) ([]entry, error) {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		if word := strings.TrimSpace(sc.Text()); word != "" {
+			entries = append(entries, entry{word: word, file: name, line: line})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,228 @@
+// match.go: the match and filter commands, scanning files for dictionary words.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itgcl/ahocorasick"
+)
+
+// scanner holds what match and filter share: the flags, the matcher and
+// the dictionary it was built from
+type scanner struct {
+	flags   *flag.FlagSet
+	dicts   []string
+	depth   int
+	maxSize int64
+	m       *ahocorasick.Matcher
+	entries []entry
+	stdin   io.Reader
+	stderr  io.Writer
+}
+
+// newScanner returns a scanner for command name with its flags defined,
+// ready for more flags to be added before parse
+func newScanner(name string, stdin io.Reader, stderr io.Writer) *scanner {
+	s := &scanner{flags: flag.NewFlagSet(name, flag.ContinueOnError), stdin: stdin, stderr: stderr}
+	s.flags.SetOutput(stderr)
+	s.flags.Func("dict", "dictionary `file`, one word per line; may be repeated", func(path string) error {
+		s.dicts = append(s.dicts, path)
+		return nil
+	})
+	s.flags.IntVar(&s.depth, "depth", 1, "nested zip and tar archives to open, 0 scans them as they are")
+	s.flags.Int64Var(&s.maxSize, "max-size", 1<<30, "bytes decompressed from an input at most, 0 for no limit")
+	s.flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: ahocorasick %s -dict file [flags] [file ...]\n", name)
+		s.flags.PrintDefaults()
+	}
+	return s
+}
+
+// parse parses args and builds the matcher, reporting errors to stderr
+func (s *scanner) parse(args []string) bool {
+	if err := s.flags.Parse(args); err != nil {
+		return false
+	}
+	if len(s.dicts) == 0 {
+		fmt.Fprintln(s.stderr, "ahocorasick: no dictionary, see -dict")
+		return false
+	}
+	entries, err := readDictionaries(s.dicts, nil)
+	if err != nil {
+		fmt.Fprintln(s.stderr, "ahocorasick:", err)
+		return false
+	}
+	words := make([]string, len(entries))
+	for i, e := range entries {
+		words[i] = e.word
+	}
+	if s.m, err = ahocorasick.Compile(words); err != nil {
+		fmt.Fprintln(s.stderr, "ahocorasick:", err)
+		return false
+	}
+	s.entries = entries
+	return true
+}
+
+// scan calls fn with every line of the inputs, the archive members they
+// hold included, and returns the exit status: 0 when fn reported a match,
+// 1 when none did, 2 when an input could not be read, which does not stop
+// the scan of the others
+func (s *scanner) scan(fn func(name string, n int, offset int64, line []byte) bool) int {
+	paths := s.flags.Args()
+	found, failed := false, false
+	each := func(name string, r io.Reader) error {
+		return scanLines(r, func(n int, offset int64, line []byte) {
+			if fn(name, n, offset, line) {
+				found = true
+			}
+		})
+	}
+	if len(paths) == 0 {
+		if err := openInput("<stdin>", s.stdin, s.depth, s.maxSize, each); err != nil {
+			fmt.Fprintln(s.stderr, "ahocorasick: <stdin>:", err)
+			failed = true
+		}
+	}
+	for _, path := range paths {
+		err := s.openFile(path, each)
+		if err != nil {
+			var pe *os.PathError
+			if !errors.As(err, &pe) {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+			fmt.Fprintln(s.stderr, "ahocorasick:", err)
+			failed = true
+		}
+	}
+	switch {
+	case failed:
+		return 2
+	case found:
+		return 0
+	}
+	return 1
+}
+
+// openFile calls openInput with the file at path
+func (s *scanner) openFile(path string, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return openInput(path, f, s.depth, s.maxSize, fn)
+}
+
+// runMatch prints every occurrence of a dictionary word in the inputs, as
+// name:line:column: word [start-end] lines by default, the column counting
+// bytes from 1 and start and end being byte offsets in the file or archive
+// member, or as JSON or CSV records for other programs; with -follow, the
+// files are followed as they grow, see follow
+func runMatch(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s := newScanner("match", stdin, stderr)
+	format := s.flags.String("format", "text", "output `format`: "+strings.Join(formats, ", "))
+	follow := s.flags.Bool("follow", false, "keep reading the files as they grow, like tail -f, until interrupted")
+	poll := s.flags.Duration("poll", 500*time.Millisecond, "`interval` between checks for new data with -follow")
+	if !s.parse(args) {
+		return 2
+	}
+	rw, err := newResultWriter(*format, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, "ahocorasick:", err)
+		return 2
+	}
+	if *follow {
+		status := s.follow(rw, *poll)
+		if err := rw.close(); err != nil {
+			fmt.Fprintln(stderr, "ahocorasick:", err)
+			return 2
+		}
+		return status
+	}
+	var werr error
+	status := s.scan(func(name string, n int, offset int64, line []byte) bool {
+		matches := s.m.FindAll(line)
+		for _, o := range matches {
+			e := s.entries[o.Pattern]
+			r := result{
+				File:     name,
+				Line:     n,
+				Column:   o.Start + 1,
+				Start:    offset + int64(o.Start),
+				End:      offset + int64(o.End),
+				Pattern:  e.word,
+				Category: category(e.file),
+			}
+			if err := rw.write(r); err != nil && werr == nil {
+				werr = err
+			}
+		}
+		return len(matches) > 0
+	})
+	if err := rw.close(); werr == nil {
+		werr = err
+	}
+	if werr != nil {
+		fmt.Fprintln(stderr, "ahocorasick:", werr)
+		return 2
+	}
+	return status
+}
+
+// runFilter prints the lines of the inputs containing a dictionary word,
+// prefixed by name:line: unless a single plain file or stdin is read
+func runFilter(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s := newScanner("filter", stdin, stderr)
+	if !s.parse(args) {
+		return 2
+	}
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	prefix := len(s.flags.Args()) > 1
+	return s.scan(func(name string, n int, offset int64, line []byte) bool {
+		if !s.m.Contains(line) {
+			return false
+		}
+		if prefix || strings.Contains(name, archiveSep) {
+			fmt.Fprintf(w, "%s:%d:", name, n)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+		return true
+	})
+}
+
+// scanLines calls fn with every line of r, numbered from 1, without its
+// line ending, and the offset of its first byte
+func scanLines(r io.Reader, fn func(n int, offset int64, line []byte)) error {
+	br := bufio.NewReader(r)
+	var offset int64
+	for n := 1; ; n++ {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// lines longer than the buffer are gathered, it happens rarely
+			rest, rerr := br.ReadBytes('\n')
+			line, err = append(append([]byte(nil), line...), rest...), rerr
+		}
+		if len(line) > 0 {
+			fn(n, offset, bytes.TrimRight(line, "\r\n"))
+		}
+		offset += int64(len(line))
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
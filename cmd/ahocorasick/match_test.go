@@ -0,0 +1,40 @@
+// match_test.go: tests for the match and filter commands
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	dir := t.TempDir()
+	dict := filepath.Join(dir, "words.txt")
+	assert(t, os.WriteFile(dict, []byte("secret\npassword\n"), 0o644) == nil)
+	bundle := filepath.Join(dir, "bundle.zip")
+	inner := makeTarGz(t, "conf/app.env", "user=root\npassword=secret\n")
+	assert(t, os.WriteFile(bundle, makeZip(t, "notes.txt", "no secrets\r\nhere", "conf.tgz", string(inner)), 0o644) == nil)
+
+	var out, errs strings.Builder
+	assert(t, run([]string{"match", "-dict", dict, "-depth", "2", bundle}, nil, &out, &errs) == 0 && errs.Len() == 0)
+	assert(t, out.String() == bundle+`!notes.txt:1:4: secret [3-9]
+`+bundle+`!conf.tgz!conf/app.env:2:1: password [10-18]
+`+bundle+`!conf.tgz!conf/app.env:2:10: secret [19-25]
+`)
+
+	out.Reset()
+	assert(t, run([]string{"filter", "-dict", dict}, strings.NewReader("a\nthe secret\nb\n"), &out, &errs) == 0)
+	assert(t, out.String() == "the secret\n")
+	out.Reset()
+	assert(t, run([]string{"filter", "-dict", dict, "-depth", "2", bundle}, nil, &out, &errs) == 0)
+	assert(t, out.String() == bundle+"!notes.txt:1:no secrets\n"+bundle+"!conf.tgz!conf/app.env:2:password=secret\n")
+
+	out.Reset()
+	assert(t, run([]string{"match", "-dict", dict}, strings.NewReader("nothing"), &out, &errs) == 1 && out.Len() == 0)
+	assert(t, errs.Len() == 0)
+	assert(t, run([]string{"match", "-dict", dict, filepath.Join(dir, "missing"), bundle}, nil, &out, &errs) == 2)
+	assert(t, strings.Contains(errs.String(), "missing") && out.Len() > 0)
+	assert(t, run([]string{"match"}, nil, &out, &errs) == 2)
+}
@@ -0,0 +1,57 @@
+// compact.go: releasing the spare storage of a built matcher.
+
+package ahocorasick
+
+import "unsafe"
+
+// MemoryBytes estimates the bytes held by m: the arrays of the automaton
+// with their spare capacity, its backend, the deduplication marks of MatchString and the
+// build-time storage kept by Rebuild, whose transition table is counted at
+// the size of its entries
+func (m *Matcher) MemoryBytes() int {
+	n := cap(m.states)*int(unsafe.Sizeof(state{})) +
+		cap(m.edges)*int(unsafe.Sizeof(edge{})) +
+		cap(m.outputs)*int(unsafe.Sizeof(int32(0))) +
+		cap(m.seen)*int(unsafe.Sizeof(uint64(0)))
+	if m.backend != nil {
+		n += m.backend.bytes()
+	}
+	if b := m.builder; b != nil {
+		n += cap(b.trie)*int(unsafe.Sizeof(node{})) +
+			cap(b.output)*int(unsafe.Sizeof(int32(0))) +
+			len(b.goTo)*int(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(uint32(0))) +
+			cap(b.transitions)*int(unsafe.Sizeof(transition{})) +
+			(cap(b.first)+cap(b.order)+cap(b.ids))*int(unsafe.Sizeof(uint32(0)))
+	}
+	return n
+}
+
+// Compact releases the storage m holds beyond its automaton: the spare
+// capacity a Rebuild into smaller arrays leaves, and the build-time storage
+// Rebuild keeps, so a matcher that will not be rebuilt for a while holds
+// exactly sized arrays; it returns MemoryBytes before and after
+// a freshly compiled matcher is already compact, its transitions being
+// frozen into one exactly sized table sorted per state; the arrays of a
+// loaded matcher stay in the buffer they were loaded from
+// m must not be in use during the call
+func (m *Matcher) Compact() (before, after int) {
+	before = m.MemoryBytes()
+	m.builder = nil
+	if m.data == nil {
+		m.states = exact(m.states)
+		m.edges = exact(m.edges)
+		m.outputs = exact(m.outputs)
+	}
+	if n := m.dedupKeys(); cap(m.seen) > n {
+		m.seen = nil // allocated again, exactly sized, on the next MatchString
+	}
+	return before, m.MemoryBytes()
+}
+
+// exact returns s, copied into exactly sized storage if it has spare capacity
+func exact[T any](s []T) []T {
+	if cap(s) == len(s) {
+		return s
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
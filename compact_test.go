@@ -0,0 +1,31 @@
+// compact_test.go: tests for releasing spare storage
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	fresh := NewStringMatcher(dictionary)
+	before, after := fresh.Compact()
+	assert(t, before == after && after > 0)
+
+	m := NewStringMatcher(dictionary6)
+	m.MatchString(sbytes2)
+	assert(t, m.Rebuild(dictionary) == nil)
+	m.MatchString(sbytes)
+	before, after = m.Compact()
+	assert(t, after < before && after == fresh.MemoryBytes())
+	assert(t, m.builder == nil && cap(m.states) == len(m.states))
+	assert(t, len(m.FindAllString(sbytes)) == 5 && len(m.MatchString(sbytes)) == 4)
+
+	// loaded matchers keep their buffer
+	var b strings.Builder
+	assert(t, m.Save(&b) == nil)
+	loaded, err := LoadBytes([]byte(b.String()))
+	assert(t, err == nil)
+	before, after = loaded.Compact()
+	assert(t, before == after && loaded.ContainsString("Mozilla"))
+}
@@ -0,0 +1,54 @@
+// compat.go: drop-in replacement for github.com/cloudflare/ahocorasick
+//
+// Package compat exposes exactly the API surface of
+// github.com/cloudflare/ahocorasick, backed by the rune-based automaton of
+// github.com/itgcl/ahocorasick, so existing code migrates by swapping the
+// import path:
+//
+//	import ahocorasick "github.com/itgcl/ahocorasick/compat"
+//
+// Results are identical for valid UTF-8 input and dictionaries. Invalid
+// UTF-8 sequences are matched as U+FFFD replacement runes instead of raw
+// bytes.
+
+package compat
+
+import "github.com/itgcl/ahocorasick"
+
+// Matcher is returned by NewMatcher and contains a list of blices to
+// match against
+type Matcher struct {
+	m *ahocorasick.Matcher
+}
+
+// NewMatcher creates a new Matcher used to match against a set of
+// blices
+func NewMatcher(dictionary [][]byte) *Matcher {
+	return &Matcher{m: ahocorasick.NewMatcher(dictionary)}
+}
+
+// NewStringMatcher creates a new Matcher used to match against a set
+// of strings (this is a helper to make initialization easy)
+func NewStringMatcher(dictionary []string) *Matcher {
+	return &Matcher{m: ahocorasick.NewStringMatcher(dictionary)}
+}
+
+// Match searches in for blices and returns all the blices found as
+// indexes into the original dictionary
+//
+// This is not thread-safe method, seek for MatchThreadSafe() instead
+func (m *Matcher) Match(in []byte) []int {
+	return m.m.Match(in)
+}
+
+// MatchThreadSafe provides the same result as Match() but does it in a
+// thread-safe manner
+func (m *Matcher) MatchThreadSafe(in []byte) []int {
+	return m.m.MatchThreadSafe(in)
+}
+
+// Contains returns true if any string matches. This can be faster
+// than Match() when you do not need to know which words matched
+func (m *Matcher) Contains(in []byte) bool {
+	return m.m.Contains(in)
+}
@@ -0,0 +1,62 @@
+// compat_test.go: tests for the cloudflare compatibility layer
+
+package compat
+
+import (
+	"sync"
+	"testing"
+)
+
+func assert(t *testing.T, b bool) {
+	if !b {
+		t.Fail()
+	}
+}
+
+func TestNoPatterns(t *testing.T) {
+	m := NewStringMatcher([]string{})
+	hits := m.Match([]byte("foo bar baz"))
+	assert(t, len(hits) == 0)
+
+	hits = m.MatchThreadSafe([]byte("foo bar baz"))
+	assert(t, len(hits) == 0)
+}
+
+func TestWikipedia(t *testing.T) {
+	m := NewMatcher([][]byte{[]byte("a"), []byte("ab"), []byte("bc"), []byte("bca"), []byte("c"), []byte("caa")})
+	hits := m.Match([]byte("abccab"))
+	assert(t, len(hits) == 4)
+	assert(t, hits[0] == 0)
+	assert(t, hits[1] == 1)
+	assert(t, hits[2] == 2)
+	assert(t, hits[3] == 4)
+
+	hits = m.Match([]byte("bccb"))
+	assert(t, len(hits) == 2)
+	assert(t, hits[0] == 2)
+	assert(t, hits[1] == 4)
+}
+
+func TestMatchThreadSafe(t *testing.T) {
+	m := NewStringMatcher([]string{"Mozilla", "Mac", "Macintosh", "Safari", "Sausage"})
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		hits := m.MatchThreadSafe([]byte("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_7_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/30.0.1599.101 Safari/537.36"))
+		assert(t, len(hits) == 4)
+	}()
+	go func() {
+		defer wg.Done()
+		hits := m.MatchThreadSafe([]byte("Mazilla/5.0 (Moc; Intel Computer OS X 10_7_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/30.0.1599.101 Sofari/537.36"))
+		assert(t, len(hits) == 0)
+	}()
+	wg.Wait()
+}
+
+func TestContains(t *testing.T) {
+	m := NewStringMatcher([]string{"SupermanX", "per"})
+	assert(t, m.Contains([]byte("The Man Of Steel: Superman")))
+	assert(t, !m.Contains([]byte("The Man Of Steel")))
+}
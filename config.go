@@ -0,0 +1,170 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// config.go: structured dictionaries, where every entry carries its own
+// metadata and matching flags, loaded in one call.
+//
+// A dictionary document lists the entries, only "pattern" being required:
+//
+//	{
+//	  "entries": [
+//	    {"pattern": "darn", "id": 17, "category": "mild", "severity": 1, "replacement": "d*rn"},
+//	    {"pattern": "ass", "category": "rude", "severity": 3, "whole_word": true, "ignore_case": true}
+//	  ]
+//	}
+//
+// The types carry yaml tags as well, so a YAML document decoded by any YAML
+// package into a DictionaryConfig can be passed to NewDictionary.
+
+package ahocorasick
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DictionaryEntry is a pattern of a structured dictionary with its metadata
+type DictionaryEntry struct {
+	Pattern     string `json:"pattern" yaml:"pattern"`
+	ID          int64  `json:"id,omitempty" yaml:"id,omitempty"`                   // reported by IDMatcher, the position when zero
+	Category    string `json:"category,omitempty" yaml:"category,omitempty"`       // see WithCategories
+	Severity    int    `json:"severity,omitempty" yaml:"severity,omitempty"`       // see PatternInfo
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"` // used by Dictionary.Replace
+	IgnoreCase  bool   `json:"ignore_case,omitempty" yaml:"ignore_case,omitempty"` // see WithCaseInsensitive
+	WholeWord   bool   `json:"whole_word,omitempty" yaml:"whole_word,omitempty"`   // see WholeWord
+}
+
+// DictionaryConfig is the document read by LoadDictionary
+type DictionaryConfig struct {
+	Entries []DictionaryEntry `json:"entries" yaml:"entries"`
+}
+
+// Dictionary is a matcher built from structured entries, pattern i being
+// entries[i], so the metadata of an occurrence is one lookup away
+// it is safe for concurrent use
+type Dictionary struct {
+	im      *IDMatcher
+	entries []DictionaryEntry
+}
+
+// LoadDictionary reads a JSON dictionary document and builds it with
+// NewDictionary; unknown fields are rejected so typos do not go unnoticed
+func LoadDictionary(r io.Reader, opts ...Option) (*Dictionary, error) {
+	var cfg DictionaryConfig
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("ahocorasick: invalid dictionary: %w", err)
+	}
+	return NewDictionary(cfg.Entries, opts...)
+}
+
+// NewDictionary builds a matcher from entries, turning their metadata into
+// options: categories, severities as PatternInfo, case insensitivity, and
+// the WholeWord validator, which prevents saving the matcher; categories are
+// added to those of opts and severities merged into their PatternInfo
+// entries must have distinct IDs, an entry without one taking its position
+func NewDictionary(entries []DictionaryEntry, opts ...Option) (*Dictionary, error) {
+	o := newOptions(opts)
+	ids := make([]Entry, len(entries))
+	seen := make(map[int64]int, len(entries))
+	categories := make(map[string][]int)
+	var info []PatternInfo // a copy of the info of opts, once a severity is set
+	var folded, whole []int
+	for i, e := range entries {
+		if e.Pattern == "" {
+			return nil, fmt.Errorf("ahocorasick: dictionary entry %d has no pattern", i)
+		}
+		id := e.ID
+		if id == 0 {
+			id = int64(i)
+		}
+		if j, ok := seen[id]; ok {
+			return nil, fmt.Errorf("ahocorasick: dictionary entries %d and %d have the same ID %d", j, i, id)
+		}
+		seen[id] = i
+		ids[i] = Entry{ID: id, Pattern: e.Pattern}
+		if e.Category != "" {
+			categories[e.Category] = append(categories[e.Category], i)
+		}
+		if e.Severity != 0 {
+			if info == nil {
+				info = make([]PatternInfo, max(len(entries), len(o.info)))
+				copy(info, o.info)
+			}
+			if s := info[i].Severity; s != 0 && s != e.Severity {
+				return nil, fmt.Errorf("ahocorasick: dictionary entry %d has severity %d, its PatternInfo %d", i, e.Severity, s)
+			}
+			info[i].Severity = e.Severity
+		}
+		if e.IgnoreCase {
+			folded = append(folded, i)
+		}
+		if e.WholeWord {
+			whole = append(whole, i)
+		}
+	}
+
+	if len(categories) > 0 {
+		for name, patterns := range o.categories {
+			categories[name] = append(categories[name], patterns...)
+		}
+		opts = append(opts, WithCategories(categories))
+	}
+	if info != nil {
+		opts = append(opts, WithPatternInfo(info))
+	}
+	if folded != nil {
+		opts = append(opts, WithCaseInsensitive(folded...))
+	}
+	if whole != nil {
+		opts = append(opts, WithValidator(WholeWord, whole...))
+	}
+	// the IDs being distinct, CompileIDs keeps pattern i as entries[i]
+	im, err := CompileIDs(ids, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Dictionary{im: im, entries: entries}, nil
+}
+
+// Matcher returns the underlying matcher, which reports entry positions
+func (d *Dictionary) Matcher() *Matcher {
+	return d.im.Matcher()
+}
+
+// IDMatcher returns the matcher reporting the IDs of the entries
+func (d *Dictionary) IDMatcher() *IDMatcher {
+	return d.im
+}
+
+// Entry returns the entry of pattern i
+func (d *Dictionary) Entry(i int) DictionaryEntry {
+	return d.entries[i]
+}
+
+// EntryByID returns the entry with the given ID
+func (d *Dictionary) EntryByID(id int64) (DictionaryEntry, bool) {
+	i, ok := d.im.Index(id)
+	if !ok {
+		return DictionaryEntry{}, false
+	}
+	return d.entries[i], true
+}
+
+// Replace substitutes the replacement of their entry for the occurrences
+// selected like ReplaceAll does; occurrences of entries without a
+// replacement are left as they are
+func (d *Dictionary) Replace(text []byte) []byte {
+	return []byte(d.ReplaceString(string(text)))
+}
+
+// ReplaceString is like Replace for a string input
+func (d *Dictionary) ReplaceString(text string) string {
+	return d.Matcher().ReplaceAllString(text, func(o Match) string {
+		if r := d.entries[o.Pattern].Replacement; r != "" {
+			return r
+		}
+		return text[o.Start:o.End]
+	})
+}
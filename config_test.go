@@ -0,0 +1,62 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// config_test.go: tests for structured dictionaries
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+const dictionaryDoc = `{
+  "entries": [
+    {"pattern": "darn", "id": 17, "category": "mild", "severity": 1, "replacement": "d*rn"},
+    {"pattern": "heck", "category": "mild"},
+    {"pattern": "ass", "category": "rude", "severity": 3, "whole_word": true, "ignore_case": true}
+  ]
+}`
+
+func TestLoadDictionary(t *testing.T) {
+	d, err := LoadDictionary(strings.NewReader(dictionaryDoc))
+	assert(t, err == nil)
+	m := d.Matcher()
+	assert(t, d.Entry(0).ID == 17 && d.Entry(2).Category == "rude")
+	assert(t, m.PatternInfo(0).Severity == 1 && m.PatternInfo(1).Severity == 0 && m.PatternInfo(2).Severity == 3)
+	assert(t, len(m.Categories()) == 2 && m.PatternCategories(1)[0] == "mild")
+
+	found := m.FindAllString("Darn, the class is an ASS heck")
+	assert(t, len(found) == 2 && found[0].Pattern == 2 && found[1].Pattern == 1)
+	assert(t, d.ReplaceString("darn it, heck") == "d*rn it, heck")
+	assert(t, string(d.Replace([]byte("darn"))) == "d*rn")
+
+	// occurrences carry the IDs of the entries, the position by default
+	ids := d.IDMatcher().FindAllString("heck, darn")
+	assert(t, len(ids) == 2 && ids[0].ID == 1 && ids[1].ID == 17)
+	e, ok := d.EntryByID(17)
+	assert(t, ok && e.Pattern == "darn")
+	_, ok = d.EntryByID(0)
+	assert(t, !ok)
+	_, err = NewDictionary([]DictionaryEntry{{Pattern: "a", ID: 1}, {Pattern: "b"}})
+	assert(t, err != nil)
+
+	// categories add up with the options
+	d, err = LoadDictionary(strings.NewReader(dictionaryDoc), WithCategories(map[string][]int{"mild": {2}}))
+	assert(t, err == nil && len(d.Matcher().PatternCategories(2)) == 2)
+
+	_, err = LoadDictionary(strings.NewReader(`{"entries": [{"pattern": "x", "severty": 2}]}`))
+	assert(t, err != nil)
+	_, err = LoadDictionary(strings.NewReader(`{"entries": [{"id": 2}]}`))
+	assert(t, err != nil)
+
+	// severities are merged into the PatternInfo of the options
+	info := []PatternInfo{1: {Payload: []byte(`"x"`)}}
+	d, err = LoadDictionary(strings.NewReader(dictionaryDoc), WithPatternInfo(info))
+	assert(t, err == nil && string(d.Matcher().PatternInfo(1).Payload) == `"x"`)
+	assert(t, d.Matcher().PatternInfo(0).Severity == 1 && info[0].Severity == 0)
+	_, err = LoadDictionary(strings.NewReader(dictionaryDoc), WithPatternInfo([]PatternInfo{{Severity: 2}}))
+	assert(t, err != nil)
+
+	d, err = NewDictionary([]DictionaryEntry{{Pattern: "plain"}})
+	assert(t, err == nil && d.Matcher().Categories() == nil && d.Matcher().PatternInfo(0).Severity == 0)
+}
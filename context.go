@@ -0,0 +1,114 @@
+// context.go: constraints on what directly surrounds an occurrence.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Context describes what may directly surround an occurrence: a rune of
+// Class, one of Strings, or the edge of the text when Edge is set
+type Context struct {
+	Class   func(r rune) bool // such as unicode.IsDigit or Runes(",;"), nil for none
+	Strings []string
+	Edge    bool // the start or the end of the text
+}
+
+// Runes returns the class of the runes of s, for Context.Class
+func Runes(s string) func(r rune) bool {
+	return func(r rune) bool {
+		return strings.ContainsRune(s, r)
+	}
+}
+
+// describe returns what c accepts, for Fingerprint; a class is a function
+// and can only be told apart from its absence
+func (c Context) describe() string {
+	return fmt.Sprintf("class %t, strings %q, edge %t", c.Class != nil, c.Strings, c.Edge)
+}
+
+// before reports whether c is found right before the start offset of an
+// occurrence
+func (c Context) before(text string, start int) bool {
+	if start <= 0 {
+		return c.Edge
+	}
+	if c.Class != nil {
+		if r, _ := utf8.DecodeLastRuneInString(text[:start]); c.Class(r) {
+			return true
+		}
+	}
+	for _, s := range c.Strings {
+		if strings.HasSuffix(text[:start], s) {
+			return true
+		}
+	}
+	return false
+}
+
+// after reports whether c is found at the end offset of an occurrence
+func (c Context) after(text string, end int) bool {
+	if end >= len(text) {
+		return c.Edge
+	}
+	if c.Class != nil {
+		if r, _ := utf8.DecodeRuneInString(text[end:]); c.Class(r) {
+			return true
+		}
+	}
+	for _, s := range c.Strings {
+		if strings.HasPrefix(text[end:], s) {
+			return true
+		}
+	}
+	return false
+}
+
+// FollowedBy is a Validator accepting the occurrences directly followed by c
+func FollowedBy(c Context) Validator {
+	return func(text string, _, end int) bool {
+		return c.after(text, end)
+	}
+}
+
+// WithTrailingContext makes patterns match only when directly followed by
+// c, such as "foo" only before a digit or at the end of the text with
+// Context{Class: unicode.IsDigit, Edge: true}; it is checked when an
+// occurrence is found, with the cost of a validator
+// FindReader holds occurrences back until it has read the lookback past
+// them or the end of the stream, which alone counts as the edge
+func WithTrailingContext(c Context, patterns ...int) Option {
+	return withValidator("followed by "+c.describe(), FollowedBy(c), patterns)
+}
+
+// NotPrecededBy is a Validator rejecting the occurrences directly preceded
+// by c
+func NotPrecededBy(c Context) Validator {
+	return func(text string, start, _ int) bool {
+		return !c.before(text, start)
+	}
+}
+
+// NotFollowedBy is a Validator rejecting the occurrences directly followed
+// by c
+func NotFollowedBy(c Context) Validator {
+	return func(text string, _, end int) bool {
+		return !c.after(text, end)
+	}
+}
+
+// WithNegativeContext suppresses the occurrences of patterns directly
+// preceded by before or followed by after, such as "free" inside "freedom"
+// with after set to Context{Class: unicode.IsLetter}; the zero Context
+// rejects nothing, so either side can be left out
+// FindReader holds occurrences back until it has read the lookback past
+// them, so its results do not depend on the size of the reads as long as
+// the strings of the contexts are no longer than the lookback
+func WithNegativeContext(before, after Context, patterns ...int) Option {
+	kind := "not preceded by " + before.describe() + ", not followed by " + after.describe()
+	return withValidator(kind, func(text string, start, end int) bool {
+		return !before.before(text, start) && !after.after(text, end)
+	}, patterns)
+}
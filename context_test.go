@@ -0,0 +1,96 @@
+// context_test.go: tests for context constraints
+
+package ahocorasick
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode"
+)
+
+func TestTrailingContext(t *testing.T) {
+	m := NewStringMatcher([]string{"foo", "bar", "baz"},
+		WithTrailingContext(Context{Class: unicode.IsDigit, Edge: true}, 0),
+		WithTrailingContext(Context{Class: Runes(",;"), Strings: []string{"-x", "é"}}, 1))
+
+	text := "foo foo1 foo bar, bar barb bar-x baré baz foo"
+	got := m.FindAllString(text)
+	want := []Match{
+		{0, 4, 7},
+		{1, 13, 16},
+		{1, 27, 30},
+		{1, 33, 36},
+		{2, 39, 42},
+		{0, 43, 46},
+	}
+	assert(t, len(got) == len(want))
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+	hits := m.MatchString("bar foo")
+	assert(t, len(hits) == 1 && hits[0] == 0)
+
+	// the edge is only accepted when asked for
+	m = NewStringMatcher([]string{"foo"}, WithTrailingContext(Context{Class: unicode.IsDigit}, 0))
+	assert(t, !m.ContainsString("foo") && m.ContainsString("foo2"))
+	assert(t, FollowedBy(Context{Strings: []string{"ab"}})("xab", 0, 1))
+}
+
+func TestNegativeContext(t *testing.T) {
+	letters := Context{Class: unicode.IsLetter}
+	m := NewStringMatcher([]string{"free", "ban", "cat"},
+		WithNegativeContext(Context{}, letters, 0),
+		WithNegativeContext(Context{Strings: []string{"ur"}, Edge: true}, Context{Class: Runes("-")}, 1),
+		WithNegativeContext(letters, letters, 2))
+
+	text := "free freedom carefree ban urban suburban ban-x a ban cat cats concat"
+	got := m.FindAllString(text)
+	want := []Match{
+		{0, 0, 4},
+		{0, 17, 21},
+		{1, 22, 25},
+		{1, 49, 52},
+		{2, 53, 56},
+	}
+	assert(t, len(got) == len(want))
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+
+	assert(t, NotPrecededBy(Context{Edge: true})("ab", 1, 2))
+	assert(t, !NotPrecededBy(Context{Edge: true})("ab", 0, 1))
+	assert(t, !NotFollowedBy(Context{Strings: []string{"dom"}})("freedom", 0, 4))
+	assert(t, NotFollowedBy(Context{})("free", 0, 4))
+}
+
+func TestTrailingContextReader(t *testing.T) {
+	m := NewStringMatcher([]string{"foo"},
+		WithTrailingContext(Context{Class: unicode.IsDigit, Edge: true}, 0))
+	text := "foo foo7 foox foo"
+	var got []Match
+	err := m.FindReader(iotest.OneByteReader(strings.NewReader(text)), func(o Match) bool {
+		got = append(got, o)
+		return true
+	})
+	assert(t, err == nil && len(got) == 2)
+	assert(t, got[0] == Match{0, 4, 7} && got[1] == Match{0, 14, 17})
+}
+
+func TestNegativeContextReader(t *testing.T) {
+	m := NewStringMatcher([]string{"free"},
+		WithNegativeContext(Context{}, Context{Class: unicode.IsLetter}, 0))
+	text := "freedom is free"
+	for _, r := range []func() io.Reader{
+		func() io.Reader { return strings.NewReader(text) },
+		func() io.Reader { return iotest.OneByteReader(strings.NewReader(text)) },
+	} {
+		var got []Match
+		err := m.FindReader(r(), func(o Match) bool {
+			got = append(got, o)
+			return true
+		})
+		assert(t, err == nil && len(got) == 1 && got[0] == Match{0, 11, 15})
+	}
+}
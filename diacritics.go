@@ -0,0 +1,36 @@
+// diacritics.go: matching regardless of accents and other diacritics.
+
+package ahocorasick
+
+import (
+	"sort"
+	"unicode"
+)
+
+// WithDiacriticFolding makes letters with diacritics match their base letter,
+// so "café" and "cafe" or "naïve" and "naive" match each other
+// precomposed letters are decomposed and combining marks are dropped, in the
+// patterns and in the input; offsets reported by FindAll refer to the
+// original input
+func WithDiacriticFolding() Option {
+	return func(o *options) {
+		o.addNormalizer("diacritics", foldDiacritics)
+	}
+}
+
+// foldDiacritics is the normalizer stripping diacritics from r
+func foldDiacritics(dst []rune, _, r rune) []rune {
+	if r < 0xC0 {
+		return append(dst, r)
+	}
+	if unicode.Is(unicode.Mn, r) {
+		return dst // a combining mark, the letter it follows was kept
+	}
+	if r < 0x2000 {
+		i := sort.Search(len(diacriticBases), func(i int) bool { return rune(diacriticBases[i][0]) >= r })
+		if i < len(diacriticBases) && rune(diacriticBases[i][0]) == r {
+			return append(dst, rune(diacriticBases[i][1]))
+		}
+	}
+	return append(dst, r)
+}
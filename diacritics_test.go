@@ -0,0 +1,38 @@
+// diacritics_test.go: tests for diacritic folding
+
+package ahocorasick
+
+import "testing"
+
+func TestDiacriticFolding(t *testing.T) {
+	m := NewStringMatcher([]string{"café", "naive", "Ωμέγα", "résumé"}, WithDiacriticFolding())
+
+	hits := m.MatchString("a naïve cafe, an ωμεγα and a RESUME")
+	assert(t, len(hits) == 2)
+	assert(t, hits[0] == 1)
+	assert(t, hits[1] == 0)
+
+	// offsets refer to the original input, combining marks included
+	text := "le cafe\u0301 est naïve"
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 2)
+	assert(t, matches[0] == Match{Pattern: 0, Start: 3, End: 9})
+	assert(t, text[matches[1].Start:matches[1].End] == "naïve")
+
+	assert(t, m.ContainsString("Ωμεγα"))
+	assert(t, m.ContainsString("resume"))
+	assert(t, !m.ContainsString("RÉSUMÉ"))
+
+	// combined with case flags, case-sensitive patterns compare folded text
+	m = NewStringMatcher([]string{"Résumé", "café"}, WithDiacriticFolding(), WithCaseInsensitive(1))
+	assert(t, m.ContainsString("Resume"))
+	assert(t, !m.ContainsString("resume"))
+	assert(t, m.ContainsString("CAFÉ"))
+}
+
+func TestFoldDiacritics(t *testing.T) {
+	p := pipeline{foldDiacritics}
+	assert(t, p.apply("Ça, c'est déjà très naïf: Łódź") == "Ca, c'est deja tres naif: Łodz")
+	assert(t, p.apply("é̂") == "e")
+	assert(t, p.apply("中文") == "中文")
+}
@@ -0,0 +1,214 @@
+// dictionary.go: helpers for preparing and inspecting dictionaries before
+// they are compiled into a Matcher.
+
+package ahocorasick
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Prune removes every pattern that contains another pattern of the dictionary
+// as a substring, such words can never change the result of Contains-style
+// checks and only inflate the automaton
+// duplicate entries are reduced to their first occurrence, the relative order
+// of the remaining patterns is preserved
+func Prune(dictionary []string) []string {
+	pruned, _ := PruneWithMapping(dictionary)
+	return pruned
+}
+
+// PruneWithMapping is like Prune but also returns a mapping from every index
+// of the original dictionary to the index in the pruned dictionary of the
+// pattern that represents it: kept patterns map to their own new position,
+// removed patterns map to the shortest kept pattern they contain
+func PruneWithMapping(dictionary []string) (pruned []string, mapping []int) {
+	m := NewStringMatcher(dictionary)
+	mapping = make([]int, len(dictionary))
+
+	// duplicates are only reported under one index by the automaton, so
+	// all hits are normalized to the first occurrence of the word
+	first := make(map[string]int, len(dictionary))
+	for i, word := range dictionary {
+		if _, ok := first[word]; !ok {
+			first[word] = i
+		}
+	}
+
+	// first pass: for every word find the pattern that makes it redundant,
+	// the shortest contained pattern is always kept itself because anything
+	// contained in it would also be contained in the word and be even shorter
+	cover := make([]int, len(dictionary))
+	for i, word := range dictionary {
+		cover[i] = first[word]
+		for _, j := range m.MatchString(word) {
+			j = first[dictionary[j]]
+			if j == cover[i] {
+				continue
+			}
+			c := dictionary[j]
+			best := dictionary[cover[i]]
+			if len(c) < len(best) || (len(c) == len(best) && j < cover[i]) {
+				cover[i] = j
+			}
+		}
+	}
+
+	// second pass: assign new positions to the kept patterns in original order
+	position := make([]int, len(dictionary))
+	for i, word := range dictionary {
+		if cover[i] != i {
+			continue
+		}
+		position[i] = len(pruned)
+		pruned = append(pruned, word)
+	}
+	for i := range dictionary {
+		mapping[i] = position[cover[i]]
+	}
+	return pruned, mapping
+}
+
+// Analysis is the report produced by Analyze
+type Analysis struct {
+	Patterns int // number of entries in the dictionary, duplicates included
+	Runes    int // total number of runes over all entries
+
+	// Duplicates lists groups of indices whose entries are identical
+	Duplicates [][]int
+
+	// Variants lists groups of indices whose entries are different strings
+	// that only differ by letter case or by full-width/half-width forms
+	Variants [][]int
+
+	Nodes        int     // projected number of trie nodes, root included
+	SharedRunes  int     // runes that do not need a node thanks to shared prefixes
+	PrefixRatio  float64 // fraction of all runes saved by prefix sharing
+	MaxDepth     int     // length in runes of the longest entry
+	MemoryBytes  int     // projected size of the compiled automaton in bytes
+	EmptyPattern bool    // whether the dictionary contains the empty string
+}
+
+// Analyze inspects a dictionary without compiling it and reports duplicate
+// entries, case/width variants, prefix-sharing statistics and the projected
+// node count and memory of the resulting automaton
+func Analyze(dictionary []string) Analysis {
+	a := Analysis{Patterns: len(dictionary)}
+
+	exact := make(map[string][]int)
+	folded := make(map[string][]int)
+	for i, word := range dictionary {
+		n := utf8.RuneCountInString(word)
+		a.Runes += n
+		if n > a.MaxDepth {
+			a.MaxDepth = n
+		}
+		if word == "" {
+			a.EmptyPattern = true
+		}
+		if len(exact[word]) == 0 {
+			// only the first occurrence of a word takes part in variant grouping
+			key := foldCaseWidth(word)
+			folded[key] = append(folded[key], i)
+		}
+		exact[word] = append(exact[word], i)
+	}
+	for _, group := range exact {
+		if len(group) > 1 {
+			a.Duplicates = append(a.Duplicates, group)
+		}
+	}
+	for _, group := range folded {
+		if len(group) > 1 {
+			a.Variants = append(a.Variants, group)
+		}
+	}
+	sortGroups(a.Duplicates)
+	sortGroups(a.Variants)
+
+	words := make([]string, 0, len(exact))
+	for word := range exact {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	a.Nodes = countNodes(words)
+	unique := 0
+	for _, word := range words {
+		unique += utf8.RuneCountInString(word)
+	}
+	edges := a.Nodes - 1
+	a.SharedRunes = unique - edges
+	if unique > 0 {
+		a.PrefixRatio = float64(a.SharedRunes) / float64(unique)
+	}
+	a.MemoryBytes = estimateMemory(a.Nodes, edges)
+	return a
+}
+
+// countNodes returns the exact number of trie nodes, root included, needed to
+// store the words, which must be sorted and may contain duplicates
+// in sorted order the longest prefix shared with any earlier word is the one
+// shared with the immediate predecessor, so every word only adds the nodes
+// for the runes following that common prefix
+func countNodes(sorted []string) int {
+	nodes, prev := 1, ""
+	for _, word := range sorted {
+		nodes += utf8.RuneCountInString(word) - commonPrefixRunes(prev, word)
+		prev = word
+	}
+	return nodes
+}
+
+// sortedCopy returns a sorted copy of the dictionary, leaving the input untouched
+func sortedCopy(dictionary []string) []string {
+	s := make([]string, len(dictionary))
+	copy(s, dictionary)
+	sort.Strings(s)
+	return s
+}
+
+// commonPrefixRunes returns the number of leading runes shared by a and b
+func commonPrefixRunes(a, b string) int {
+	n := 0
+	for len(a) > 0 && len(b) > 0 {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if ra != rb || sa != sb {
+			break
+		}
+		a, b = a[sa:], b[sb:]
+		n++
+	}
+	return n
+}
+
+// foldCaseWidth maps full-width ASCII forms and the ideographic space to their
+// half-width counterparts and folds letter case, so that two words with the
+// same key only differ by case or width
+func foldCaseWidth(word string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			r -= 0xFF01 - 0x21
+		case r == 0x3000:
+			r = ' '
+		}
+		return unicode.ToLower(r)
+	}, word)
+}
+
+// sortGroups orders groups by their first index so reports are deterministic
+func sortGroups(groups [][]int) {
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+}
+
+// estimateMemory projects the size in bytes of an automaton with the given
+// number of states and transitions, including the output side array and the
+// deduplication counters
+func estimateMemory(nodes, edges int) int {
+	perState := unsafe.Sizeof(state{}) + unsafe.Sizeof(int32(0)) + unsafe.Sizeof(uint64(0))
+	return nodes*int(perState) + edges*int(unsafe.Sizeof(edge{}))
+}
@@ -0,0 +1,71 @@
+// dictionary_test.go: tests for the dictionary helpers
+
+package ahocorasick
+
+import "testing"
+
+func TestPrune(t *testing.T) {
+	pruned := Prune([]string{"Superman", "man", "per", "Steel", "Man Of Steel"})
+	assert(t, len(pruned) == 3)
+	assert(t, pruned[0] == "man")
+	assert(t, pruned[1] == "per")
+	assert(t, pruned[2] == "Steel")
+
+	pruned = Prune([]string{})
+	assert(t, len(pruned) == 0)
+}
+
+func TestPruneWithMapping(t *testing.T) {
+	dict := []string{"中文测试", "ab", "测试", "xaby", "ab", "b"}
+	pruned, mapping := PruneWithMapping(dict)
+	assert(t, len(pruned) == 2)
+	assert(t, pruned[0] == "测试")
+	assert(t, pruned[1] == "b")
+	assert(t, len(mapping) == len(dict))
+	assert(t, mapping[0] == 0)
+	assert(t, mapping[1] == 1)
+	assert(t, mapping[2] == 0)
+	assert(t, mapping[3] == 1)
+	assert(t, mapping[4] == 1)
+	assert(t, mapping[5] == 1)
+
+	pruned, mapping = PruneWithMapping([]string{"dup", "dup"})
+	assert(t, len(pruned) == 1)
+	assert(t, mapping[0] == 0)
+	assert(t, mapping[1] == 0)
+}
+
+func TestAnalyze(t *testing.T) {
+	a := Analyze([]string{"he", "she", "his", "hers", "she", "Hers", "ｈｅｒｓ", "中文"})
+	assert(t, a.Patterns == 8)
+	assert(t, a.Runes == 25)
+	assert(t, len(a.Duplicates) == 1)
+	assert(t, len(a.Duplicates[0]) == 2)
+	assert(t, a.Duplicates[0][0] == 1)
+	assert(t, a.Duplicates[0][1] == 4)
+	assert(t, len(a.Variants) == 1)
+	assert(t, len(a.Variants[0]) == 3)
+	assert(t, a.Variants[0][0] == 3)
+	assert(t, a.Variants[0][1] == 5)
+	assert(t, a.Variants[0][2] == 6)
+	assert(t, a.MaxDepth == 4)
+	assert(t, !a.EmptyPattern)
+
+	// the projection must agree with the trie actually built
+	m := NewStringMatcher([]string{"he", "she", "his", "hers", "she", "Hers", "ｈｅｒｓ", "中文"})
+	assert(t, a.Nodes == len(m.states))
+	assert(t, a.Nodes == 20)
+	assert(t, a.SharedRunes == 3)
+	assert(t, a.MemoryBytes > 0)
+
+	a = Analyze(nil)
+	assert(t, a.Nodes == 1)
+	assert(t, a.PrefixRatio == 0)
+}
+
+func TestCountNodes(t *testing.T) {
+	for _, dict := range [][]string{dictionary, dictionary5, dictionary6, {"", "a", "a", "ab", "中文", "中"}} {
+		m := NewStringMatcher(dict)
+		assert(t, countNodes(sortedCopy(dict)) == len(m.states))
+	}
+}
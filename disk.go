@@ -0,0 +1,363 @@
+// disk.go: matching with an automaton that stays on disk.
+//
+// A dictionary of hundreds of millions of patterns yields an automaton that
+// may not fit in memory. DiskMatcher reads the uncompressed output of Save
+// through an io.ReaderAt in fixed-size pages and keeps the most recently
+// used pages in memory, so hot states near the root stay cached while the
+// long tail is read on demand. Every state visited costs a page lookup, so
+// matching is slower than with a Matcher; it is the trade for bounded memory.
+
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DiskOption configures OpenDisk
+type DiskOption func(*diskOptions)
+
+type diskOptions struct {
+	pageSize   int
+	cachePages int
+}
+
+// WithPageSize sets the size in bytes of the pages read from disk, a power of
+// two of at least 64; the default is 4096
+func WithPageSize(n int) DiskOption {
+	return func(o *diskOptions) {
+		o.pageSize = n
+	}
+}
+
+// WithCachePages sets how many pages are kept in memory, the default is 1024
+func WithCachePages(n int) DiskOption {
+	return func(o *diskOptions) {
+		o.cachePages = n
+	}
+}
+
+// DiskMatcher matches with an automaton saved by Save without loading it,
+// it is safe for concurrent use
+type DiskMatcher struct {
+	r        io.ReaderAt
+	nstates  uint32
+	nedges   uint32
+	outputs  int64 // offset of the output array
+	edges    int64 // offset of the edge array
+	ids      int64 // offset of the pattern ID of every output, 0 for none
+	nids     uint32
+	pageSize int64
+	cache    *pageCache
+}
+
+// OpenDisk prepares matching with the automaton stored uncompressed in the
+// first size bytes of r, only the header and the start of the metadata are
+// read upfront; matchers built with pattern IDs, such as by CompileSynonyms,
+// report the IDs like Load does
+// links are checked as they are followed, so corrupt data makes matching
+// fail with ErrInvalidFormat instead of misbehaving
+func OpenDisk(r io.ReaderAt, size int64, opts ...DiskOption) (*DiskMatcher, error) {
+	o := diskOptions{pageSize: 4096, cachePages: 1024}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.pageSize < 64 || o.pageSize&(o.pageSize-1) != 0 || o.cachePages < 1 {
+		return nil, fmt.Errorf("ahocorasick: invalid page cache of %d pages of %d bytes", o.cachePages, o.pageSize)
+	}
+
+	var head [headerSize]byte
+	if _, err := r.ReadAt(head[:], 0); err != nil {
+		if err == io.EOF {
+			return nil, ErrInvalidFormat
+		}
+		return nil, err
+	}
+	if string(head[:len(formatMagic)]) != formatMagic {
+		return nil, ErrInvalidFormat
+	}
+	version := head[len(formatMagic)]
+	if version != formatVersion && version != metaFormatVersion {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrInvalidFormat, version)
+	}
+	d := &DiskMatcher{
+		r:        r,
+		nstates:  binary.LittleEndian.Uint32(head[8:]),
+		nedges:   binary.LittleEndian.Uint32(head[12:]),
+		pageSize: int64(o.pageSize),
+	}
+	d.outputs = headerSize + int64(d.nstates)*stateSize
+	d.edges = d.outputs + int64(d.nstates)*outputSize
+	end := d.edges + int64(d.nedges)*edgeSize
+	if version == metaFormatVersion {
+		// of the metadata only the pattern IDs are used, read as needed
+		var meta [metaIDsOffset]byte
+		n, err := r.ReadAt(meta[:], end)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		length := int64(binary.LittleEndian.Uint32(meta[:]))
+		if n < len(meta) || length < metaIDsOffset-metaLengthSize {
+			return nil, ErrInvalidFormat
+		}
+		if schema := binary.LittleEndian.Uint32(meta[4:]); schema < 1 || schema > metaSchema {
+			return nil, fmt.Errorf("%w: unknown metadata schema %d", ErrInvalidFormat, schema)
+		}
+		if nids := binary.LittleEndian.Uint32(meta[12:]); nids != noIDs {
+			if int64(nids)*4 > length-(metaIDsOffset-metaLengthSize) {
+				return nil, ErrInvalidFormat
+			}
+			d.ids, d.nids = end+metaIDsOffset, nids
+		}
+		end += metaLengthSize + length
+	}
+	if d.nstates == 0 || end != size {
+		return nil, ErrInvalidFormat
+	}
+	d.cache = newPageCache(o.cachePages, d.read)
+	return d, nil
+}
+
+// CacheStats returns the number of page lookups served from memory and read
+// from disk so far
+func (d *DiskMatcher) CacheStats() (hits, misses uint64) {
+	return d.cache.stats()
+}
+
+// Match returns the indices of the dictionary words found in text, like
+// Matcher.Match; the error reports a failed read or corrupt data
+func (d *DiskMatcher) Match(text []byte) ([]int, error) {
+	return d.MatchString(bytesToString(text))
+}
+
+// MatchString is like Match for a string input
+func (d *DiskMatcher) MatchString(text string) ([]int, error) {
+	var hits []int
+	seen := make(map[int32]bool)
+	var err error
+	scanErr := d.scan(text, func(out int32) bool {
+		if out, err = d.id(out); err != nil {
+			return false
+		}
+		if !seen[out] {
+			seen[out] = true
+			hits = append(hits, int(out))
+		}
+		return true
+	})
+	if scanErr != nil {
+		err = scanErr
+	}
+	return hits, err
+}
+
+// id returns the pattern reported for output out: its ID when the matcher
+// was built from synonyms or entries with IDs, out itself otherwise
+func (d *DiskMatcher) id(out int32) (int32, error) {
+	if d.ids == 0 {
+		return out, nil
+	}
+	if uint32(out) >= d.nids {
+		return 0, ErrInvalidFormat
+	}
+	id, err := d.uint32At(d.ids + int64(out)*4)
+	if err == nil && int32(id) < 0 {
+		err = ErrInvalidFormat
+	}
+	return int32(id), err
+}
+
+// Contains reports whether any dictionary word occurs in text
+func (d *DiskMatcher) Contains(text []byte) (bool, error) {
+	return d.ContainsString(bytesToString(text))
+}
+
+// ContainsString is like Contains for a string input
+func (d *DiskMatcher) ContainsString(text string) (bool, error) {
+	found := false
+	err := d.scan(text, func(int32) bool {
+		found = true
+		return false
+	})
+	return found, err
+}
+
+// scan runs the automaton over text and calls emit for every pattern ending
+// at every position, until emit returns false
+func (d *DiskMatcher) scan(text string, emit func(out int32) bool) error {
+	s := uint32(root)
+	for _, r := range text {
+		var err error
+		if s, err = d.step(s, r); err != nil {
+			return err
+		}
+		out, err := d.output(s)
+		if err != nil {
+			return err
+		}
+		if out >= 0 && !emit(out) {
+			return nil
+		}
+		for f := s; ; {
+			next, err := d.field(f, 12)
+			if err != nil {
+				return err
+			}
+			if next == root {
+				break
+			}
+			if next >= f && f != root {
+				return ErrInvalidFormat
+			}
+			f = next
+			if out, err = d.output(f); err != nil {
+				return err
+			}
+			if out < 0 {
+				return ErrInvalidFormat
+			}
+			if !emit(out) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// step returns the state reached from s on r, following fail links
+func (d *DiskMatcher) step(s uint32, r rune) (uint32, error) {
+	for {
+		next, ok, err := d.next(s, r)
+		if err != nil || ok {
+			return next, err
+		}
+		if s == root {
+			return root, nil
+		}
+		fail, err := d.field(s, 8)
+		if err != nil {
+			return 0, err
+		}
+		if fail >= s {
+			return 0, ErrInvalidFormat
+		}
+		s = fail
+	}
+}
+
+// next binary searches the edges of s for r
+func (d *DiskMatcher) next(s uint32, r rune) (uint32, bool, error) {
+	first, err := d.field(s, 0)
+	if err != nil {
+		return 0, false, err
+	}
+	n, err := d.field(s, 4)
+	if err != nil {
+		return 0, false, err
+	}
+	if uint64(first)+uint64(n) > uint64(d.nedges) {
+		return 0, false, ErrInvalidFormat
+	}
+	lo, hi := first, first+n
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		label, err := d.uint32At(d.edges + int64(mid)*edgeSize)
+		if err != nil {
+			return 0, false, err
+		}
+		switch {
+		case rune(label) < r:
+			lo = mid + 1
+		case rune(label) > r:
+			hi = mid
+		default:
+			next, err := d.uint32At(d.edges + int64(mid)*edgeSize + 4)
+			if err == nil && (next >= d.nstates || next == root) {
+				err = ErrInvalidFormat
+			}
+			return next, err == nil, err
+		}
+	}
+	return 0, false, nil
+}
+
+// field reads the 32 bit field at offset off of the record of state s
+func (d *DiskMatcher) field(s uint32, off int64) (uint32, error) {
+	if s >= d.nstates {
+		return 0, ErrInvalidFormat
+	}
+	return d.uint32At(headerSize + int64(s)*stateSize + off)
+}
+
+// output returns the pattern ending in state s, -1 if none
+func (d *DiskMatcher) output(s uint32) (int32, error) {
+	out, err := d.uint32At(d.outputs + int64(s)*outputSize)
+	return int32(out), err
+}
+
+// uint32At reads a little-endian integer through the page cache, records
+// are 4 byte aligned so an integer never straddles two pages; an offset
+// outside the file, or past the end of a page the reader cut short, is
+// reported as corruption
+func (d *DiskMatcher) uint32At(off int64) (uint32, error) {
+	if off < 0 || off%4 != 0 {
+		return 0, ErrInvalidFormat
+	}
+	page, err := d.cache.get(off / d.pageSize)
+	if err != nil {
+		return 0, err
+	}
+	if i := off % d.pageSize; i+4 <= int64(len(page)) {
+		return binary.LittleEndian.Uint32(page[i:]), nil
+	}
+	return 0, ErrInvalidFormat
+}
+
+// read loads page i from disk, the last page may be short
+func (d *DiskMatcher) read(i int64) ([]byte, error) {
+	page := make([]byte, d.pageSize)
+	n, err := d.r.ReadAt(page, i*d.pageSize)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return page[:n], err
+}
+
+// pageCache keeps the most recently used pages in memory
+type pageCache struct {
+	mu     sync.Mutex
+	pages  *lru[int64, []byte]
+	load   func(i int64) ([]byte, error)
+	hits   uint64
+	misses uint64
+}
+
+func newPageCache(limit int, load func(i int64) ([]byte, error)) *pageCache {
+	return &pageCache{pages: newLRU[int64, []byte](limit), load: load}
+}
+
+// get returns page i, loading it and evicting the least recently used page
+// if needed; the lock is held while loading so a page is read only once
+func (c *pageCache) get(i int64) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if data, ok := c.pages.get(i); ok {
+		c.hits++
+		return data, nil
+	}
+	c.misses++
+	data, err := c.load(i)
+	if err != nil {
+		return nil, err
+	}
+	c.pages.put(i, data)
+	return data, nil
+}
+
+func (c *pageCache) stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
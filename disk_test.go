@@ -0,0 +1,102 @@
+// disk_test.go: tests for matching with an automaton kept on disk
+
+package ahocorasick
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// openDisk saves m and opens the result as a DiskMatcher
+func openDisk(t *testing.T, m *Matcher, opts ...DiskOption) *DiskMatcher {
+	var buf strings.Builder
+	if err := m.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	d, err := OpenDisk(strings.NewReader(buf.String()), int64(buf.Len()), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDiskMatcher(t *testing.T) {
+	// a tiny cache forces evictions all the time
+	d := openDisk(t, precomputed6, WithPageSize(64), WithCachePages(4))
+	hits, err := d.Match(bytes2)
+	assert(t, err == nil)
+	want := precomputed6.Match(bytes2)
+	assert(t, len(hits) == len(want))
+	for i := range hits {
+		assert(t, hits[i] == want[i])
+	}
+
+	ok, err := d.ContainsString("XYZ 3.6")
+	assert(t, err == nil && !ok)
+	d = openDisk(t, precomputed)
+	ok, err = d.Contains(bytes)
+	assert(t, err == nil && ok)
+
+	cached, read := d.CacheStats()
+	assert(t, cached > 0 && read > 0)
+}
+
+func TestDiskMatcherConcurrently(t *testing.T) {
+	d := openDisk(t, precomputed6, WithPageSize(128), WithCachePages(8))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hits, err := d.Match(bytes2)
+			assert(t, err == nil && len(hits) == 105)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDiskMatcherIDs(t *testing.T) {
+	m, err := CompileSynonyms([][]string{{"New York", "NYC"}, {"Los Angeles", "LA"}})
+	assert(t, err == nil)
+	loaded := roundTrip(t, m)
+	d := openDisk(t, m)
+	for _, text := range []string{"LA to NYC", "NYC, New York and LA", "Los Angeles, LA", "none"} {
+		want := loaded.MatchString(text)
+		got, err := d.MatchString(text)
+		assert(t, err == nil && len(got) == len(want))
+		for i := range got {
+			assert(t, got[i] == want[i])
+		}
+	}
+	hits, _ := d.MatchString("LA to NYC")
+	assert(t, len(hits) == 2 && hits[0] == 1 && hits[1] == 0)
+}
+
+func TestDiskMatcherInvalid(t *testing.T) {
+	var buf strings.Builder
+	assert(t, precomputed.Save(&buf) == nil)
+	data := buf.String()
+
+	_, err := OpenDisk(strings.NewReader(data), int64(len(data))-1)
+	assert(t, errors.Is(err, ErrInvalidFormat))
+	_, err = OpenDisk(strings.NewReader("ACT"), 3)
+	assert(t, errors.Is(err, ErrInvalidFormat))
+	_, err = OpenDisk(strings.NewReader(data), int64(len(data)), WithPageSize(100))
+	assert(t, err != nil)
+
+	// a fail link pointing forward is reported rather than followed
+	corrupt := []byte(data)
+	corrupt[headerSize+2*stateSize+8] = 5
+	d, err := OpenDisk(strings.NewReader(string(corrupt)), int64(len(corrupt)))
+	assert(t, err == nil)
+	_, err = d.MatchString(string(bytes))
+	assert(t, errors.Is(err, ErrInvalidFormat))
+
+	// so is a reader holding less than the header describes
+	d, err = OpenDisk(strings.NewReader(data[:headerSize+stateSize+4]), int64(len(data)))
+	assert(t, err == nil)
+	_, err = d.MatchString(string(bytes))
+	assert(t, errors.Is(err, ErrInvalidFormat))
+}
@@ -0,0 +1,172 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// dump.go: a JSON dump of the automaton for programs in other languages.
+//
+// The dump is a layout of this package, not a format other Aho-Corasick
+// libraries read: no common interchange format exists, pyahocorasick
+// pickling Python objects and Java libraries serializing their own
+// classes. It holds the patterns, which a consumer passes to the library
+// of its language, and the automaton as flat arrays of integers with
+// transitions labelled by Unicode code points, which a consumer can walk
+// with a few lines of Python or Java instead; see the README.
+
+package ahocorasick
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	dumpFormat  = "ahocorasick/dump"
+	dumpVersion = 1
+)
+
+// jsonDump is the document written by ExportDump
+type jsonDump struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+
+	// Patterns holds the patterns by index, null for those the automaton
+	// does not hold, such as earlier duplicates
+	Patterns []*string `json:"patterns"`
+
+	// IDs holds the ID reported for every pattern index, absent when the
+	// index itself is reported; IDs are smaller than the number of patterns
+	IDs []int32 `json:"ids,omitempty"`
+
+	// the automaton, rebuilt from the patterns by ImportDump; state 0
+	// is the root, the transitions of state s are those from EdgeOffsets[s]
+	// to EdgeOffsets[s+1], sorted by label
+	Output      []int32  `json:"output"` // pattern index ending in every state, -1 for none
+	Fail        []uint32 `json:"fail"`
+	Suffix      []uint32 `json:"suffix"` // nearest output state on the fail chain, 0 for none
+	EdgeOffsets []uint32 `json:"edge_offsets"`
+	EdgeLabels  []rune   `json:"edge_labels"`
+	EdgeTargets []uint32 `json:"edge_targets"`
+}
+
+// ExportDump writes m as a JSON dump in the layout of this package, which
+// no other library reads as is: programs in other languages take its
+// patterns, to build an automaton with the library of their choice, or walk
+// the automaton itself, given as flat arrays; its offsets are in code points
+// matchers rewriting their input, by normalization, case folding or
+// equivalence classes, or checking it with validators or positions cannot
+// be exported, as consumers could not reproduce them, and return
+// ErrNotSerializable;
+// categories, metadata and the overlap policy are not exported
+func (m *Matcher) ExportDump(w io.Writer) error {
+	if m.norm != nil || m.fold != nil || m.equiv != nil || m.validators != nil || m.positions != nil {
+		return fmt.Errorf("%w: the dump cannot hold input rewriting, validators or positions", ErrNotSerializable)
+	}
+	if m.merged() {
+		return fmt.Errorf("%w: %w", ErrNotSerializable, errMinimized)
+	}
+	doc := jsonDump{
+		Format:      dumpFormat,
+		Version:     dumpVersion,
+		Patterns:    make([]*string, m.wordCount()),
+		IDs:         m.ids,
+		Output:      m.outputs,
+		Fail:        make([]uint32, len(m.states)),
+		Suffix:      make([]uint32, len(m.states)),
+		EdgeOffsets: make([]uint32, len(m.states)+1),
+		EdgeLabels:  make([]rune, len(m.edges)),
+		EdgeTargets: make([]uint32, len(m.edges)),
+	}
+	prefix := make([]string, len(m.states))
+	for s := range m.states {
+		id := uint32(s)
+		st := &m.states[s]
+		doc.Fail[s], doc.Suffix[s] = m.failLink(id), m.suffixLink(id)
+		doc.EdgeOffsets[s+1] = st.edges + st.nedges
+		for k, e := range m.edges[st.edges : st.edges+st.nedges] {
+			doc.EdgeLabels[int(st.edges)+k], doc.EdgeTargets[int(st.edges)+k] = e.label, e.next
+			prefix[e.next] = prefix[s] + string(e.label)
+		}
+		if out := m.outputs[s]; out >= 0 {
+			word := prefix[s]
+			doc.Patterns[out] = &word
+		}
+		prefix[s] = "" // states are numbered breadth-first, no later one needs it
+	}
+	bw := bufio.NewWriter(w)
+	if err := json.NewEncoder(bw).Encode(doc); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// errMinimized is wrapped by ExportDump and ExportJSON for minimized
+// matchers, whose states no longer spell every pattern
+var errMinimized = errors.New("the automaton was minimized")
+
+// merged reports whether some state of m is reached by several transitions,
+// which only minimization does outside of equivalence classes
+func (m *Matcher) merged() bool {
+	reached := make([]bool, len(m.states))
+	for _, e := range m.edges {
+		if reached[e.next] {
+			return true
+		}
+		reached[e.next] = true
+	}
+	return false
+}
+
+// wordCount returns the number of pattern indices of m, which exceeds the
+// number of reported IDs when synonyms share one
+func (m *Matcher) wordCount() int {
+	if m.ids != nil {
+		return len(m.ids)
+	}
+	return m.npatterns
+}
+
+// ImportDump builds a matcher from a dump written by ExportDump,
+// or by hand in another language, in which only format, version and
+// patterns are required: the automaton is rebuilt from the patterns with
+// opts, so the arrays of the document are not trusted
+func ImportDump(r io.Reader, opts ...Option) (*Matcher, error) {
+	var doc jsonDump
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if doc.Format != dumpFormat {
+		return nil, fmt.Errorf("%w: format %q is not %q", ErrInvalidFormat, doc.Format, dumpFormat)
+	}
+	if doc.Version < 1 || doc.Version > dumpVersion {
+		return nil, fmt.Errorf("%w: unknown dump version %d", ErrInvalidFormat, doc.Version)
+	}
+	if doc.IDs != nil && len(doc.IDs) != len(doc.Patterns) {
+		return nil, fmt.Errorf("%w: %d ids for %d patterns", ErrInvalidFormat, len(doc.IDs), len(doc.Patterns))
+	}
+	// patterns left out keep their index, through explicit IDs; IDs are
+	// bounded by the number of patterns, as per-ID tables are sized by them
+	var words []string
+	var ids []int32
+	nids := len(doc.Patterns)
+	if doc.IDs != nil {
+		nids = 0
+	}
+	for i, p := range doc.Patterns {
+		id := int32(i)
+		if doc.IDs != nil {
+			if id = doc.IDs[i]; id < 0 || int(id) >= len(doc.Patterns) {
+				return nil, fmt.Errorf("%w: id %d out of range for %d patterns", ErrInvalidFormat, id, len(doc.Patterns))
+			}
+			nids = max(nids, int(id)+1)
+		}
+		if p != nil && *p != "" {
+			words = append(words, *p)
+			ids = append(ids, id)
+		}
+	}
+	if doc.IDs == nil && len(words) == len(doc.Patterns) {
+		ids = nil
+	}
+	return compile(words, ids, nids, opts)
+}
@@ -0,0 +1,138 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// dump_test.go: tests for the JSON dump
+
+package ahocorasick
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// walkDump matches text with the arrays of a dump the way
+// a consumer in another language would, offsets in code points
+func walkDump(doc *jsonDump, text string) []Match {
+	next := func(s uint32, c rune) (uint32, bool) {
+		for k := doc.EdgeOffsets[s]; k < doc.EdgeOffsets[s+1]; k++ {
+			if doc.EdgeLabels[k] == c {
+				return doc.EdgeTargets[k], true
+			}
+		}
+		return 0, false
+	}
+	var matches []Match
+	s := uint32(0)
+	for pos, c := range []rune(text) {
+		n, ok := next(s, c)
+		for !ok && s != 0 {
+			s = doc.Fail[s]
+			n, ok = next(s, c)
+		}
+		s = n
+		t := s
+		if doc.Output[t] < 0 {
+			t = doc.Suffix[t]
+		}
+		for ; t != 0; t = doc.Suffix[t] {
+			p := doc.Output[t]
+			n := len([]rune(*doc.Patterns[p]))
+			matches = append(matches, Match{Pattern: int(p), Start: pos + 1 - n, End: pos + 1})
+		}
+	}
+	return matches
+}
+
+func TestExportDump(t *testing.T) {
+	text := "中文文本 he said she sells ushers " + sbytes
+	dict := append([]string{"中文", "文本", "he", "she", "his", "hers", "Mac"}, dictionary...)
+	m := NewStringMatcher(dict)
+	var sb strings.Builder
+	assert(t, m.ExportDump(&sb) == nil)
+
+	var doc jsonDump
+	assert(t, json.Unmarshal([]byte(sb.String()), &doc) == nil)
+	assert(t, doc.Format == "ahocorasick/dump" && doc.Version == 1 && doc.IDs == nil)
+	assert(t, len(doc.Patterns) == len(dict) && doc.Patterns[6] == nil && *doc.Patterns[8] == "Mac")
+	assert(t, strings.Contains(sb.String(), `"edge_labels":[`) && doc.EdgeLabels[0] == 'M')
+
+	// the arrays match like the matcher, in code points
+	want := m.FindAllString(text)
+	got := walkDump(&doc, text)
+	assert(t, len(got) == len(want))
+	for i := range got {
+		o := want[i]
+		assert(t, got[i].Pattern == o.Pattern)
+		assert(t, got[i].End == len([]rune(text[:o.End])) && got[i].Start == len([]rune(text[:o.Start])))
+	}
+
+	// importing rebuilds the same matcher
+	imported, err := ImportDump(strings.NewReader(sb.String()), WithBackend(BackendDense))
+	assert(t, err == nil && imported.Backend() == BackendDense)
+	again := imported.FindAllString(text)
+	assert(t, len(again) == len(want))
+	for i := range again {
+		assert(t, again[i] == want[i])
+	}
+
+	// synonyms keep their IDs
+	syn, err := CompileSynonyms([][]string{{"NYC", "New York"}, {"LA"}})
+	assert(t, err == nil)
+	sb.Reset()
+	assert(t, syn.ExportDump(&sb) == nil)
+	imported, err = ImportDump(strings.NewReader(sb.String()))
+	assert(t, err == nil)
+	hits := imported.MatchString("LA to New York")
+	assert(t, len(hits) == 2 && hits[0] == 1 && hits[1] == 0)
+
+	// matchers rewriting their input cannot be exported
+	folded := NewStringMatcher([]string{"Mac"}, WithCaseInsensitive(0))
+	assert(t, errors.Is(folded.ExportDump(&sb), ErrNotSerializable))
+}
+
+func TestImportDump(t *testing.T) {
+	// a document written by hand, from a dictionary in another language
+	m, err := ImportDump(strings.NewReader(`{"format":"ahocorasick/dump","version":1,"patterns":["foo",null,"bar"]}`))
+	assert(t, err == nil)
+	hits := m.MatchString("bar foo")
+	assert(t, len(hits) == 2 && hits[0] == 2 && hits[1] == 0)
+	m, err = ImportDump(strings.NewReader(`{"format":"ahocorasick/dump","version":1,"patterns":["foo","bar","baz"],"ids":[2,0,2]}`))
+	assert(t, err == nil)
+	hits = m.MatchString("bar foo baz")
+	assert(t, len(hits) == 2 && hits[0] == 0 && hits[1] == 2)
+
+	// patterns without spellings are kept through saving
+	m, err = ImportDump(strings.NewReader(`{"format":"ahocorasick/dump","version":1,"patterns":["foo",null,null]}`))
+	assert(t, err == nil)
+	loaded := roundTrip(t, m)
+	assert(t, loaded.npatterns == 3 && loaded.MatchString("foo")[0] == 0)
+
+	for _, doc := range []string{
+		`not json`,
+		`{"format":"other","version":1,"patterns":[]}`,
+		`{"format":"ahocorasick/dump","version":2,"patterns":[]}`,
+		`{"format":"ahocorasick/dump","version":1,"patterns":["a"],"ids":[1,2]}`,
+		`{"format":"ahocorasick/dump","version":1,"patterns":["a"],"ids":[-1]}`,
+		`{"format":"ahocorasick/dump","version":1,"patterns":["foo","bar"],"ids":[7,3]}`,
+		// an ID sizing per-ID tables far beyond the document
+		`{"format":"ahocorasick/dump","version":1,"patterns":["a"],"ids":[50000000]}`,
+		`{"format":"ahocorasick/dump","version":1,"patterns":["a"],"ids":[2147483647]}`,
+	} {
+		_, err := ImportDump(strings.NewReader(doc))
+		assert(t, errors.Is(err, ErrInvalidFormat))
+	}
+}
+
+func TestExportDumpMinimized(t *testing.T) {
+	groups := [][]string{leetVariants("password"), leetVariants("admin")}
+	plain, err := CompileSynonyms(groups)
+	assert(t, err == nil)
+	min, err := CompileSynonyms(groups, WithMinimize())
+	assert(t, err == nil)
+
+	// merged states no longer spell every pattern
+	var sb strings.Builder
+	assert(t, errors.Is(min.ExportDump(&sb), ErrNotSerializable))
+	assert(t, plain.ExportDump(&sb) == nil)
+}
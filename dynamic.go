@@ -0,0 +1,145 @@
+// dynamic.go: a dictionary that changes at runtime, compiled in the
+// background of the matching traffic and swapped in atomically.
+
+package ahocorasick
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dynamic manages a dictionary edited at runtime: words are added and
+// removed at any time and Rebuild compiles the pending changes into a new
+// matcher that replaces the previous one atomically, so matching never waits
+// for a build
+// words may be registered with an expiry, after which they stop matching
+// immediately and are dropped by the next Rebuild
+// all methods are safe for concurrent use
+type Dynamic struct {
+	opts []Option
+
+	mu      sync.Mutex
+	words   map[string]time.Time // word to expiry, zero for none
+	dirty   bool
+	current atomic.Pointer[dynamicSnapshot]
+
+	now func() time.Time // clock, replaced in tests
+}
+
+// dynamicSnapshot is an immutable compiled version of the dictionary
+type dynamicSnapshot struct {
+	matcher *Matcher
+	words   []string    // words[i] is the pattern with index i
+	expires []time.Time // expiry of every pattern, zero for none
+}
+
+// NewDynamic creates an empty dynamic dictionary, opts are used for every build
+func NewDynamic(opts ...Option) *Dynamic {
+	d := &Dynamic{opts: opts, words: make(map[string]time.Time), now: time.Now}
+	d.current.Store(&dynamicSnapshot{matcher: NewStringMatcher(nil)})
+	return d
+}
+
+// Add registers words without expiry, they match after the next Rebuild
+func (d *Dynamic) Add(words ...string) {
+	d.AddUntil(time.Time{}, words...)
+}
+
+// AddWithTTL registers words that stop matching once ttl has elapsed
+func (d *Dynamic) AddWithTTL(ttl time.Duration, words ...string) {
+	d.AddUntil(d.now().Add(ttl), words...)
+}
+
+// AddUntil registers words that stop matching at expiry, a zero expiry
+// means never; adding a word again replaces its expiry
+func (d *Dynamic) AddUntil(expiry time.Time, words ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range words {
+		d.words[w] = expiry
+	}
+	d.dirty = true
+}
+
+// Remove unregisters words, they stop matching after the next Rebuild
+func (d *Dynamic) Remove(words ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range words {
+		delete(d.words, w)
+	}
+	d.dirty = true
+}
+
+// Rebuild compiles the current dictionary, without the expired words, and
+// swaps it in; it does nothing when there is no pending change or expiry
+func (d *Dynamic) Rebuild() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for w, expiry := range d.words {
+		if !expiry.IsZero() && !now.Before(expiry) {
+			delete(d.words, w)
+			d.dirty = true
+		}
+	}
+	if !d.dirty {
+		return nil
+	}
+
+	snap := &dynamicSnapshot{words: make([]string, 0, len(d.words))}
+	for w := range d.words {
+		snap.words = append(snap.words, w)
+	}
+	sort.Strings(snap.words)
+	snap.expires = make([]time.Time, len(snap.words))
+	for i, w := range snap.words {
+		snap.expires[i] = d.words[w]
+	}
+	m, err := Compile(snap.words, d.opts...)
+	if err != nil {
+		return err
+	}
+	snap.matcher = m
+	d.current.Store(snap)
+	d.dirty = false
+	return nil
+}
+
+// Words returns the words of the matcher currently in use, in pattern index order
+func (d *Dynamic) Words() []string {
+	snap := d.current.Load()
+	return append([]string(nil), snap.words...)
+}
+
+// Match returns the words of the current matcher found in text, words past
+// their expiry are filtered out even before the next Rebuild
+func (d *Dynamic) Match(text []byte) []string {
+	return d.MatchString(bytesToString(text))
+}
+
+// MatchString is like Match for a string input
+func (d *Dynamic) MatchString(text string) []string {
+	snap := d.current.Load()
+	now := d.now()
+	var words []string
+	for _, i := range snap.matcher.MatchThreadSafeString(text) {
+		if expiry := snap.expires[i]; expiry.IsZero() || now.Before(expiry) {
+			words = append(words, snap.words[i])
+		}
+	}
+	return words
+}
+
+// Contains reports whether text contains any unexpired word of the current matcher
+func (d *Dynamic) Contains(text []byte) bool {
+	return len(d.Match(text)) > 0
+}
+
+// ContainsString is like Contains for a string input
+func (d *Dynamic) ContainsString(text string) bool {
+	return len(d.MatchString(text)) > 0
+}
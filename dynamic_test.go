@@ -0,0 +1,72 @@
+// dynamic_test.go: tests for the runtime-editable dictionary
+
+package ahocorasick
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDynamic(t *testing.T) {
+	d := NewDynamic()
+	assert(t, !d.ContainsString("anything"))
+
+	d.Add("Man", "Steel")
+	assert(t, !d.ContainsString("Man Of Steel")) // not rebuilt yet
+	assert(t, d.Rebuild() == nil)
+	words := d.MatchString("The Man Of Steel")
+	assert(t, len(words) == 2)
+	assert(t, words[0] == "Man")
+	assert(t, words[1] == "Steel")
+
+	d.Remove("Man")
+	assert(t, d.Rebuild() == nil)
+	words = d.Match([]byte("The Man Of Steel"))
+	assert(t, len(words) == 1)
+	assert(t, words[0] == "Steel")
+	assert(t, len(d.Words()) == 1)
+}
+
+func TestDynamicTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := NewDynamic()
+	d.now = func() time.Time { return now }
+
+	d.Add("Steel")
+	d.AddWithTTL(time.Hour, "incident")
+	assert(t, d.Rebuild() == nil)
+	assert(t, len(d.MatchString("incident at the Steel mill")) == 2)
+
+	// expired words stop matching immediately
+	now = now.Add(2 * time.Hour)
+	words := d.MatchString("incident at the Steel mill")
+	assert(t, len(words) == 1)
+	assert(t, words[0] == "Steel")
+	assert(t, len(d.Words()) == 2)
+
+	// and are dropped by the next rebuild
+	assert(t, d.Rebuild() == nil)
+	assert(t, len(d.Words()) == 1)
+}
+
+func TestDynamicConcurrently(t *testing.T) {
+	d := NewDynamic(WithMaxPatterns(1000))
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for _, w := range dictionary6 {
+			d.Add(w)
+			assert(t, d.Rebuild() == nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			d.Match(bytes2)
+		}
+	}()
+	wg.Wait()
+	assert(t, len(d.Match(bytes2)) == 105)
+}
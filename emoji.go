@@ -0,0 +1,50 @@
+// emoji.go: normalization of emoji sequences.
+
+package ahocorasick
+
+// zwj is the zero width joiner gluing emoji into a single glyph
+const zwj = 0x200D
+
+// WithEmojiNormalization makes emoji match the same way on every platform:
+// variation selectors, which only choose between text and emoji
+// presentation, are removed, and zero width joiners are kept only where
+// they join two emoji, so stray joiners inserted around or inside words
+// cannot split a pattern
+func WithEmojiNormalization() Option {
+	return func(o *options) {
+		o.addNormalizer("emoji", normalizeEmoji)
+	}
+}
+
+// normalizeEmoji is the normalizer of WithEmojiNormalization
+func normalizeEmoji(dst []rune, prev, r rune) []rune {
+	switch {
+	case r < 0x200D:
+		return append(dst, r)
+	case isVariationSelector(r):
+		return dst
+	case r == zwj && !isEmoji(prev):
+		return dst // joins nothing, also drops repeated joiners
+	}
+	return append(dst, r)
+}
+
+// isVariationSelector reports whether r selects a glyph variant of the rune
+// before it
+func isVariationSelector(r rune) bool {
+	return (r >= 0xFE00 && r <= 0xFE0F) || (r >= 0xE0100 && r <= 0xE01EF)
+}
+
+// isEmoji reports whether r belongs to one of the blocks emoji are encoded
+// in, skin tone modifiers and regional indicators included
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // emoticons, pictographs, symbols
+		r >= 0x2600 && r <= 0x27BF, // miscellaneous symbols and dingbats
+		r >= 0x2300 && r <= 0x23FF, // miscellaneous technical
+		r >= 0x2B00 && r <= 0x2BFF, // arrows and shapes
+		r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122:
+		return true
+	}
+	return false
+}
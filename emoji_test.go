@@ -0,0 +1,22 @@
+// emoji_test.go: tests for emoji normalization
+
+package ahocorasick
+
+import "testing"
+
+func TestEmojiNormalization(t *testing.T) {
+	p := pipeline{normalizeEmoji}
+	assert(t, p.apply("❤\ufe0f") == "❤") // red heart, emoji presentation
+	assert(t, p.apply("\U0001F469\u200d\U0001F4BB") == "\U0001F469\u200d\U0001F4BB")
+	assert(t, p.apply("\U0001F469\ufe0f\u200d\u200d\U0001F4BB") == "\U0001F469\u200d\U0001F4BB")
+	assert(t, p.apply("b\u200dad\u200d") == "bad")
+	assert(t, p.apply("\u200d") == "")
+
+	m := NewStringMatcher([]string{"❤\ufe0f", "bad"}, WithEmojiNormalization())
+	text := "I ❤ this, b\u200dad ❤\ufe0e"
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 3)
+	assert(t, matches[0] == Match{Pattern: 0, Start: 2, End: 5})
+	assert(t, text[matches[1].Start:matches[1].End] == "b\u200dad")
+	assert(t, text[matches[2].Start:matches[2].End] == "❤\ufe0e")
+}
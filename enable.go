@@ -0,0 +1,87 @@
+// enable.go: disabling patterns at runtime without rebuilding the automaton.
+
+package ahocorasick
+
+// patternMask is a bitset of disabled patterns, replaced as a whole on every
+// change so matching calls read it without locking
+type patternMask []uint64
+
+// has reports whether pattern out is in the mask
+func (mk patternMask) has(out int32) bool {
+	w := int(out) >> 6
+	return w < len(mk) && mk[w]&(1<<(uint(out)&63)) != 0
+}
+
+// DisablePattern stops pattern i from being reported by any matching call
+// started afterwards, as if it were not in the dictionary, so an entry
+// prone to false positives can be switched off instantly while a proper
+// rebuild happens; it is safe to call concurrently with matching
+// duplicates in the dictionary are reported under their last index, which
+// is the one to disable
+func (m *Matcher) DisablePattern(i int) {
+	m.updateMask(i, true)
+}
+
+// EnablePattern reverts DisablePattern
+func (m *Matcher) EnablePattern(i int) {
+	m.updateMask(i, false)
+}
+
+// PatternEnabled reports whether pattern i is reported by matching calls
+func (m *Matcher) PatternEnabled(i int) bool {
+	return i < 0 || !m.disabledPatterns().has(int32(i))
+}
+
+// disabledPatterns returns the current mask, loaded once per matching call
+func (m *Matcher) disabledPatterns() patternMask {
+	if p := m.disabled.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// updateMask replaces the mask by a copy where pattern i is disabled or not;
+// an empty mask is stored as nil so matching skips it entirely
+func (m *Matcher) updateMask(i int, disable bool) {
+	if i < 0 {
+		return
+	}
+	m.maskMu.Lock()
+	defer m.maskMu.Unlock()
+
+	mk := append(patternMask(nil), m.disabledPatterns()...)
+	w, bit := i>>6, uint64(1)<<(uint(i)&63)
+	if disable {
+		if w >= len(mk) {
+			mk = append(mk, make(patternMask, w+1-len(mk))...)
+		}
+		mk[w] |= bit
+	} else if w < len(mk) {
+		mk[w] &^= bit
+	}
+	for len(mk) > 0 && mk[len(mk)-1] == 0 {
+		mk = mk[:len(mk)-1]
+	}
+	if len(mk) == 0 {
+		m.disabled.Store(nil)
+		return
+	}
+	m.disabled.Store(&mk)
+}
+
+// enabledAt reports whether state n or a state of its suffix chain ends an
+// enabled pattern, n being known to end some pattern
+func (m *Matcher) enabledAt(n uint32, off patternMask) bool {
+	if len(off) == 0 {
+		return true
+	}
+	if out := m.outputs[n]; out >= 0 && !off.has(out) {
+		return true
+	}
+	for f := m.suffixLink(n); f != root; f = m.suffixLink(f) {
+		if !off.has(m.outputs[f]) {
+			return true
+		}
+	}
+	return false
+}
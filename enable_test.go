@@ -0,0 +1,84 @@
+// enable_test.go: tests for disabling patterns at runtime
+
+package ahocorasick
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDisablePattern(t *testing.T) {
+	m := NewStringMatcher(dictionary)
+	assert(t, m.PatternEnabled(1))
+	m.DisablePattern(1)
+	m.DisablePattern(-1)
+	assert(t, !m.PatternEnabled(1))
+	assert(t, m.PatternEnabled(2))
+
+	hits := m.MatchString(sbytes)
+	assert(t, len(hits) == 3)
+	for _, h := range hits {
+		assert(t, h != 1)
+	}
+	hits = m.MatchThreadSafe(bytes)
+	assert(t, len(hits) == 3)
+	assert(t, len(m.MatchScratch(m.NewScratch(), bytes)) == 3)
+	assert(t, !m.MatchSetString(sbytes).Has(1))
+	assert(t, !m.ContainsAllString(sbytes, []int{0, 1}))
+	for _, o := range m.FindAll(bytes) {
+		assert(t, o.Pattern != 1)
+	}
+
+	// "Mac" is the first pattern ending inside "Macintosh"
+	m.DisablePattern(0)
+	first, ok := m.MatchFirstString("Mozilla/5.0 (Macintosh")
+	assert(t, ok && first == 2)
+
+	m.EnablePattern(0)
+	m.EnablePattern(1)
+	assert(t, m.disabled.Load() == nil)
+	assert(t, len(m.MatchString(sbytes)) == 4)
+}
+
+func TestDisablePatternContains(t *testing.T) {
+	m := NewStringMatcher([]string{"he", "she", "hers"})
+	m.DisablePattern(0)
+	assert(t, m.ContainsString("she"))
+	m.DisablePattern(1)
+	assert(t, !m.ContainsString("she"))
+	assert(t, m.ContainsString("ushers"))
+	m.DisablePattern(2)
+	assert(t, !m.ContainsString("ushers"))
+	_, ok := m.MatchFirstString("ushers")
+	assert(t, !ok)
+	assert(t, !m.CanSegmentString("he"))
+	assert(t, len(m.Suggest("he", 0)) == 0)
+}
+
+func TestDisablePatternTransformed(t *testing.T) {
+	m := NewStringMatcher([]string{"Go", "go"}, WithCaseInsensitive(0))
+	m.DisablePattern(0)
+	hits := m.MatchString("GO go")
+	assert(t, len(hits) == 1 && hits[0] == 1)
+	assert(t, !m.ContainsString("GO"))
+}
+
+func TestDisablePatternConcurrent(t *testing.T) {
+	m := NewStringMatcher(dictionary)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				hits := m.MatchThreadSafe(bytes)
+				assert(t, len(hits) >= 3)
+			}
+		}()
+	}
+	for j := 0; j < 200; j++ {
+		m.DisablePattern(j % 4)
+		m.EnablePattern(j % 4)
+	}
+	wg.Wait()
+}
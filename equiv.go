@@ -0,0 +1,115 @@
+// equiv.go: rune equivalence classes compiled into the transitions.
+//
+// Patterns are rewritten with the first rune of every class, the class
+// representative, and every transition over a representative is then
+// duplicated for the other runes of its class, so an input rune of a class
+// follows the same transition as its representative. The input is never
+// rewritten and matching costs nothing more; the classes are saved with the
+// automaton since its transitions already include them.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithEquivalence makes the runes of every class match each other, a class
+// being given as the string of its runes, such as "aáàâä" or "o0"; unlike a
+// normalizer the classes are compiled into the automaton, so the input is
+// not rewritten at match time and costs nothing more to scan
+// classes apply to runes as the automaton sees them, after normalization
+// and case folding, and patterns are reported under their last index when
+// they only differ by equivalent runes
+func WithEquivalence(classes ...string) Option {
+	return func(o *options) {
+		o.equivalence = append(o.equivalence, classes...)
+	}
+}
+
+// equivalence maps every rune of a class but its representative to the
+// representative
+type equivalence struct {
+	classes []string        // the classes, representative first
+	rep     map[rune]rune   // representative of every other rune of a class
+	members map[rune][]rune // other runes of the class of every representative
+}
+
+// newEquivalence checks the classes, which must not share runes, and indexes
+// them; classes of a single rune are dropped
+func newEquivalence(classes []string) (*equivalence, error) {
+	e := &equivalence{rep: make(map[rune]rune), members: make(map[rune][]rune)}
+	owner := make(map[rune]int) // class of every rune seen
+	for k, class := range classes {
+		var runes []rune
+		for _, r := range class {
+			if c, ok := owner[r]; ok {
+				if c == k {
+					continue // repeated in the same class
+				}
+				return nil, fmt.Errorf("ahocorasick: rune %q is in two equivalence classes", r)
+			}
+			owner[r] = k
+			runes = append(runes, r)
+		}
+		if len(runes) < 2 {
+			continue
+		}
+		e.classes = append(e.classes, string(runes))
+		for _, r := range runes[1:] {
+			e.rep[r] = runes[0]
+		}
+		e.members[runes[0]] = runes[1:]
+	}
+	if len(e.classes) == 0 {
+		return nil, nil
+	}
+	return e, nil
+}
+
+// canonical rewrites every rune of s with the representative of its class
+func (e *equivalence) canonical(s string) string {
+	return strings.Map(e.canonicalRune, s)
+}
+
+// canonicalRune returns the representative of the class of r, r itself when
+// it is in no class
+func (e *equivalence) canonicalRune(r rune) rune {
+	if c, ok := e.rep[r]; ok {
+		return c
+	}
+	return r
+}
+
+// alias reports whether a transition labeled r duplicates the transition
+// over the representative of its class
+func (m *Matcher) alias(r rune) bool {
+	if m.equiv == nil {
+		return false
+	}
+	_, ok := m.equiv.rep[r]
+	return ok
+}
+
+// addAliases duplicates every transition over a class representative for
+// the other runes of the class, keeping the transitions of every state
+// sorted by label
+func (m *Matcher) addAliases() {
+	edges := make([]edge, 0, len(m.edges))
+	for s := range m.states {
+		st := &m.states[s]
+		first := len(edges)
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			edges = append(edges, e)
+			for _, r := range m.equiv.members[e.label] {
+				edges = append(edges, edge{label: r, next: e.next})
+			}
+		}
+		own := edges[first:]
+		sort.Slice(own, func(i, j int) bool { return own[i].label < own[j].label })
+		st.edges = uint32(first)
+		st.nedges = uint32(len(own))
+	}
+	m.edges = edges
+}
@@ -0,0 +1,63 @@
+// equiv_test.go: tests for rune equivalence classes
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEquivalence(t *testing.T) {
+	classes := []string{"eéèê", "o0", "aáà"}
+	words := []string{"café", "hello", "banana", "ab", "bá"}
+	m := NewStringMatcher(words, WithEquivalence(classes...))
+
+	assert(t, m.ContainsString("cafe") && m.ContainsString("cafè"))
+	assert(t, m.ContainsString("hell0") && !m.ContainsString("hellO"))
+	found := m.FindAllString("hèll0 bánàná")
+	assert(t, len(found) == 3)
+	assert(t, found[0] == Match{Pattern: 1, Start: 0, End: 6})
+	assert(t, found[1] == Match{Pattern: 4, Start: 7, End: 10})
+	assert(t, found[2] == Match{Pattern: 2, Start: 7, End: len("hèll0 bánàná")})
+
+	// the same occurrences as rewriting the input, which costs a normalizer
+	equiv, err := newEquivalence(classes)
+	assert(t, err == nil)
+	norm := NewStringMatcher(words, WithNormalizer(MapRune(equiv.canonicalRune)))
+	text := strings.Repeat("bábàbab cafe banána ", 20)
+	want := norm.FindAllString(text)
+	got := m.FindAllString(text)
+	assert(t, len(got) == len(want) && len(want) > 0)
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+	assert(t, len(m.MatchString(text)) == len(norm.MatchString(text)))
+
+	// no extra work at match time: the transitions hold the aliases
+	assert(t, len(m.norm) == 0 && len(m.edges) > len(NewStringMatcher(words).edges))
+
+	// streamed dictionaries and bloom filters
+	s, err := CompileSeq(seqOf(words), WithEquivalence(classes...))
+	assert(t, err == nil && len(s.FindAllString(text)) == len(want))
+	b := NewStringMatcher(words, WithEquivalence(classes...), WithBloomFilter())
+	assert(t, b.ContainsString("bánàná"))
+
+	_, err = Compile(words, WithEquivalence("ab", "bc"))
+	assert(t, err != nil)
+	assert(t, NewStringMatcher(words, WithEquivalence("a", "ee")).equiv == nil)
+}
+
+func TestEquivalenceSaved(t *testing.T) {
+	m := NewStringMatcher([]string{"café", "cafe"}, WithEquivalence("eé"))
+	var b strings.Builder
+	assert(t, m.Save(&b) == nil)
+	loaded, err := LoadBytes([]byte(b.String()))
+	assert(t, err == nil && loaded.equiv != nil)
+	assert(t, loaded.FindAllString("café cafe")[0].Pattern == 1)
+
+	// walks over the trie skip the aliases
+	for _, x := range []*Matcher{m, loaded} {
+		s := x.Suggest("café", 1)
+		assert(t, len(s) == 1 && s[0].Distance == 0 && s[0].Word == "cafe")
+	}
+}
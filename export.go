@@ -0,0 +1,107 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// export.go: a JSON description of the compiled automaton for inspection.
+
+package ahocorasick
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ExportOption configures ExportJSON
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	maxDepth int // deepest state exported, negative for all
+}
+
+// WithExportDepth limits ExportJSON to the states at most depth runes from
+// the root, deeper states are left out and their parents marked truncated
+func WithExportDepth(depth int) ExportOption {
+	return func(o *exportOptions) {
+		o.maxDepth = depth
+	}
+}
+
+// exportState is the JSON description of a state
+type exportState struct {
+	ID          int                `json:"id"`
+	Prefix      string             `json:"prefix"` // the runes leading to the state
+	Depth       int                `json:"depth"`
+	Output      int                `json:"output"` // pattern ending here, -1 for none
+	Fail        uint32             `json:"fail"`
+	Suffix      uint32             `json:"suffix"` // nearest output state on the fail chain
+	Transitions []exportTransition `json:"transitions,omitempty"`
+	Truncated   bool               `json:"truncated,omitempty"` // transitions left out by the depth limit
+}
+
+// exportTransition is the JSON description of a transition
+type exportTransition struct {
+	Label string `json:"label"`
+	Next  uint32 `json:"next"`
+}
+
+// ExportJSON writes a structured description of the automaton to w: its
+// size, then every state in breadth-first order with its prefix, output,
+// links and transitions, for tooling auditing what is compiled into a
+// production matcher
+// patterns are shown as the automaton holds them, i.e. normalized and case
+// folded when the matcher does so; the states of a minimized matcher no
+// longer have one prefix, so it cannot be exported and ErrNotSerializable
+// is returned
+func (m *Matcher) ExportJSON(w io.Writer, opts ...ExportOption) error {
+	if m.equiv == nil && m.merged() {
+		return fmt.Errorf("%w: %w", ErrNotSerializable, errMinimized)
+	}
+	o := exportOptions{maxDepth: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m.depthOnce.Do(m.computeDepths)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `{"states":%d,"edges":%d,"patterns":%d,"nodes":[`, len(m.states), len(m.edges), m.npatterns)
+	enc := json.NewEncoder(bw)
+	prefix := make(map[uint32]string)
+	prefix[root] = ""
+	first := true
+	for s := range m.states {
+		id := uint32(s)
+		depth := int(m.depth[id])
+		if o.maxDepth >= 0 && depth > o.maxDepth {
+			continue
+		}
+		st := &m.states[id]
+		node := exportState{
+			ID:     s,
+			Prefix: prefix[id],
+			Depth:  depth,
+			Output: int(m.outputs[id]),
+			Fail:   m.failLink(id),
+			Suffix: m.suffixLink(id),
+		}
+		delete(prefix, id)
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			if o.maxDepth >= 0 && depth >= o.maxDepth {
+				node.Truncated = true
+				break
+			}
+			node.Transitions = append(node.Transitions, exportTransition{Label: string(e.label), Next: e.next})
+			if !m.alias(e.label) {
+				prefix[e.next] = node.Prefix + string(e.label)
+			}
+		}
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		if err := enc.Encode(node); err != nil {
+			return err
+		}
+	}
+	bw.WriteString("]}\n")
+	return bw.Flush()
+}
@@ -0,0 +1,90 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// export_test.go: tests for the JSON export of the automaton
+
+package ahocorasick
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type exportDoc struct {
+	States   int           `json:"states"`
+	Edges    int           `json:"edges"`
+	Patterns int           `json:"patterns"`
+	Nodes    []exportState `json:"nodes"`
+}
+
+func exportDecode(t *testing.T, m *Matcher, opts ...ExportOption) exportDoc {
+	var buf strings.Builder
+	if err := m.ExportJSON(&buf, opts...); err != nil {
+		t.Fatal(err)
+	}
+	var doc exportDoc
+	if err := json.Unmarshal([]byte(buf.String()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestExportJSON(t *testing.T) {
+	words := []string{"he", "she", "his", "hers", "中文"}
+	m := NewStringMatcher(words)
+	doc := exportDecode(t, m)
+	assert(t, doc.States == len(m.states) && doc.Edges == len(m.edges))
+	assert(t, doc.Patterns == len(words))
+	assert(t, len(doc.Nodes) == len(m.states))
+	outputs := 0
+	for _, n := range doc.Nodes {
+		if n.Output >= 0 {
+			outputs++
+			assert(t, n.Prefix == words[n.Output])
+		}
+		assert(t, !n.Truncated)
+	}
+	assert(t, outputs == len(words))
+	assert(t, doc.Nodes[0].Prefix == "" && len(doc.Nodes[0].Transitions) == 3)
+
+	// "she" falls back to "he"
+	for _, n := range doc.Nodes {
+		if n.Prefix == "she" {
+			assert(t, doc.Nodes[n.Fail].Prefix == "he")
+			assert(t, doc.Nodes[n.Suffix].Output == 0)
+		}
+	}
+}
+
+func TestExportJSONDepth(t *testing.T) {
+	m := NewStringMatcher([]string{"he", "she", "his", "hers"})
+	doc := exportDecode(t, m, WithExportDepth(1))
+	assert(t, len(doc.Nodes) == 3)
+	for _, n := range doc.Nodes[1:] {
+		assert(t, n.Depth == 1 && n.Truncated && len(n.Transitions) == 0)
+	}
+	doc = exportDecode(t, m, WithExportDepth(0))
+	assert(t, len(doc.Nodes) == 1 && doc.Nodes[0].Truncated)
+}
+
+func TestExportJSONEquivalence(t *testing.T) {
+	m := NewStringMatcher([]string{"café", "cafe"}, WithEquivalence("eé"))
+	var b strings.Builder
+	assert(t, m.Save(&b) == nil)
+	loaded, err := LoadBytes([]byte(b.String()))
+	assert(t, err == nil)
+
+	// the export skips the aliases
+	var out strings.Builder
+	assert(t, loaded.ExportJSON(&out) == nil && strings.Contains(out.String(), `"prefix":"cafe"`))
+	assert(t, !strings.Contains(out.String(), `"prefix":"café"`))
+}
+
+func TestExportJSONMinimized(t *testing.T) {
+	min, err := CompileSynonyms([][]string{leetVariants("secret")}, WithMinimize())
+	assert(t, err == nil)
+	var out strings.Builder
+	err = min.ExportJSON(&out)
+	assert(t, errors.Is(err, ErrNotSerializable) && errors.Is(err, errMinimized) && out.Len() == 0)
+}
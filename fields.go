@@ -0,0 +1,58 @@
+// fields.go: matching pre-split fields of structured records such as CSV.
+
+package ahocorasick
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+)
+
+// FieldMatch is an occurrence found in a field of a record
+type FieldMatch struct {
+	Record  int // index of the record, starting at 0
+	Field   int // index of the field in the record
+	Pattern int // index of the pattern in the dictionary
+	Start   int // byte offset of the first byte in the field
+	End     int // byte offset just after the last byte in the field
+}
+
+// MatchFields returns the occurrences found in the fields of a record, each
+// field matched on its own like FindAll so a pattern never spans two
+// fields; Record is 0 in every result
+func (m *Matcher) MatchFields(fields []string) []FieldMatch {
+	return m.appendFields(nil, 0, fields)
+}
+
+// MatchCSV reads every record of r and returns the occurrences found in its
+// fields, like MatchFields with the records numbered in reading order
+// a header row, if any, should be read from r before the call
+func (m *Matcher) MatchCSV(r *csv.Reader) ([]FieldMatch, error) {
+	var found []FieldMatch
+	for record := 0; ; record++ {
+		fields, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return found, nil
+		}
+		if err != nil {
+			return found, err
+		}
+		found = m.appendFields(found, record, fields)
+	}
+}
+
+// appendFields appends the occurrences found in the fields of a record
+func (m *Matcher) appendFields(found []FieldMatch, record int, fields []string) []FieldMatch {
+	for field, text := range fields {
+		for _, o := range m.FindAllString(text) {
+			found = append(found, FieldMatch{
+				Record:  record,
+				Field:   field,
+				Pattern: o.Pattern,
+				Start:   o.Start,
+				End:     o.End,
+			})
+		}
+	}
+	return found
+}
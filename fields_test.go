@@ -0,0 +1,41 @@
+// fields_test.go: tests for matching fields of structured records
+
+package ahocorasick
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestMatchFields(t *testing.T) {
+	m := NewStringMatcher([]string{"root", "admin", "tad"})
+
+	found := m.MatchFields([]string{"user=root", "team", "admin"})
+	assert(t, len(found) == 2)
+	assert(t, found[0] == FieldMatch{Record: 0, Field: 0, Pattern: 0, Start: 5, End: 9})
+	assert(t, found[1] == FieldMatch{Record: 0, Field: 2, Pattern: 1, Start: 0, End: 5})
+
+	// "t" + "ad" across two fields is not an occurrence
+	assert(t, len(m.MatchFields([]string{"t", "ad"})) == 0)
+	assert(t, m.MatchFields(nil) == nil)
+}
+
+func TestMatchCSV(t *testing.T) {
+	m := NewStringMatcher([]string{"root", "admin"})
+	r := csv.NewReader(strings.NewReader("name,role\nalice,\"admin, root\"\nbob,user\ncarol,root\n"))
+	_, err := r.Read() // header
+	assert(t, err == nil)
+
+	found, err := m.MatchCSV(r)
+	assert(t, err == nil)
+	assert(t, len(found) == 3)
+	assert(t, found[0] == FieldMatch{Record: 0, Field: 1, Pattern: 1, Start: 0, End: 5})
+	assert(t, found[1] == FieldMatch{Record: 0, Field: 1, Pattern: 0, Start: 7, End: 11})
+	assert(t, found[2] == FieldMatch{Record: 2, Field: 1, Pattern: 0, Start: 0, End: 4})
+
+	// records read before a malformed one are kept
+	r = csv.NewReader(strings.NewReader("root,a\nx,\"unterminated\n"))
+	found, err = m.MatchCSV(r)
+	assert(t, err != nil && len(found) == 1)
+}
@@ -0,0 +1,188 @@
+// file.go: scanning large files with several goroutines.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// fileChunk is the size of the chunks a file is read in when it cannot be
+// mapped into memory
+const fileChunk = 4 << 20
+
+// MatchFile reports every occurrence of every pattern in the file at path,
+// like FindAll over its whole content, scanning it with up to workers
+// goroutines (GOMAXPROCS when workers <= 0) as MatchParallel does
+// the file is mapped read-only into memory when the platform allows it and
+// otherwise read in chunks by the workers, so it never has to fit in memory;
+// it must not be modified during the call
+func (m *Matcher) MatchFile(path string, workers int) ([]Match, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// files that cannot be mapped, such as special files, are read instead
+	if data, unmap, err := mapFile(f); err == nil && data != nil {
+		defer unmap()
+		return m.MatchParallel(data, workers), nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return m.matchReaderAt(f, fi.Size(), workers, fileChunk)
+}
+
+// FindAllRange reports the occurrences ending in bytes lo to hi of r like
+// FindAll, with offsets relative to the start of r, so indexing systems can
+// split a huge file into segments scanned by different workers or machines:
+// up to the longest pattern is read before lo, and a few bytes after hi, so
+// contiguous ranges together report every occurrence exactly once, as long
+// as the overlap policy is ReportAll; other policies are applied per range
+// the data may end before hi; with normalization, validators or positions,
+// which may look anywhere in the text, all of r is read
+func (m *Matcher) FindAllRange(r io.ReaderAt, lo, hi int64) ([]Match, error) {
+	if lo < 0 || hi < lo {
+		return nil, fmt.Errorf("ahocorasick: invalid byte range %d-%d", lo, hi)
+	}
+	if m.norm != nil || m.validators != nil || m.positions != nil {
+		data, err := io.ReadAll(io.NewSectionReader(r, 0, math.MaxInt64))
+		if err != nil {
+			return nil, err
+		}
+		var matches []Match
+		for _, o := range m.FindAll(data) {
+			if int64(o.End) > lo && int64(o.End) <= hi {
+				matches = append(matches, o)
+			}
+		}
+		return matches, nil
+	}
+	m.depthOnce.Do(m.computeDepths)
+	matches, _, err := m.scanRange(r, -1, lo, hi, m.maxDepth*utf8.UTFMax, nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.mergeChunks([][]Match{matches}), nil
+}
+
+// FindAllSection is like FindAllRange over the bytes of s, with offsets in
+// the data s is a section of, which is read around the section as needed
+func (m *Matcher) FindAllSection(s *io.SectionReader) ([]Match, error) {
+	r, off, n := s.Outer()
+	return m.FindAllRange(r, off, off+n)
+}
+
+// matchReaderAt reports every occurrence in the size bytes of r, read in
+// chunks of the given size by up to workers goroutines; every chunk is read
+// with an overlap before it and a few bytes around its bounds, so bounds
+// are moved to rune starts exactly like MatchParallel moves them
+func (m *Matcher) matchReaderAt(r io.ReaderAt, size int64, workers, chunk int) ([]Match, error) {
+	m.depthOnce.Do(m.computeDepths)
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	overlap := m.maxDepth * utf8.UTFMax
+	chunk = max(chunk, 2*overlap)
+	// normalization needs the whole text, see MatchParallel, validators
+	// may look anywhere in it and positions are resolved against all of it
+	if size <= int64(chunk) || m.norm != nil || m.validators != nil || m.positions != nil {
+		data := make([]byte, size)
+		if _, err := io.ReadFull(io.NewSectionReader(r, 0, size), data); err != nil {
+			return nil, err
+		}
+		return m.FindAll(data), nil
+	}
+
+	nchunks := (size + int64(chunk) - 1) / int64(chunk)
+	results := make([][]Match, nchunks)
+	var next atomic.Int64
+	var errOnce sync.Once
+	var firstErr error
+	wg := sync.WaitGroup{}
+	for range min(int64(workers), nchunks) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf []byte
+			for k := next.Add(1) - 1; k < nchunks; k = next.Add(1) - 1 {
+				lo := k * int64(chunk)
+				hi := min(lo+int64(chunk), size)
+				var err error
+				results[k], buf, err = m.scanRange(r, size, lo, hi, overlap, buf)
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return m.mergeChunks(results), nil
+}
+
+// scanRange returns the occurrences ending in bytes lo to hi of r, reading
+// them into buf, which is returned for reuse; size is the size of the data
+// of r, or -1 when unknown and found by reading up to its end
+func (m *Matcher) scanRange(r io.ReaderAt, size, lo, hi int64, overlap int, buf []byte) ([]Match, []byte, error) {
+	// the margins leave runeStart enough bytes to look back at on both bounds
+	margin := int64(2 * utf8.UTFMax)
+	b0 := max(lo-int64(overlap)-margin, 0)
+	b1 := hi + margin
+	if size >= 0 {
+		b1 = min(b1, size)
+	}
+	if n := int(b1 - b0); cap(buf) < n {
+		buf = make([]byte, n)
+	} else {
+		buf = buf[:n]
+	}
+	if n, err := r.ReadAt(buf, b0); n < len(buf) {
+		if size >= 0 || err != io.EOF {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, buf, err
+		}
+		buf, size = buf[:n], b0+int64(n)
+		if hi = min(hi, size); lo >= hi {
+			return nil, buf, nil
+		}
+	} else if size < 0 {
+		size = b1 + 1 // more data may follow
+	}
+
+	text := bytesToString(buf)
+	l, h := int(lo-b0), int(hi-b0)
+	if lo > 0 {
+		l = runeStart(text, l)
+	}
+	if hi < size {
+		h = runeStart(text, h)
+	}
+	from := runeStart(text, max(l-overlap, 0))
+	var matches []Match
+	m.find(text[from:h], func(pattern int32, start, end int) bool {
+		if from+end > l {
+			matches = append(matches, Match{
+				Pattern: int(pattern),
+				Start:   int(b0) + from + start,
+				End:     int(b0) + from + end,
+			})
+		}
+		return true
+	})
+	return matches, buf, nil
+}
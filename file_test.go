@@ -0,0 +1,98 @@
+// file_test.go: tests for scanning large files
+
+package ahocorasick
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchFile(t *testing.T) {
+	text := strings.Repeat(string(bytes2)+"中文\xe4\xb8 Mac\xff", 300)
+	dict := append([]string{"中文", "� M", "c�"}, dictionary6...)
+	m := NewStringMatcher(dict)
+	want := m.FindAllString(text)
+	assert(t, len(want) > 0)
+
+	path := filepath.Join(t.TempDir(), "input")
+	assert(t, os.WriteFile(path, []byte(text), 0o644) == nil)
+	got, err := m.MatchFile(path, 4)
+	assert(t, err == nil && len(got) == len(want))
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+
+	// files read in chunks, with bounds falling inside runes and occurrences
+	r := strings.NewReader(text)
+	for _, chunk := range []int{1, 1000, 4093} {
+		got, err := m.matchReaderAt(r, int64(len(text)), 3, chunk)
+		assert(t, err == nil && len(got) == len(want))
+		for i := range got {
+			assert(t, got[i] == want[i])
+		}
+	}
+
+	// a short read is reported
+	_, err = m.matchReaderAt(strings.NewReader(text[:5000]), int64(len(text)), 3, 1000)
+	assert(t, err != nil)
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	assert(t, os.WriteFile(empty, nil, 0o644) == nil)
+	got, err = m.MatchFile(empty, 4)
+	assert(t, err == nil && len(got) == 0)
+
+	_, err = m.MatchFile(filepath.Join(t.TempDir(), "missing"), 4)
+	assert(t, err != nil)
+}
+
+func TestFindAllRange(t *testing.T) {
+	text := strings.Repeat(string(bytes2)+"中文\xe4\xb8 Mac\xff", 50)
+	dict := append([]string{"中文", "� M", "c�"}, dictionary6...)
+	m := NewStringMatcher(dict)
+	want := m.FindAllString(text)
+	r := strings.NewReader(text)
+
+	// contiguous ranges, with bounds inside runes and occurrences, report
+	// every occurrence once with offsets in the whole text; the last range
+	// runs past the end of the data
+	for _, step := range []int64{1, 7, 1000} {
+		var got []Match
+		for lo := int64(0); lo < int64(len(text)); lo += step {
+			found, err := m.FindAllRange(r, lo, lo+step)
+			assert(t, err == nil)
+			for _, o := range found {
+				assert(t, int64(o.End) > lo && int64(o.End) <= lo+step)
+			}
+			got = append(got, found...)
+		}
+		assert(t, len(got) == len(want))
+		for i := range got {
+			assert(t, got[i] == want[i])
+		}
+	}
+
+	got, err := m.FindAllRange(r, int64(len(text)), int64(len(text))+10)
+	assert(t, err == nil && len(got) == 0)
+	_, err = m.FindAllRange(r, 10, 5)
+	assert(t, err != nil)
+
+	// a section reports offsets in the data it is a section of
+	got, err = m.FindAllSection(io.NewSectionReader(r, 500, 300))
+	assert(t, err == nil && len(got) > 0)
+	var k int
+	for _, o := range want {
+		if o.End > 500 && o.End <= 800 {
+			assert(t, got[k] == o)
+			k++
+		}
+	}
+	assert(t, k == len(got))
+
+	// validators see the whole text
+	v := NewStringMatcher([]string{"Mac", "Mozilla"}, WithValidator(WholeWord, 0))
+	got, err = v.FindAllRange(strings.NewReader("Mac Macintosh Mozilla"), 3, 21)
+	assert(t, err == nil && len(got) == 1 && got[0] == Match{Pattern: 1, Start: 14, End: 21})
+}
@@ -0,0 +1,332 @@
+// find.go: positional matching, reporting every occurrence of every pattern
+// together with its location in the input.
+
+package ahocorasick
+
+import "unicode/utf8"
+
+// Match is an occurrence of a pattern in the input
+type Match struct {
+	Pattern int // index of the pattern in the dictionary
+	Start   int // byte offset of the first byte of the occurrence
+	End     int // byte offset just after the last byte of the occurrence
+}
+
+// Columns holds matches as parallel slices instead of a slice of structs,
+// entry i of every slice describes the same occurrence
+// this suits columnar post-processing and lets bulk consumers reuse the
+// same buffers for millions of matches
+type Columns struct {
+	Patterns []int
+	Starts   []int
+	Ends     []int
+}
+
+// Len returns the number of matches held
+func (c *Columns) Len() int {
+	return len(c.Patterns)
+}
+
+// Reset empties c, keeping its buffers for reuse
+func (c *Columns) Reset() {
+	c.Patterns = c.Patterns[:0]
+	c.Starts = c.Starts[:0]
+	c.Ends = c.Ends[:0]
+}
+
+// FindAll returns every occurrence of every pattern in text, ordered by end
+// offset and, for occurrences ending at the same offset, from the longest
+// to the shortest pattern; with an overlap policy other than ReportAll only
+// the occurrences it keeps are returned, ordered by start offset
+// unlike Match it reports repeated occurrences and is safe for concurrent use
+func (m *Matcher) FindAll(text []byte) []Match {
+	return m.FindAllString(bytesToString(text))
+}
+
+// FindAllString is like FindAll for a string input
+func (m *Matcher) FindAllString(text string) []Match {
+	matches := m.resolveOverlaps(m.collect(text))
+	m.countHits(matches)
+	return matches
+}
+
+// FindAllScreened is like FindAll but first looks for an occurrence the way
+// Contains does: a clean text, the common case, costs one scan without any
+// allocation and yields nil, and a dirty one is only rescanned from shortly
+// before its first occurrence to collect the full results
+func (m *Matcher) FindAllScreened(text []byte) []Match {
+	return m.FindAllScreenedString(bytesToString(text))
+}
+
+// FindAllScreenedString is like FindAllScreened for a string input
+func (m *Matcher) FindAllScreenedString(text string) []Match {
+	end := m.firstEnd(text)
+	if end < 0 {
+		return nil
+	}
+	if m.generic() {
+		// occurrences may span more input than their pattern, and
+		// validators see the whole text
+		return m.FindAllString(text)
+	}
+	// no occurrence ends before end, so none starts more than the longest
+	// pattern before it
+	m.depthOnce.Do(m.computeDepths)
+	from := runeStart(text, max(end-m.maxDepth*utf8.UTFMax, 0))
+	matches := m.collect(text[from:])
+	for k := range matches {
+		matches[k].Start += from
+		matches[k].End += from
+	}
+	matches = m.resolveOverlaps(matches)
+	m.countHits(matches)
+	return matches
+}
+
+// collect returns every occurrence in text, before overlap resolution
+func (m *Matcher) collect(text string) []Match {
+	var matches []Match
+	m.find(text, func(pattern int32, start, end int) bool {
+		matches = append(matches, Match{Pattern: int(pattern), Start: start, End: end})
+		return true
+	})
+	return matches
+}
+
+// FindLongest is like FindAll but, of the occurrences ending at the same
+// offset, only reports the longest one: "New York" but not also "York"
+func (m *Matcher) FindLongest(text []byte) []Match {
+	return m.FindLongestString(bytesToString(text))
+}
+
+// FindLongestString is like FindLongest for a string input
+func (m *Matcher) FindLongestString(text string) []Match {
+	var matches []Match
+	m.find(text, func(pattern int32, start, end int) bool {
+		// occurrences ending at the same offset come from the longest
+		if n := len(matches); n > 0 && matches[n-1].End == end {
+			return true
+		}
+		m.hits.add(pattern)
+		matches = append(matches, Match{Pattern: int(pattern), Start: start, End: end})
+		return true
+	})
+	return matches
+}
+
+// FindAllColumns appends every occurrence of every pattern in text to c, in
+// the order of FindAll
+func (m *Matcher) FindAllColumns(c *Columns, text []byte) {
+	m.FindAllColumnsString(c, bytesToString(text))
+}
+
+// FindAllColumnsString is like FindAllColumns for a string input
+func (m *Matcher) FindAllColumnsString(c *Columns, text string) {
+	if m.overlap != ReportAll {
+		for _, o := range m.FindAllString(text) {
+			c.Patterns = append(c.Patterns, o.Pattern)
+			c.Starts = append(c.Starts, o.Start)
+			c.Ends = append(c.Ends, o.End)
+		}
+		return
+	}
+	m.find(text, func(pattern int32, start, end int) bool {
+		m.hits.add(pattern)
+		c.Patterns = append(c.Patterns, int(pattern))
+		c.Starts = append(c.Starts, start)
+		c.Ends = append(c.Ends, end)
+		return true
+	})
+}
+
+// computeDepths records the depth in runes of every state, which is the
+// length of the pattern ending there, and sizes the ring of rune offsets
+// kept by find; states are numbered breadth-first, so a parent is always
+// visited before its children
+func (m *Matcher) computeDepths() {
+	m.depth = make([]uint32, len(m.states))
+	maxDepth := uint32(0)
+	for s := range m.states {
+		st := &m.states[s]
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			m.depth[e.next] = m.depth[s] + 1
+			maxDepth = max(maxDepth, m.depth[e.next])
+		}
+	}
+	m.maxDepth = int(maxDepth)
+	m.minDepth = 0
+	for s, out := range m.outputs {
+		if out >= 0 && (m.minDepth == 0 || int(m.depth[s]) < m.minDepth) {
+			m.minDepth = int(m.depth[s])
+		}
+	}
+	size := 1
+	for size < int(maxDepth) {
+		size <<= 1
+	}
+	m.ringMask = size - 1
+}
+
+// find scans text and calls emit for every occurrence with the pattern index
+// and its byte offsets, until emit returns false
+// the start offsets of the last runes are kept in a ring as long as the
+// longest pattern, so occurrences are located exactly even when invalid
+// UTF-8 sequences are decoded as replacement runes
+func (m *Matcher) find(text string, emit func(pattern int32, start, end int) bool) {
+	m.findWith(text, nil, emit)
+}
+
+// findWith is like find with the input rewritten by transform before the
+// normalization of the matcher, if any; the patterns were not rewritten by
+// it, so nothing known about them can skip or reject input
+func (m *Matcher) findWith(text string, transform pipeline, emit func(pattern int32, start, end int) bool) {
+	if transform == nil && (m.tooShort(text) || m.screened(text)) {
+		return
+	}
+	if off := m.disabledPatterns(); len(off) > 0 {
+		report := emit
+		emit = func(pattern int32, start, end int) bool {
+			return off.has(pattern) || report(pattern, start, end)
+		}
+	}
+	if m.validators != nil {
+		report := emit
+		emit = func(pattern int32, start, end int) bool {
+			return !m.accepts(pattern, text, start, end) || report(pattern, start, end)
+		}
+	}
+	scan := text
+	from := 0
+	if m.positions != nil {
+		spans, lo, hi := m.positions.resolve(text)
+		report := emit
+		emit = func(pattern int32, start, end int) bool {
+			k := m.positions.of[pattern]
+			return k >= 0 && !spans[k].allows(start, end) || report(pattern, start, end)
+		}
+		// occurrences outside of every span need not be looked for, unless
+		// normalization moves them
+		if m.positions.all && transform == nil && m.norm == nil {
+			if lo >= hi {
+				return
+			}
+			scan, from = text[:hi], lo
+		}
+	}
+	if m.ids != nil {
+		report := emit
+		emit = func(pattern int32, start, end int) bool {
+			return report(m.ids[pattern], start, end)
+		}
+	}
+	m.depthOnce.Do(m.computeDepths)
+	ring := make([]int, m.ringMask+1)
+	var norm *normScanner
+	var pending []occurrence
+	if transform != nil {
+		norm = append(transform[:len(transform):len(transform)], m.norm...).scanner()
+	} else if m.norm != nil {
+		norm = m.norm.scanner()
+	}
+	n := uint32(root)
+	for i, pos := from, 0; i < len(scan); {
+		if transform == nil {
+			if i = m.skip(scan, i, n); i == len(scan) {
+				break
+			}
+		}
+		r, size := decodeRune(scan, i)
+		start := i
+		i += size
+		if norm == nil {
+			// the common case, every input rune is fed as is
+			ring[pos&m.ringMask] = start
+			if m.fold != nil {
+				r = foldRune(r)
+			}
+			n = m.step(n, r)
+			if out := m.outputs[n]; out >= 0 {
+				if !m.emit(out, text, m.startOf(ring, pos, i, n), i, emit) {
+					return
+				}
+			}
+			for f := m.suffixLink(n); f != root; f = m.suffixLink(f) {
+				if !m.emit(m.outputs[f], text, m.startOf(ring, pos, i, f), i, emit) {
+					return
+				}
+			}
+			pos++
+			continue
+		}
+		// the runes an input rune normalizes to all span its bytes, and the
+		// occurrences ending with them also span the following input runes
+		// that normalize to nothing, such as combining marks
+		runes := norm.next(r)
+		if len(runes) == 0 {
+			continue
+		}
+		if !m.flush(pending, text, start, emit) {
+			return
+		}
+		pending = pending[:0]
+		for _, r := range runes {
+			ring[pos&m.ringMask] = start
+			if m.fold != nil {
+				r = foldRune(r)
+			}
+			n = m.step(n, r)
+			if out := m.outputs[n]; out >= 0 {
+				pending = append(pending, occurrence{out, m.startOf(ring, pos, i, n)})
+			}
+			for f := m.suffixLink(n); f != root; f = m.suffixLink(f) {
+				pending = append(pending, occurrence{m.outputs[f], m.startOf(ring, pos, i, f)})
+			}
+			pos++
+		}
+	}
+	m.flush(pending, text, len(text), emit)
+}
+
+// occurrence is the output of a state found at some start offset, whose end
+// offset is not known yet
+type occurrence struct {
+	out   int32
+	start int
+}
+
+// flush emits the pending occurrences, which end at offset end
+func (m *Matcher) flush(pending []occurrence, text string, end int, emit func(pattern int32, start, end int) bool) bool {
+	for _, o := range pending {
+		if !m.emit(o.out, text, o.start, end, emit) {
+			return false
+		}
+	}
+	return true
+}
+
+// emit reports the occurrence text[start:end] of the output out of a state,
+// which stands for a chain of patterns when case flags are in use
+func (m *Matcher) emit(out int32, text string, start, end int, emit func(pattern int32, start, end int) bool) bool {
+	if m.fold != nil {
+		return m.fold.emit(out, text, start, end, emit)
+	}
+	return emit(out, start, end)
+}
+
+// startOf returns the start offset of the occurrence of the pattern of state
+// f ending with the rune number pos, which ends at byte offset end
+func (m *Matcher) startOf(ring []int, pos, end int, f uint32) int {
+	d := int(m.depth[f])
+	if d == 0 {
+		return end
+	}
+	return ring[(pos-d+1)&m.ringMask]
+}
+
+// id returns the ID reported for pattern p
+func (m *Matcher) id(p int32) int32 {
+	if m.ids != nil {
+		return m.ids[p]
+	}
+	return p
+}
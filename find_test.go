@@ -0,0 +1,101 @@
+// find_test.go: tests for positional matching
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindAll(t *testing.T) {
+	m := NewStringMatcher([]string{"he", "she", "his", "hers"})
+	matches := m.FindAllString("ushers she")
+	assert(t, len(matches) == 5)
+	assert(t, matches[0] == Match{Pattern: 1, Start: 1, End: 4})
+	assert(t, matches[1] == Match{Pattern: 0, Start: 2, End: 4})
+	assert(t, matches[2] == Match{Pattern: 3, Start: 2, End: 6})
+	assert(t, matches[3] == Match{Pattern: 1, Start: 7, End: 10})
+	assert(t, matches[4] == Match{Pattern: 0, Start: 8, End: 10})
+
+	assert(t, len(m.FindAll([]byte("nothing"))) == 0)
+}
+
+func TestFindAllMultiByte(t *testing.T) {
+	m := NewStringMatcher([]string{"中文", "文测", "�x"})
+	text := "这是中文测试\xffx"
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 3)
+	assert(t, text[matches[0].Start:matches[0].End] == "中文")
+	assert(t, text[matches[1].Start:matches[1].End] == "文测")
+
+	// an invalid byte is decoded as one replacement rune but spans one byte
+	assert(t, matches[2] == Match{Pattern: 2, Start: len(text) - 2, End: len(text)})
+}
+
+func TestFindLongest(t *testing.T) {
+	m := NewStringMatcher([]string{"York", "New York", "New York City", "City"})
+	matches := m.FindLongestString("New York City")
+	assert(t, len(matches) == 2)
+	assert(t, matches[0] == Match{Pattern: 1, Start: 0, End: 8})
+	assert(t, matches[1] == Match{Pattern: 2, Start: 0, End: 13})
+
+	// with case flags the longest pattern accepting the input is reported
+	m = NewStringMatcher([]string{"york", "New york"}, WithCaseInsensitive(0))
+	matches = m.FindLongest([]byte("NEW YORK"))
+	assert(t, len(matches) == 1)
+	assert(t, matches[0] == Match{Pattern: 0, Start: 4, End: 8})
+}
+
+func TestFindAllColumns(t *testing.T) {
+	var c Columns
+	precomputed.FindAllColumns(&c, bytes)
+	assert(t, c.Len() == 5)
+	matches := precomputed.FindAll(bytes)
+	for i, match := range matches {
+		assert(t, c.Patterns[i] == match.Pattern)
+		assert(t, c.Starts[i] == match.Start)
+		assert(t, c.Ends[i] == match.End)
+		assert(t, sbytes[match.Start:match.End] == dictionary[match.Pattern])
+	}
+
+	c.Reset()
+	assert(t, c.Len() == 0)
+	precomputed.FindAllColumnsString(&c, "Mac")
+	assert(t, c.Len() == 1)
+}
+
+func BenchmarkLargeFindAllColumns(b *testing.B) {
+	b.ReportAllocs()
+	var c Columns
+	for i := 0; i < b.N; i++ {
+		c.Reset()
+		precomputed6.FindAllColumns(&c, bytes2)
+	}
+}
+
+func TestFindAllScreened(t *testing.T) {
+	clean := strings.Repeat("0123 5678 ", 100)
+	assert(t, precomputed6.FindAllScreenedString(clean) == nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		precomputed6.FindAllScreenedString(clean)
+	})
+	assert(t, allocs == 0)
+
+	texts := []string{string(bytes2), clean + string(bytes2), clean + "Mac" + clean + "Macintosh"}
+	matchers := []*Matcher{
+		precomputed6,
+		precomputed,
+		NewStringMatcher(dictionary, WithOverlapPolicy(LongestWins)),
+		NewStringMatcher(dictionary, WithCaseInsensitive(1)),
+	}
+	for _, m := range matchers {
+		for _, text := range texts {
+			want := m.FindAllString(text)
+			got := m.FindAllScreened([]byte(text))
+			assert(t, len(got) == len(want))
+			for i := range got {
+				assert(t, got[i] == want[i])
+			}
+		}
+	}
+}
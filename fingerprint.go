@@ -0,0 +1,73 @@
+// fingerprint.go: a content hash identifying what a matcher matches.
+
+package ahocorasick
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Fingerprint returns a stable hash, in hexadecimal, of the compiled
+// automaton and of the options affecting its results: two matchers with the
+// same fingerprint report the same occurrences, whichever process, machine
+// or release built them, so it can key caches of serialized artifacts or
+// check that all replicas run the same dictionary version
+// it is computed on the first call; runtime state such as disabled patterns
+// is not included
+// the built-in normalizations and contexts are identified by what they do,
+// but functions given by the caller, to WithNormalizer, WithValidator or
+// WithPinyin or as the class of a Context, cannot be hashed: only their kind
+// and place contribute, so matchers differing only by such functions share
+// a fingerprint and the guarantee above does not hold for them
+func (m *Matcher) Fingerprint() string {
+	m.fingerprintOnce.Do(func() {
+		h := sha256.New()
+		m.writeFingerprint(h)
+		m.fingerprint = hex.EncodeToString(h.Sum(nil))
+	})
+	return m.fingerprint
+}
+
+// writeFingerprint writes everything the fingerprint covers to w: the saved
+// form of the automaton, with its metadata, followed by the options that
+// cannot be saved
+func (m *Matcher) writeFingerprint(w io.Writer) {
+	m.encode(w) // writes to a hash never fail
+	fmt.Fprintf(w, "overlap %d\nnormalizers %d\n", m.overlap, len(m.norm))
+	for _, kind := range m.normKinds {
+		fmt.Fprintf(w, "%s\n", kind)
+	}
+	if m.fold != nil {
+		fmt.Fprintf(w, "sensitive %d\n", len(m.fold.sensitive))
+		for _, s := range m.fold.sensitive {
+			if s {
+				w.Write([]byte{1})
+			} else {
+				w.Write([]byte{0})
+			}
+		}
+	}
+	if m.validators != nil {
+		fmt.Fprintf(w, "validators %d\n", len(m.validators))
+		for _, v := range m.validators {
+			w.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		}
+		for _, kind := range m.validatorKinds {
+			fmt.Fprintf(w, "%s\n", kind)
+		}
+	}
+	if m.positions != nil {
+		fmt.Fprintf(w, "positions %d\n", len(m.positions.of))
+		for _, k := range m.positions.of {
+			if k < 0 {
+				fmt.Fprintf(w, "-\n")
+				continue
+			}
+			p := m.positions.set[k]
+			fmt.Fprintf(w, "%d %d %v\n", p.First, p.Last, p.Windows)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// fingerprint_test.go: tests for the content hash of a matcher
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	fp := NewStringMatcher(dictionary).Fingerprint()
+	assert(t, len(fp) == 64)
+	assert(t, fp == NewStringMatcher(dictionary).Fingerprint())
+	assert(t, fp == precomputed.Fingerprint())
+
+	// loaded matchers keep the fingerprint of the one that was saved
+	var b strings.Builder
+	assert(t, precomputed.Save(&b) == nil)
+	loaded, err := LoadBytes([]byte(b.String()))
+	assert(t, err == nil && loaded.Fingerprint() == fp)
+
+	// options not affecting results do not change it
+	assert(t, NewStringMatcher(dictionary, WithHitCounters(), WithTransitionCache(4)).Fingerprint() == fp)
+
+	different := []*Matcher{
+		NewStringMatcher(dictionary[1:]),
+		NewStringMatcher([]string{"Mac", "Mozilla", "Macintosh", "Safari", "Sausage"}),
+		NewStringMatcher(dictionary, WithOverlapPolicy(LongestWins)),
+		NewStringMatcher(dictionary, WithCaseInsensitive(0)),
+		NewStringMatcher(dictionary, WithCaseInsensitive(1)),
+		NewStringMatcher(dictionary, WithPatternInfo([]PatternInfo{{Severity: 1}})),
+		NewStringMatcher(dictionary, WithValidator(Luhn, 0)),
+		NewStringMatcher(dictionary, WithValidator(Luhn, 1)),
+		// built-in normalizations and contexts are told apart
+		NewStringMatcher(dictionary, WithWhitespaceCollapsing()),
+		NewStringMatcher(dictionary, WithDiacriticFolding()),
+		NewStringMatcher(dictionary, WithRTLNormalization(false)),
+		NewStringMatcher(dictionary, WithRTLNormalization(true)),
+		NewStringMatcher(dictionary, WithTrailingContext(Context{Edge: true}, 0)),
+		NewStringMatcher(dictionary, WithNegativeContext(Context{}, Context{Edge: true}, 0)),
+		NewStringMatcher(dictionary, WithNegativeContext(Context{Edge: true}, Context{}, 0)),
+		NewStringMatcher(dictionary, WithTrailingContext(Context{Strings: []string{"x"}}, 0)),
+	}
+	seen := map[string]bool{fp: true}
+	for _, m := range different {
+		assert(t, !seen[m.Fingerprint()])
+		seen[m.Fingerprint()] = true
+	}
+
+	// the fingerprint is stable across releases
+	assert(t, NewStringMatcher([]string{"he", "she"}).Fingerprint() == "881be30051300fabd6b453650dba105d0a482afb0bbbcda21ac941f979e39ad5")
+}
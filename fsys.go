@@ -0,0 +1,103 @@
+// fsys.go: loading dictionaries from file systems, embedded ones included.
+
+package ahocorasick
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// NewMatcherFromFS builds a matcher from the dictionary files of fsys
+// matching glob, in the syntax of fs.Glob, so a binary can ship its
+// wordlists in an embed.FS and load them with one call
+// files hold one word per line, spaces around it trimmed and blank lines
+// skipped, and are read in lexical order of their names; the returned
+// slice holds the words by pattern index
+// it fails when no file matches glob; see WithFileCategories to group the
+// patterns by file
+func NewMatcherFromFS(fsys fs.FS, glob string, opts ...Option) (*Matcher, []string, error) {
+	names, err := fs.Glob(fsys, glob)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("ahocorasick: no dictionary file matches %q", glob)
+	}
+	var words []string
+	o := newOptions(opts)
+	byFile := make(map[string][]int)
+	for _, name := range names {
+		first := len(words)
+		if words, err = readWords(fsys, name, words); err != nil {
+			return nil, nil, err
+		}
+		if o.fileCategories != nil {
+			category := fileCategory(name, o.fileCategories)
+			for i := first; i < len(words); i++ {
+				byFile[category] = append(byFile[category], i)
+			}
+		}
+	}
+	if o.fileCategories != nil {
+		for name, patterns := range o.categories {
+			byFile[name] = append(byFile[name], patterns...)
+		}
+		opts = append(opts, WithCategories(byFile))
+	}
+	m, err := Compile(words, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, words, nil
+}
+
+// WithFileCategories makes NewMatcherFromFS put the patterns of every file
+// in a category, so the organization of the files drives the grouping of
+// policies: names maps a file name, as returned by the glob, to its category,
+// and the files it does not list, all of them when it is nil, are put in
+// the category named after the file without directory and extension, such
+// as "profanity" for "lists/profanity.txt"
+// the categories are added to those given with WithCategories; other
+// constructors ignore this option
+func WithFileCategories(names map[string]string) Option {
+	return func(o *options) {
+		if names == nil {
+			names = map[string]string{}
+		}
+		o.fileCategories = names
+	}
+}
+
+// fileCategory returns the category of the patterns of file name
+func fileCategory(name string, names map[string]string) string {
+	if category, ok := names[name]; ok {
+		return category
+	}
+	base := path.Base(name)
+	if stem := strings.TrimSuffix(base, path.Ext(base)); stem != "" {
+		return stem
+	}
+	return base
+}
+
+// readWords appends to words the words of file name of fsys
+func readWords(fsys fs.FS, name string, words []string) ([]string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if word := strings.TrimSpace(sc.Text()); word != "" {
+			words = append(words, word)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("ahocorasick: %s: %w", name, err)
+	}
+	return words, nil
+}
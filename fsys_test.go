@@ -0,0 +1,61 @@
+// fsys_test.go: tests for dictionaries loaded from file systems
+
+package ahocorasick
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewMatcherFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"words/b.txt":   {Data: []byte("Safari\r\n\n  Sausage \n")},
+		"words/a.txt":   {Data: []byte("Mozilla\nMac\nMacintosh")},
+		"words/c.csv":   {Data: []byte("ignored")},
+		"other/d.txt":   {Data: []byte("ignored")},
+		"words/sub/e.t": {Data: []byte("ignored")},
+	}
+	m, words, err := NewMatcherFromFS(fsys, "words/*.txt")
+	assert(t, err == nil && len(words) == 5)
+	assert(t, words[0] == "Mozilla" && words[3] == "Safari" && words[4] == "Sausage")
+	hits := m.MatchString(sbytes)
+	assert(t, len(hits) == len(precomputed.MatchString(sbytes)))
+	for k, i := range hits {
+		assert(t, words[i] == dictionary[precomputed.MatchString(sbytes)[k]])
+	}
+
+	_, _, err = NewMatcherFromFS(fsys, "words/*.json")
+	assert(t, err != nil)
+	_, _, err = NewMatcherFromFS(fsys, "words/[")
+	assert(t, err != nil)
+
+	m, _, err = NewMatcherFromFS(fsys, "words/a.txt", WithCaseInsensitive(0))
+	assert(t, err == nil && m.ContainsString("MOZILLA"))
+}
+
+func TestNewMatcherFromFSCategories(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lists/profanity.txt": {Data: []byte("darn\nheck")},
+		"lists/slurs.txt":     {Data: []byte("meanie")},
+		"lists/brands.txt":    {Data: []byte("Mozilla\nheck")},
+	}
+	m, words, err := NewMatcherFromFS(fsys, "lists/*.txt", WithFileCategories(nil))
+	assert(t, err == nil && len(words) == 5)
+	cats := m.Categories()
+	assert(t, len(cats) == 3 && cats[0] == "brands" && cats[1] == "profanity" && cats[2] == "slurs")
+	assert(t, m.PatternCategories(2)[0] == "profanity")
+	// a duplicate is reported under its last index
+	assert(t, len(m.PatternCategories(3)) == 1)
+	assert(t, len(m.FindAllWithString("darn heck meanie", MatchOptions{Subset: m.WithoutCategories("profanity")}).Matches) == 1)
+
+	m, _, err = NewMatcherFromFS(fsys, "lists/*.txt",
+		WithFileCategories(map[string]string{"lists/slurs.txt": "profanity"}),
+		WithCategories(map[string][]int{"mild": {2}}))
+	assert(t, err == nil && len(m.Categories()) == 3)
+	p := m.PatternCategories(2)
+	assert(t, len(p) == 2 && p[0] == "mild" && p[1] == "profanity")
+	assert(t, m.PatternCategories(4)[0] == "profanity")
+
+	m, _, err = NewMatcherFromFS(fsys, "lists/*.txt")
+	assert(t, err == nil && m.Categories() == nil)
+}
@@ -0,0 +1,146 @@
+// gen.go: synthetic dictionaries and corpora for benchmarking
+//
+// Package gen generates synthetic dictionaries and texts resembling real
+// workloads (dictionary size, pattern length distribution, alphabet, share of
+// CJK characters and hit density), so the options of the matcher can be
+// benchmarked against data shaped like the caller's own. Generation is
+// deterministic for a given seed.
+
+package gen
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// LengthDistribution draws the length in runes of a pattern
+type LengthDistribution func(r *rand.Rand) int
+
+// Uniform draws lengths uniformly in [min, max]
+func Uniform(min, max int) LengthDistribution {
+	if max < min {
+		max = min
+	}
+	return func(r *rand.Rand) int {
+		return min + r.Intn(max-min+1)
+	}
+}
+
+// Normal draws lengths from a normal distribution clamped to [min, max]
+func Normal(mean, stddev float64, min, max int) LengthDistribution {
+	return func(r *rand.Rand) int {
+		n := int(math.Round(r.NormFloat64()*stddev + mean))
+		if n < min {
+			return min
+		}
+		if n > max {
+			return max
+		}
+		return n
+	}
+}
+
+// cjkFirst and cjkLast bound the CJK Unified Ideographs block
+const (
+	cjkFirst = 0x4E00
+	cjkLast  = 0x9FFF
+)
+
+// Config describes the data to generate, zero fields take their defaults
+type Config struct {
+	Seed     int64              // seed of the random source
+	Words    int                // number of dictionary entries, default 1000
+	Length   LengthDistribution // pattern length in runes, default Uniform(3, 12)
+	Alphabet []rune             // runes used outside CJK, default 'a' to 'z'
+	CJKRatio float64            // probability for a rune to be a CJK ideograph
+
+	// CJKRange limits the CJK ideographs drawn to the first CJKRange runes
+	// of the block, a small range produces realistic shared prefixes;
+	// default is the whole block
+	CJKRange int
+}
+
+// Generator produces dictionaries and corpora for a Config
+type Generator struct {
+	cfg Config
+	rnd *rand.Rand
+}
+
+// New creates a generator, the same config always yields the same data
+func New(cfg Config) *Generator {
+	if cfg.Words <= 0 {
+		cfg.Words = 1000
+	}
+	if cfg.Length == nil {
+		cfg.Length = Uniform(3, 12)
+	}
+	if len(cfg.Alphabet) == 0 {
+		for r := 'a'; r <= 'z'; r++ {
+			cfg.Alphabet = append(cfg.Alphabet, r)
+		}
+	}
+	if cfg.CJKRange <= 0 || cfg.CJKRange > cjkLast-cjkFirst+1 {
+		cfg.CJKRange = cjkLast - cjkFirst + 1
+	}
+	return &Generator{cfg: cfg, rnd: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// Rune draws a single rune according to the alphabet and CJK ratio
+func (g *Generator) Rune() rune {
+	if g.cfg.CJKRatio > 0 && g.rnd.Float64() < g.cfg.CJKRatio {
+		return cjkFirst + rune(g.rnd.Intn(g.cfg.CJKRange))
+	}
+	return g.cfg.Alphabet[g.rnd.Intn(len(g.cfg.Alphabet))]
+}
+
+// Word draws a word whose length follows the length distribution
+func (g *Generator) Word() string {
+	n := g.cfg.Length(g.rnd)
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteRune(g.Rune())
+	}
+	return b.String()
+}
+
+// Dictionary returns Words distinct, non-empty words
+func (g *Generator) Dictionary() []string {
+	seen := make(map[string]struct{}, g.cfg.Words)
+	dict := make([]string, 0, g.cfg.Words)
+	// small alphabets cannot always produce enough distinct words, give up
+	// after a bounded number of collisions
+	for misses := 0; len(dict) < g.cfg.Words && misses < 100*g.cfg.Words; {
+		w := g.Word()
+		if _, ok := seen[w]; ok || w == "" {
+			misses++
+			continue
+		}
+		seen[w] = struct{}{}
+		dict = append(dict, w)
+	}
+	return dict
+}
+
+// Corpus returns a text of about size runes made of random words separated
+// by spaces, where every word is replaced with probability hitRate by an
+// entry of dictionary, so the density of matches can be controlled
+func (g *Generator) Corpus(size int, dictionary []string, hitRate float64) string {
+	var b strings.Builder
+	b.Grow(size)
+	for n := 0; n < size; {
+		var w string
+		if len(dictionary) > 0 && g.rnd.Float64() < hitRate {
+			w = dictionary[g.rnd.Intn(len(dictionary))]
+		} else {
+			w = g.Word()
+		}
+		if n > 0 {
+			b.WriteByte(' ')
+			n++
+		}
+		b.WriteString(w)
+		n += len([]rune(w))
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+// gen_test.go: tests for the synthetic data generator
+
+package gen
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func assert(t *testing.T, b bool) {
+	if !b {
+		t.Fail()
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	cfg := Config{Seed: 42, Words: 500, Length: Uniform(2, 5)}
+	dict := New(cfg).Dictionary()
+	assert(t, len(dict) == 500)
+
+	seen := make(map[string]bool)
+	for _, w := range dict {
+		n := utf8.RuneCountInString(w)
+		assert(t, n >= 2 && n <= 5)
+		assert(t, !seen[w])
+		seen[w] = true
+	}
+
+	// the same seed yields the same data
+	again := New(cfg).Dictionary()
+	for i := range dict {
+		assert(t, dict[i] == again[i])
+	}
+}
+
+func TestCJKRatio(t *testing.T) {
+	g := New(Config{Seed: 1, CJKRatio: 1, Length: Normal(4, 1, 1, 8)})
+	for _, w := range g.Dictionary()[:50] {
+		for _, r := range w {
+			assert(t, r >= cjkFirst && r <= cjkLast)
+		}
+	}
+
+	// small alphabets stop once no new word can be found
+	dict := New(Config{Alphabet: []rune("ab"), Length: Uniform(1, 1), Words: 10}).Dictionary()
+	assert(t, len(dict) == 2)
+}
+
+func TestCorpus(t *testing.T) {
+	g := New(Config{Seed: 7})
+	dict := g.Dictionary()
+	text := g.Corpus(10000, dict, 1)
+	assert(t, utf8.RuneCountInString(text) >= 10000)
+	for _, w := range strings.Fields(text)[:20] {
+		found := false
+		for _, d := range dict {
+			found = found || d == w
+		}
+		assert(t, found)
+	}
+}
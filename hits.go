@@ -0,0 +1,94 @@
+// hits.go: optional per-pattern hit counters accumulated while matching.
+
+package ahocorasick
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrSnapshotMismatch is returned when merging hit snapshots taken from
+// matchers with a different number of patterns
+var ErrSnapshotMismatch = errors.New("ahocorasick: hit snapshots have different pattern counts")
+
+// HitCounters counts how often every pattern of a matcher built with
+// WithHitCounters has been reported, so operators can see which entries of
+// a dictionary actually fire in production
+// a hit is a pattern reported by a Match-style call, at most once per text,
+// by MatchFirst, or an occurrence reported by FindAll
+// counters are updated atomically and are safe for concurrent use
+type HitCounters struct {
+	counts []atomic.Uint64
+}
+
+// WithHitCounters makes the matcher count the hits of every pattern, read
+// them through Matcher.Hits
+func WithHitCounters() Option {
+	return func(o *options) {
+		o.hitCounters = true
+	}
+}
+
+// Hits returns the hit counters of the matcher, nil unless it was built with
+// WithHitCounters
+func (m *Matcher) Hits() *HitCounters {
+	return m.hits
+}
+
+// Snapshot returns the current count of every pattern, indexed by pattern
+// index; counts keep accumulating while the snapshot is taken, so it is not
+// an atomic view across patterns
+func (h *HitCounters) Snapshot() []uint64 {
+	if h == nil {
+		return nil
+	}
+	counts := make([]uint64, len(h.counts))
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+	}
+	return counts
+}
+
+// HitSnapshot is the serializable form of the hit counters of a matcher,
+// snapshots from instances serving the same dictionary can be merged for
+// fleet-wide analytics
+type HitSnapshot struct {
+	Counts []uint64 `json:"counts"` // hits of every pattern, indexed by pattern index
+}
+
+// Export returns the current counts as a HitSnapshot
+func (h *HitCounters) Export() HitSnapshot {
+	return HitSnapshot{Counts: h.Snapshot()}
+}
+
+// Merge adds the counts of other to s, both must cover the same number of
+// patterns; an empty s takes the shape of other
+func (s *HitSnapshot) Merge(other HitSnapshot) error {
+	if len(s.Counts) == 0 {
+		s.Counts = make([]uint64, len(other.Counts))
+	}
+	if len(s.Counts) != len(other.Counts) {
+		return ErrSnapshotMismatch
+	}
+	for i, c := range other.Counts {
+		s.Counts[i] += c
+	}
+	return nil
+}
+
+// Reset sets every count back to zero
+func (h *HitCounters) Reset() {
+	if h == nil {
+		return
+	}
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+}
+
+// add records a hit of pattern, a nil receiver counts nothing
+func (h *HitCounters) add(pattern int32) {
+	if h != nil {
+		h.counts[pattern].Add(1)
+	}
+}
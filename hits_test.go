@@ -0,0 +1,75 @@
+// hits_test.go: tests for the per-pattern hit counters
+
+package ahocorasick
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestHitCounters(t *testing.T) {
+	m := NewStringMatcher([]string{"a", "ab", "bc", "unused"}, WithHitCounters())
+	m.MatchString("abcab") // a, ab, bc, each once per text
+	m.MatchThreadSafeString("bc")
+	m.FindAllString("aa") // two occurrences of a
+	m.MatchFirstString("xab")
+
+	counts := m.Hits().Snapshot()
+	assert(t, len(counts) == 4)
+	assert(t, counts[0] == 4)
+	assert(t, counts[1] == 1)
+	assert(t, counts[2] == 2)
+	assert(t, counts[3] == 0)
+
+	m.Hits().Reset()
+	counts = m.Hits().Snapshot()
+	assert(t, counts[0] == 0 && counts[2] == 0)
+
+	// counters are off by default
+	assert(t, precomputed.Hits() == nil)
+	assert(t, precomputed.Hits().Snapshot() == nil)
+	precomputed.Hits().Reset()
+}
+
+func TestHitCountersConcurrently(t *testing.T) {
+	m := NewStringMatcher(dictionary6, WithHitCounters())
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.MatchThreadSafe(bytes2)
+		}()
+	}
+	wg.Wait()
+	total := uint64(0)
+	for _, c := range m.Hits().Snapshot() {
+		total += c
+	}
+	assert(t, total == 8*105)
+}
+
+func TestHitSnapshotMerge(t *testing.T) {
+	a := NewStringMatcher([]string{"a", "b"}, WithHitCounters())
+	b := NewStringMatcher([]string{"a", "b"}, WithHitCounters())
+	a.MatchString("ab")
+	b.MatchString("b")
+
+	// snapshots survive a round trip through JSON
+	data, err := json.Marshal(b.Hits().Export())
+	assert(t, err == nil)
+	var remote HitSnapshot
+	assert(t, json.Unmarshal(data, &remote) == nil)
+
+	var total HitSnapshot
+	assert(t, total.Merge(a.Hits().Export()) == nil)
+	assert(t, total.Merge(remote) == nil)
+	assert(t, len(total.Counts) == 2)
+	assert(t, total.Counts[0] == 1)
+	assert(t, total.Counts[1] == 2)
+
+	other := NewStringMatcher([]string{"a"}, WithHitCounters())
+	assert(t, errors.Is(total.Merge(other.Hits().Export()), ErrSnapshotMismatch))
+}
@@ -0,0 +1,128 @@
+// ids.go: matchers reporting caller-defined stable pattern IDs.
+
+package ahocorasick
+
+// Entry is a pattern with a caller-defined ID
+type Entry struct {
+	ID      int64
+	Pattern string
+}
+
+// IDMatch is an occurrence reported with the ID of its pattern
+type IDMatch struct {
+	ID    int64
+	Start int // offset of the first byte
+	End   int // offset just after the last byte
+}
+
+// IDMatcher reports the caller-defined IDs of the patterns it was built
+// from instead of slice positions, which change whenever a dictionary is
+// reordered or pruned between versions
+// entries sharing an ID are synonyms, reported once under that ID
+// it is safe for concurrent use
+type IDMatcher struct {
+	m     *Matcher
+	ids   []int64       // ID of every pattern index of m
+	index map[int64]int // pattern index of every ID
+}
+
+// CompileIDs builds an IDMatcher from entries; the underlying matcher numbers
+// the distinct IDs in order of first appearance, which is the index options
+// referring to patterns, such as WithCategories, must use (see Index)
+func CompileIDs(entries []Entry, opts ...Option) (*IDMatcher, error) {
+	im := &IDMatcher{index: make(map[int64]int)}
+	var groups [][]string
+	for _, e := range entries {
+		i, ok := im.index[e.ID]
+		if !ok {
+			i = len(im.ids)
+			im.index[e.ID] = i
+			im.ids = append(im.ids, e.ID)
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], e.Pattern)
+	}
+	m, err := compileGroups(groups, opts)
+	if err != nil {
+		return nil, err
+	}
+	im.m = m
+	return im, nil
+}
+
+// Matcher returns the underlying matcher, which reports pattern indices
+func (im *IDMatcher) Matcher() *Matcher {
+	return im.m
+}
+
+// ID returns the ID of pattern index i of the underlying matcher
+func (im *IDMatcher) ID(i int) int64 {
+	return im.ids[i]
+}
+
+// Index returns the pattern index of id in the underlying matcher
+func (im *IDMatcher) Index(id int64) (int, bool) {
+	i, ok := im.index[id]
+	return i, ok
+}
+
+// Match returns the IDs of the patterns found in text, like Match of a Matcher
+func (im *IDMatcher) Match(text []byte) []int64 {
+	return im.MatchString(bytesToString(text))
+}
+
+// MatchString is like Match for a string input
+func (im *IDMatcher) MatchString(text string) []int64 {
+	hits := im.m.MatchThreadSafeString(text)
+	if len(hits) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(hits))
+	for k, i := range hits {
+		ids[k] = im.ids[i]
+	}
+	return ids
+}
+
+// Contains reports whether any pattern occurs in text
+func (im *IDMatcher) Contains(text []byte) bool {
+	return im.m.Contains(text)
+}
+
+// ContainsString is like Contains for a string input
+func (im *IDMatcher) ContainsString(text string) bool {
+	return im.m.ContainsString(text)
+}
+
+// MatchFirst returns the ID of the first pattern found in text
+func (im *IDMatcher) MatchFirst(text []byte) (id int64, ok bool) {
+	return im.MatchFirstString(bytesToString(text))
+}
+
+// MatchFirstString is like MatchFirst for a string input
+func (im *IDMatcher) MatchFirstString(text string) (id int64, ok bool) {
+	i, ok := im.m.MatchFirstString(text)
+	if !ok {
+		return 0, false
+	}
+	return im.ids[i], true
+}
+
+// FindAll returns every occurrence in text with the ID of its pattern, like
+// FindAll of a Matcher
+func (im *IDMatcher) FindAll(text []byte) []IDMatch {
+	return im.FindAllString(bytesToString(text))
+}
+
+// FindAllString is like FindAll for a string input
+func (im *IDMatcher) FindAllString(text string) []IDMatch {
+	matches := im.m.FindAllString(text)
+	if len(matches) == 0 {
+		return nil
+	}
+	found := make([]IDMatch, len(matches))
+	for k, o := range matches {
+		found[k] = IDMatch{ID: im.ids[o.Pattern], Start: o.Start, End: o.End}
+	}
+	return found
+}
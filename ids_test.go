@@ -0,0 +1,39 @@
+// ids_test.go: tests for matchers reporting caller-defined IDs
+
+package ahocorasick
+
+import "testing"
+
+func TestCompileIDs(t *testing.T) {
+	im, err := CompileIDs([]Entry{
+		{ID: 9001, Pattern: "Safari"},
+		{ID: 42, Pattern: "Mozilla"},
+		{ID: 1 << 40, Pattern: "Mac"},
+		{ID: 42, Pattern: "Gecko"},
+		{ID: -7, Pattern: "Sausage"},
+	})
+	assert(t, err == nil)
+	ids := im.MatchString(sbytes)
+	// synonyms are reported once
+	assert(t, len(ids) == 3)
+	assert(t, ids[0] == 42 && ids[1] == 1<<40 && ids[2] == 9001)
+	assert(t, im.Match([]byte("Sausage"))[0] == -7)
+	assert(t, im.MatchString("nothing") == nil)
+	assert(t, im.Contains(bytes) && !im.ContainsString("Chrome"))
+
+	id, ok := im.MatchFirst(bytes)
+	assert(t, ok && id == 42)
+	_, ok = im.MatchFirstString("")
+	assert(t, !ok)
+
+	matches := im.FindAll(bytes)
+	assert(t, len(matches) == 5)
+	assert(t, matches[3].ID == 42 && sbytes[matches[3].Start:matches[3].End] == "Gecko")
+	assert(t, matches[4].ID == 9001 && sbytes[matches[4].Start:matches[4].End] == "Safari")
+
+	i, ok := im.Index(42)
+	assert(t, ok && i == 1 && im.ID(i) == 42)
+	_, ok = im.Index(5)
+	assert(t, !ok)
+	assert(t, im.Matcher() != nil)
+}
@@ -0,0 +1,105 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// json.go: matching the string values of JSON documents.
+
+package ahocorasick
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSONMatch is an occurrence found in a string value of a JSON document
+type JSONMatch struct {
+	Path    string // JSON pointer (RFC 6901) of the value, "" for the root
+	Pattern int    // index of the pattern in the dictionary
+	Start   int    // byte offset of the first byte in the decoded value
+	End     int    // byte offset just after the last byte in the decoded value
+}
+
+// jsonFrame is an object or array being walked
+type jsonFrame struct {
+	object  bool
+	needKey bool   // the next string of an object is a key
+	key     string // key of the current member of an object
+	index   int    // index of the current element of an array
+}
+
+// MatchJSON returns the occurrences found in the string values of the JSON
+// document data, matched after unescaping like FindAll; keys, numbers and
+// the structure itself are never matched
+func (m *Matcher) MatchJSON(data []byte) ([]JSONMatch, error) {
+	return m.MatchJSONReader(strings.NewReader(bytesToString(data)))
+}
+
+// MatchJSONReader is like MatchJSON for a document read from r, which is
+// decoded as a stream and never held in memory as a whole
+func (m *Matcher) MatchJSONReader(r io.Reader) ([]JSONMatch, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var stack []jsonFrame
+	var found []JSONMatch
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return found, nil
+		}
+		if err != nil {
+			return found, err
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+		}
+		if s, ok := tok.(string); ok && top != nil && top.needKey {
+			top.key, top.needKey = s, false
+			continue
+		}
+
+		// tok starts a value
+		if top != nil {
+			if top.object {
+				top.needKey = true
+			} else {
+				top.index++
+			}
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			stack = append(stack, jsonFrame{object: t == '{', needKey: t == '{', index: -1})
+		case string:
+			matches := m.FindAllString(t)
+			if len(matches) == 0 {
+				break
+			}
+			path := jsonPointer(stack)
+			for _, o := range matches {
+				found = append(found, JSONMatch{Path: path, Pattern: o.Pattern, Start: o.Start, End: o.End})
+			}
+		}
+	}
+}
+
+// pointerEscaper escapes a key as a JSON pointer reference token
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// jsonPointer returns the JSON pointer of the current value of stack
+func jsonPointer(stack []jsonFrame) string {
+	var b strings.Builder
+	for _, f := range stack {
+		b.WriteByte('/')
+		if f.object {
+			pointerEscaper.WriteString(&b, f.key)
+		} else {
+			b.WriteString(strconv.Itoa(f.index))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,34 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// json_test.go: tests for matching the string values of JSON documents
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchJSON(t *testing.T) {
+	m := NewStringMatcher([]string{"secret", "token", "42"})
+	doc := `{
+		"token": "none",
+		"user": {"name": "a secret agent", "id": 42},
+		"notes": ["public", "top secret", {"a/b~c": "token!"}],
+		"secret": null
+	}`
+
+	found, err := m.MatchJSON([]byte(doc))
+	assert(t, err == nil)
+	assert(t, len(found) == 3)
+	assert(t, found[0] == JSONMatch{Path: "/user/name", Pattern: 0, Start: 2, End: 8})
+	assert(t, found[1] == JSONMatch{Path: "/notes/1", Pattern: 0, Start: 4, End: 10})
+	assert(t, found[2] == JSONMatch{Path: "/notes/2/a~1b~0c", Pattern: 1, Start: 0, End: 5})
+
+	found, err = m.MatchJSONReader(strings.NewReader(`"token"`))
+	assert(t, err == nil && len(found) == 1 && found[0].Path == "")
+
+	// matches before a syntax error are kept
+	found, err = m.MatchJSON([]byte(`["secret", }`))
+	assert(t, err != nil && len(found) == 1)
+}
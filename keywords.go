@@ -0,0 +1,76 @@
+// keywords.go: dictionaries where several surface forms stand for one
+// canonical term.
+
+package ahocorasick
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LoadKeywords builds a matcher from a flashtext-style keyword file: every
+// line is either "surface=>canonical", mapping a surface form to a canonical
+// name, or a lone keyword that is its own canonical name; blank lines are
+// skipped and spaces around the arrow are trimmed
+// matches report the ID of the canonical name rather than a line index,
+// the returned slice holds the names by ID, in order of first appearance
+func LoadKeywords(r io.Reader, opts ...Option) (*Matcher, []string, error) {
+	var names []string
+	var groups [][]string
+	ids := make(map[string]int)
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		surface, name := text, text
+		if s, n, ok := strings.Cut(text, "=>"); ok {
+			surface, name = strings.TrimSpace(s), strings.TrimSpace(n)
+			if surface == "" || name == "" {
+				return nil, nil, fmt.Errorf("ahocorasick: keyword file line %d: empty keyword", line)
+			}
+		}
+		id, ok := ids[name]
+		if !ok {
+			id = len(names)
+			ids[name] = id
+			names = append(names, name)
+			groups = append(groups, nil)
+		}
+		groups[id] = append(groups[id], surface)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	m, err := compileGroups(groups, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, names, nil
+}
+
+// compileGroups builds a matcher where every surface form of groups[i]
+// is reported as i
+func compileGroups(groups [][]string, opts []Option) (*Matcher, error) {
+	var words []string
+	var ids []int32
+	for id, group := range groups {
+		for _, word := range group {
+			words = append(words, word)
+			ids = append(ids, int32(id))
+		}
+	}
+	return compile(words, ids, len(groups), opts)
+}
+
+// CompileSynonyms builds a matcher from synonym groups: every word of
+// groups[i], such as {"NYC", "New York", "New York City"}, is reported as
+// pattern i by all matching APIs, so results are deduplicated per entity
+// options referring to patterns, like categories, use the group indices
+// a word listed in several groups is only reported for the last one
+func CompileSynonyms(groups [][]string, opts ...Option) (*Matcher, error) {
+	return compileGroups(groups, opts)
+}
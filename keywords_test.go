@@ -0,0 +1,103 @@
+// keywords_test.go: tests for keyword files with canonical names
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+const keywordFile = `java_2e=>java
+java programing => java
+product management=>product management
+  product management techniques => product management
+
+python
+`
+
+func TestLoadKeywords(t *testing.T) {
+	m, names, err := LoadKeywords(strings.NewReader(keywordFile))
+	assert(t, err == nil)
+	assert(t, len(names) == 3)
+	assert(t, names[0] == "java" && names[1] == "product management" && names[2] == "python")
+
+	text := "I know java_2e and java programing, python and product management techniques"
+	hits := m.MatchString(text)
+	assert(t, len(hits) == 3)
+	assert(t, hits[0] == 0 && hits[1] == 2 && hits[2] == 1)
+
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 5)
+	assert(t, matches[1].Pattern == 0 && text[matches[1].Start:matches[1].End] == "java programing")
+
+	first, ok := m.MatchFirstString("learning python")
+	assert(t, ok && first == 2)
+	assert(t, m.MatchSetString(text).Len() == 3)
+}
+
+func TestLoadKeywordsErrors(t *testing.T) {
+	_, _, err := LoadKeywords(strings.NewReader("ok\n=>java\n"))
+	assert(t, err != nil && strings.Contains(err.Error(), "line 2"))
+	_, _, err = LoadKeywords(strings.NewReader("java=> "))
+	assert(t, err != nil)
+}
+
+func TestKeywordsSaveLoad(t *testing.T) {
+	m, _, err := LoadKeywords(strings.NewReader(keywordFile), WithHitCounters())
+	assert(t, err == nil)
+	m.MatchString("java_2e java programing")
+	assert(t, m.Hits().Snapshot()[0] == 1)
+
+	loaded := roundTrip(t, m)
+	hits := loaded.MatchString("python, java_2e")
+	assert(t, len(hits) == 2 && hits[0] == 2 && hits[1] == 0)
+}
+
+func TestCompileSynonyms(t *testing.T) {
+	groups := [][]string{
+		{"NYC", "New York", "New York City"},
+		{"LA", "Los Angeles"},
+		{},
+		{"SF", "San Francisco"},
+	}
+	m, err := CompileSynonyms(groups, WithCategories(map[string][]int{"west": {1, 3}}))
+	assert(t, err == nil)
+	text := "From New York City (NYC) to LA, then San Francisco"
+
+	for _, hits := range [][]int{
+		m.MatchString(text),
+		m.MatchThreadSafeString(text),
+		m.MatchScratchString(m.NewScratch(), text),
+		m.AppendMatchString(nil, text),
+	} {
+		assert(t, len(hits) == 3)
+		assert(t, hits[0] == 0 && hits[1] == 1 && hits[2] == 3)
+	}
+	assert(t, m.ContainsAllString(text, []int{0, 1, 3}))
+	assert(t, !m.ContainsAllString(text, []int{2}))
+	first, ok := m.MatchFirstString(text)
+	assert(t, ok && first == 0)
+	assert(t, m.MatchSetString(text).Len() == 3)
+
+	for _, o := range m.FindAllString(text) {
+		assert(t, o.Pattern == 0 || o.Pattern == 1 || o.Pattern == 3)
+	}
+	longest := m.FindLongestString(text)
+	assert(t, len(longest) == 5)
+	assert(t, text[longest[1].Start:longest[1].End] == "New York City")
+	assert(t, len(m.MatchParallelString(text, 2)) == len(m.FindAllString(text)))
+
+	hits := m.WithoutCategories("west").MatchString(text)
+	assert(t, len(hits) == 1 && hits[0] == 0)
+	assert(t, m.Subset([]int{3}).ContainsString("SF"))
+	s := m.Suggest("Los Angelos", 1)
+	assert(t, len(s) == 1 && s[0].Pattern == 1)
+	tokens := m.SegmentString("NYC LA")
+	assert(t, len(tokens) == 3 && tokens[0].Pattern == 0 && tokens[2].Pattern == 1)
+
+	m.DisablePattern(0)
+	assert(t, !m.ContainsString("NYC and New York"))
+
+	_, err = CompileSynonyms(groups, WithCategories(map[string][]int{"x": {4}}))
+	assert(t, err != nil)
+}
@@ -0,0 +1,151 @@
+// layered.go: a large immutable dictionary combined with a small overlay
+// that is rebuilt as it changes.
+
+package ahocorasick
+
+import "time"
+
+// Layered combines a large base matcher, built once, with a small overlay
+// dictionary edited at runtime like Dynamic: frequent changes only rebuild
+// the overlay, never the base, and every text is matched against both
+// tiers in a single pass
+// base words can be switched off with DisablePattern on the base matcher
+// until the next full build
+// all methods are safe for concurrent use
+type Layered struct {
+	base      *Matcher
+	baseWords []string
+	overlay   *Dynamic
+}
+
+// NewLayered creates a layered matcher over base, built from baseWords, with
+// an empty overlay; opts are used for every overlay build and should be the
+// options of the base so both tiers match alike
+func NewLayered(base *Matcher, baseWords []string, opts ...Option) *Layered {
+	return &Layered{base: base, baseWords: baseWords, overlay: NewDynamic(opts...)}
+}
+
+// Add registers overlay words without expiry, they match after the next Rebuild
+func (l *Layered) Add(words ...string) {
+	l.overlay.Add(words...)
+}
+
+// AddWithTTL registers overlay words that stop matching once ttl has elapsed
+func (l *Layered) AddWithTTL(ttl time.Duration, words ...string) {
+	l.overlay.AddWithTTL(ttl, words...)
+}
+
+// AddUntil registers overlay words that stop matching at expiry
+func (l *Layered) AddUntil(expiry time.Time, words ...string) {
+	l.overlay.AddUntil(expiry, words...)
+}
+
+// Remove unregisters overlay words, they stop matching after the next Rebuild
+func (l *Layered) Remove(words ...string) {
+	l.overlay.Remove(words...)
+}
+
+// Rebuild compiles the pending overlay changes, the base is left untouched
+func (l *Layered) Rebuild() error {
+	return l.overlay.Rebuild()
+}
+
+// Base returns the base matcher
+func (l *Layered) Base() *Matcher {
+	return l.base
+}
+
+// OverlayWords returns the words of the overlay currently in use
+func (l *Layered) OverlayWords() []string {
+	return l.overlay.Words()
+}
+
+// Match returns the words of both tiers found in text, each once, in the
+// order their first occurrence ends, base words first when both tiers end
+// an occurrence at the same offset; expired overlay words are filtered out
+func (l *Layered) Match(text []byte) []string {
+	return l.MatchString(bytesToString(text))
+}
+
+// MatchString is like Match for a string input
+func (l *Layered) MatchString(text string) []string {
+	snap := l.overlay.current.Load()
+	now := l.overlay.now()
+	seen := make(map[string]bool)
+	var words []string
+	add := func(word string) {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	addOverlay := func(i int) {
+		if expiry := snap.expires[i]; expiry.IsZero() || now.Before(expiry) {
+			add(snap.words[i])
+		}
+	}
+
+	base, overlay := l.base, snap.matcher
+	if !base.walkable() || !overlay.walkable() {
+		// the tiers do not see the same runes, so they are scanned in turn
+		for _, i := range base.MatchThreadSafeString(text) {
+			add(l.baseWords[i])
+		}
+		for _, i := range overlay.MatchThreadSafeString(text) {
+			addOverlay(i)
+		}
+		return words
+	}
+
+	var baseSeen, overlaySeen PatternSet
+	baseOff, overlayOff := base.disabledPatterns(), overlay.disabledPatterns()
+	b, o := uint32(root), uint32(root)
+	for _, r := range text {
+		b = base.step(b, r)
+		base.outputsAt(b, baseOff, func(out int32) {
+			if !baseSeen.Has(int(out)) {
+				baseSeen.Add(int(out))
+				base.hits.add(out)
+				add(l.baseWords[out])
+			}
+		})
+		o = overlay.step(o, r)
+		overlay.outputsAt(o, overlayOff, func(out int32) {
+			if !overlaySeen.Has(int(out)) {
+				overlaySeen.Add(int(out))
+				overlay.hits.add(out)
+				addOverlay(int(out))
+			}
+		})
+	}
+	return words
+}
+
+// Contains reports whether text contains any word of either tier
+func (l *Layered) Contains(text []byte) bool {
+	return l.ContainsString(bytesToString(text))
+}
+
+// ContainsString is like Contains for a string input
+func (l *Layered) ContainsString(text string) bool {
+	return l.base.ContainsString(text) || l.overlay.ContainsString(text)
+}
+
+// walkable reports whether the matcher can be stepped rune by rune over the
+// raw input, reporting every output it reaches
+func (m *Matcher) walkable() bool {
+	return !m.generic() && m.overlap == ReportAll
+}
+
+// outputsAt calls fn with every enabled pattern ending at state n, the
+// output of n then its suffix chain
+func (m *Matcher) outputsAt(n uint32, off patternMask, fn func(out int32)) {
+	if out := m.outputs[n]; out >= 0 && !off.has(out) {
+		fn(out)
+	}
+	for f := m.suffixLink(n); f != root; f = m.suffixLink(f) {
+		if out := m.outputs[f]; !off.has(out) {
+			fn(out)
+		}
+	}
+}
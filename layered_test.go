@@ -0,0 +1,58 @@
+// layered_test.go: tests for the two-tier matcher
+
+package ahocorasick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLayered(t *testing.T) {
+	l := NewLayered(precomputed, dictionary)
+	words := l.MatchString(sbytes)
+	assert(t, len(words) == 4)
+	assert(t, words[0] == "Mozilla" && words[3] == "Safari")
+
+	l.Add("Chrome", "Mac", "KHTML")
+	assert(t, len(l.MatchString(sbytes)) == 4)
+	assert(t, l.Rebuild() == nil)
+	assert(t, len(l.OverlayWords()) == 3)
+	words = l.Match(bytes)
+	assert(t, len(words) == 6)
+	// "Mac" is in both tiers and reported once
+	assert(t, words[1] == "Mac" && words[2] == "Macintosh")
+	assert(t, words[3] == "KHTML" && words[4] == "Chrome")
+	assert(t, l.ContainsString("Chrome"))
+	assert(t, !l.ContainsString("Firefox"))
+
+	l.Remove("Chrome")
+	assert(t, l.Rebuild() == nil)
+	assert(t, !l.ContainsString("Chrome"))
+	assert(t, l.Base() == precomputed)
+}
+
+func TestLayeredExpiry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewLayered(precomputed, dictionary)
+	l.overlay.now = func() time.Time { return now }
+	l.AddWithTTL(time.Minute, "Chrome")
+	l.AddUntil(time.Time{}, "Gecko")
+	assert(t, l.Rebuild() == nil)
+	assert(t, len(l.MatchString(sbytes)) == 6)
+	now = now.Add(2 * time.Minute)
+	words := l.MatchString(sbytes)
+	assert(t, len(words) == 5)
+	for _, w := range words {
+		assert(t, w != "Chrome")
+	}
+}
+
+func TestLayeredTransformed(t *testing.T) {
+	// tiers that rewrite their input are scanned in turn
+	base := NewStringMatcher([]string{"cafe"}, WithDiacriticFolding())
+	l := NewLayered(base, []string{"cafe"}, WithDiacriticFolding())
+	l.Add("thé")
+	assert(t, l.Rebuild() == nil)
+	words := l.MatchString("café or the")
+	assert(t, len(words) == 2 && words[0] == "cafe" && words[1] == "thé")
+}
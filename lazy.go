@@ -0,0 +1,82 @@
+// lazy.go: fail and suffix links computed on first use.
+
+package ahocorasick
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// unresolved marks a link that has not been computed yet
+const unresolved = math.MaxUint32
+
+// lazyLinks holds the fail and suffix links of a matcher built with
+// WithLazyLinks, they are computed from the parent state and the label of
+// the incoming transition the first time they are needed
+// links are deterministic, so concurrent goroutines resolving the same link
+// store the same value and atomics are enough to keep them consistent
+type lazyLinks struct {
+	parent []uint32
+	label  []rune
+	fail   []atomic.Uint32
+	suffix []atomic.Uint32
+}
+
+// newLazyLinks prepares unresolved links for every state of m
+func newLazyLinks(m *Matcher) *lazyLinks {
+	l := &lazyLinks{
+		parent: make([]uint32, len(m.states)),
+		label:  make([]rune, len(m.states)),
+		fail:   make([]atomic.Uint32, len(m.states)),
+		suffix: make([]atomic.Uint32, len(m.states)),
+	}
+	for s := range m.states {
+		st := &m.states[s]
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			l.parent[e.next] = uint32(s)
+			l.label[e.next] = e.label
+		}
+		l.fail[s].Store(unresolved)
+		l.suffix[s].Store(unresolved)
+	}
+	l.fail[root].Store(root)
+	l.suffix[root].Store(root)
+	return l
+}
+
+// failLink returns the state to continue from when no transition of s matches
+func (m *Matcher) failLink(s uint32) uint32 {
+	if m.lazy == nil {
+		return m.states[s].fail
+	}
+	l := m.lazy
+	if f := l.fail[s].Load(); f != unresolved {
+		return f
+	}
+	// the fail link of a state is reached by consuming its label from the
+	// fail link of its parent, first level states fail to the root
+	f := uint32(root)
+	if p := l.parent[s]; p != root {
+		f = m.resolve(m.failLink(p), l.label[s])
+	}
+	l.fail[s].Store(f)
+	return f
+}
+
+// suffixLink returns the nearest output state on the fail chain of s, the
+// root if there is none
+func (m *Matcher) suffixLink(s uint32) uint32 {
+	if m.lazy == nil {
+		return m.states[s].suffix
+	}
+	l := m.lazy
+	if f := l.suffix[s].Load(); f != unresolved {
+		return f
+	}
+	f := m.failLink(s)
+	if f == root || m.outputs[f] < 0 {
+		f = m.suffixLink(f)
+	}
+	l.suffix[s].Store(f)
+	return f
+}
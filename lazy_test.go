@@ -0,0 +1,47 @@
+// lazy_test.go: tests for lazily computed links
+
+package ahocorasick
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLazyLinks(t *testing.T) {
+	m := NewStringMatcher([]string{"a", "ab", "bc", "bca", "c", "caa"}, WithLazyLinks())
+	hits := m.Match([]byte("abccab"))
+	assert(t, len(hits) == 4)
+	assert(t, hits[0] == 0)
+	assert(t, hits[1] == 1)
+	assert(t, hits[2] == 2)
+	assert(t, hits[3] == 4)
+
+	m = NewStringMatcher([]string{"Superman", "uperman", "perman", "erman"}, WithLazyLinks())
+	hits = m.MatchThreadSafe([]byte("The Man Of Steel: Superman"))
+	assert(t, len(hits) == 4)
+	assert(t, m.Contains([]byte("hermanos")))
+	i, ok := m.MatchFirstString("permanent")
+	assert(t, ok && i == 2)
+}
+
+func TestLazyLinksConcurrently(t *testing.T) {
+	m := NewStringMatcher(dictionary6, WithLazyLinks(), WithTransitionCache(4))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert(t, len(m.MatchThreadSafe(bytes2)) == 105)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLazyLinksSave(t *testing.T) {
+	// saving resolves every link, producing the same artifact as an eager build
+	var eager, lazy strings.Builder
+	assert(t, precomputed6.Save(&eager) == nil)
+	assert(t, NewStringMatcher(dictionary6, WithLazyLinks()).Save(&lazy) == nil)
+	assert(t, eager.String() == lazy.String())
+}
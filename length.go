@@ -0,0 +1,26 @@
+// length.go: pattern length bounds and the quick reject of short inputs.
+
+package ahocorasick
+
+// MinPatternLen returns the length in runes of the shortest pattern, as
+// matched after normalization, 0 for an empty dictionary
+func (m *Matcher) MinPatternLen() int {
+	m.depthOnce.Do(m.computeDepths)
+	return m.minDepth
+}
+
+// MaxPatternLen returns the length in runes of the longest pattern, as
+// matched after normalization; an occurrence spans at most utf8.UTFMax
+// bytes per rune, which bounds the overlap chunked scans need, except with
+// normalization where an input rune can produce several runes or none
+func (m *Matcher) MaxPatternLen() int {
+	m.depthOnce.Do(m.computeDepths)
+	return m.maxDepth
+}
+
+// tooShort reports whether text has fewer bytes, hence fewer runes, than the
+// shortest pattern has runes, so nothing can match; normalization can
+// expand a rune into several, so no text is too short then
+func (m *Matcher) tooShort(text string) bool {
+	return m.norm == nil && len(text) < m.MinPatternLen()
+}
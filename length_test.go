@@ -0,0 +1,31 @@
+// length_test.go: tests for pattern length bounds
+
+package ahocorasick
+
+import "testing"
+
+func TestPatternLen(t *testing.T) {
+	assert(t, precomputed.MinPatternLen() == 3 && precomputed.MaxPatternLen() == 9)
+	m := NewStringMatcher([]string{"中文字", "abcd"})
+	assert(t, m.MinPatternLen() == 3 && m.MaxPatternLen() == 4)
+	empty := NewStringMatcher(nil)
+	assert(t, empty.MinPatternLen() == 0 && empty.MaxPatternLen() == 0)
+
+	// short inputs are rejected before scanning
+	assert(t, !precomputed.ContainsString("Ma"))
+	assert(t, precomputed.ContainsString("Mac"))
+	assert(t, len(precomputed.MatchString("Ma")) == 0)
+	assert(t, len(precomputed.FindAllString("Ma")) == 0)
+	assert(t, m.ContainsString("中文字") && !m.ContainsString("ab"))
+
+	// unless normalization can expand the input
+	expand := NormalizerFunc(func(dst []rune, _, r rune) []rune {
+		if r == '&' {
+			return append(dst, 'a', 'n', 'd')
+		}
+		return append(dst, r)
+	})
+	m = NewStringMatcher([]string{"and"}, WithNormalizer(expand))
+	assert(t, m.MinPatternLen() == 3)
+	assert(t, m.ContainsString("&"))
+}
@@ -0,0 +1,214 @@
+// limits.go: safety limits on the dictionaries a Matcher accepts.
+
+package ahocorasick
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// ErrLimitExceeded is matched by every LimitError with errors.Is
+var ErrLimitExceeded = errors.New("ahocorasick: limit exceeded")
+
+// LimitError reports a dictionary violating one of the limits set with
+// WithMaxPatterns, WithMaxPatternLength, WithMaxTotalRunes or WithMaxMemory
+type LimitError struct {
+	Limit string // name of the exceeded limit
+	Index int    // index of the pattern where the limit was hit, -1 if not pattern specific
+	Max   int    // configured maximum
+}
+
+func (e *LimitError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("ahocorasick: %s limit of %d exceeded", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("ahocorasick: %s limit of %d exceeded at pattern %d", e.Limit, e.Max, e.Index)
+}
+
+// Is makes errors.Is(err, ErrLimitExceeded) report true
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// WithMaxPatterns limits the number of patterns of the dictionary
+func WithMaxPatterns(n int) Option {
+	return func(o *options) {
+		o.maxPatterns = n
+	}
+}
+
+// WithMaxPatternLength limits the length in runes of every pattern
+func WithMaxPatternLength(runes int) Option {
+	return func(o *options) {
+		o.maxPatternLength = runes
+	}
+}
+
+// WithMaxTotalRunes limits the sum of the lengths in runes of all patterns
+func WithMaxTotalRunes(n int) Option {
+	return func(o *options) {
+		o.maxTotalRunes = n
+	}
+}
+
+// WithMaxMemory limits the size in bytes of the automaton, as projected by
+// Analyze in its MemoryBytes, so a service compiling user dictionaries
+// fails with an error naming the pattern that crossed the limit instead of
+// being killed for running out of memory; the limit is checked before the
+// trie is allocated, on the patterns as inserted into it, and the build
+// temporarily needs about twice the projection
+// the table of BackendDense, which grows with the states times the runes
+// of the patterns, counts toward the limit, and BackendAuto only picks it
+// when it fits
+func WithMaxMemory(bytes int) Option {
+	return func(o *options) {
+		o.maxMemory = bytes
+	}
+}
+
+// checkMemory verifies the patterns as inserted into the trie against the
+// memory limit; when they exceed it, the pattern crossing it is found by a
+// binary search over the prefixes of words, which only failing builds pay
+func checkMemory(words []string, o *options) error {
+	if o.maxMemory <= 0 {
+		return nil
+	}
+	over := func(n int) bool {
+		nodes := countNodes(sortedCopy(words[:n]))
+		var alpha map[rune]bool
+		if o.backend == BackendDense {
+			alpha = denseAlphabet(o)
+			for _, word := range words[:n] {
+				addRunes(alpha, word)
+			}
+		}
+		return projectMemory(o, nodes, len(alpha)) > o.maxMemory
+	}
+	if !over(len(words)) {
+		return nil
+	}
+	i := sort.Search(len(words), func(i int) bool { return over(i + 1) })
+	return &LimitError{Limit: "memory", Index: i, Max: o.maxMemory}
+}
+
+// projectMemory returns the projected size of an automaton of nodes states,
+// with the table of BackendDense over an alphabet of runes runes when o
+// requests it
+func projectMemory(o *options, nodes, runes int) int {
+	size := estimateMemory(nodes, nodes-1)
+	if o.backend == BackendDense {
+		size += nodes * runes * int(unsafe.Sizeof(uint32(0)))
+	}
+	return size
+}
+
+// denseAlphabet returns the runes the table of BackendDense has columns for
+// before any pattern is added: those of the equivalence classes, which label
+// alias transitions
+func denseAlphabet(o *options) map[rune]bool {
+	alpha := make(map[rune]bool)
+	for _, class := range o.equivalence {
+		addRunes(alpha, class)
+	}
+	return alpha
+}
+
+// addRunes adds the runes of s to alpha
+func addRunes(alpha map[rune]bool, s string) {
+	for _, r := range s {
+		alpha[r] = true
+	}
+}
+
+// checkLimits verifies the dictionary against the configured limits before
+// anything is allocated for it
+func checkLimits(dictionary []string, o *options) error {
+	if o.maxPatterns > 0 && len(dictionary) > o.maxPatterns {
+		return &LimitError{Limit: "pattern count", Index: -1, Max: o.maxPatterns}
+	}
+	if o.maxPatternLength <= 0 && o.maxTotalRunes <= 0 {
+		return nil
+	}
+	c := limitChecker{o: o}
+	for i, word := range dictionary {
+		if err := c.check(i, word); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limitChecker verifies the patterns of a dictionary one at a time, for
+// dictionaries that are streamed rather than held in a slice
+type limitChecker struct {
+	o     *options
+	total int           // runes of the patterns checked so far
+	alpha map[rune]bool // runes of the patterns inserted so far, for BackendDense
+}
+
+// checkNodes verifies the memory limit once pattern i, inserted as word,
+// made the trie grow to nodes nodes
+func (c *limitChecker) checkNodes(i int, word string, nodes int) error {
+	if c.o.maxMemory <= 0 {
+		return nil
+	}
+	if c.o.backend == BackendDense {
+		if c.alpha == nil {
+			c.alpha = denseAlphabet(c.o)
+		}
+		addRunes(c.alpha, word)
+	}
+	if projectMemory(c.o, nodes, len(c.alpha)) > c.o.maxMemory {
+		return &LimitError{Limit: "memory", Index: i, Max: c.o.maxMemory}
+	}
+	return nil
+}
+
+// check verifies pattern i, word
+// counting stops as soon as a limit is hit, so a huge pattern is rejected
+// without being scanned completely
+func (c *limitChecker) check(i int, word string) error {
+	o := c.o
+	if o.maxPatterns > 0 && i >= o.maxPatterns {
+		return &LimitError{Limit: "pattern count", Index: -1, Max: o.maxPatterns}
+	}
+	if o.maxPatternLength <= 0 && o.maxTotalRunes <= 0 {
+		return nil
+	}
+	bound := -1 // remaining runes allowed for this word, -1 if unlimited
+	if o.maxPatternLength > 0 {
+		bound = o.maxPatternLength
+	}
+	if o.maxTotalRunes > 0 && (bound < 0 || o.maxTotalRunes-c.total < bound) {
+		bound = o.maxTotalRunes - c.total
+	}
+	n := countRunes(word, bound)
+	if o.maxPatternLength > 0 && n > o.maxPatternLength {
+		return &LimitError{Limit: "pattern length", Index: i, Max: o.maxPatternLength}
+	}
+	c.total += n
+	if o.maxTotalRunes > 0 && c.total > o.maxTotalRunes {
+		return &LimitError{Limit: "total runes", Index: i, Max: o.maxTotalRunes}
+	}
+	return nil
+}
+
+// countRunes counts the runes of s, stopping once more than bound runes have
+// been seen unless bound is negative
+func countRunes(s string, bound int) int {
+	if bound < 0 || len(s) <= bound {
+		// a string never has more runes than bytes
+		return utf8.RuneCountInString(s)
+	}
+	n := 0
+	for range s {
+		n++
+		if n > bound {
+			break
+		}
+	}
+	return n
+}
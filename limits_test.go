@@ -0,0 +1,77 @@
+// limits_test.go: tests for the dictionary safety limits
+
+package ahocorasick
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	m, err := Compile(dictionary, WithMaxPatterns(5), WithMaxPatternLength(9), WithMaxTotalRunes(32))
+	assert(t, err == nil)
+	assert(t, len(m.MatchString(sbytes)) == 4)
+
+	_, err = Compile(dictionary, WithMaxPatterns(4))
+	assert(t, errors.Is(err, ErrLimitExceeded))
+
+	var le *LimitError
+	_, err = Compile([]string{"short", "中文中文中文", strings.Repeat("x", 1<<20)}, WithMaxPatternLength(5))
+	assert(t, errors.As(err, &le))
+	assert(t, le.Limit == "pattern length")
+	assert(t, le.Index == 1)
+
+	_, err = Compile(dictionary, WithMaxTotalRunes(20))
+	assert(t, errors.As(err, &le))
+	assert(t, le.Limit == "total runes")
+	assert(t, le.Index == 3)
+	assert(t, le.Error() == "ahocorasick: total runes limit of 20 exceeded at pattern 3")
+}
+
+func TestLimitsPanic(t *testing.T) {
+	defer func() {
+		assert(t, recover() != nil)
+	}()
+	NewStringMatcher(dictionary, WithMaxPatterns(1))
+}
+
+func TestMaxMemory(t *testing.T) {
+	limit := Analyze(dictionary[:3]).MemoryBytes
+	_, err := Compile(dictionary[:3], WithMaxMemory(limit))
+	assert(t, err == nil)
+
+	var le *LimitError
+	_, err = Compile(dictionary, WithMaxMemory(limit))
+	assert(t, errors.As(err, &le) && le.Limit == "memory" && le.Index == 3)
+	assert(t, le.Error() == fmt.Sprintf("ahocorasick: memory limit of %d exceeded at pattern 3", limit))
+	_, err = CompileSeq(seqOf(dictionary), WithMaxMemory(limit))
+	assert(t, errors.As(err, &le) && le.Index == 3)
+
+	// a failed rebuild leaves the matcher as it was
+	m := NewStringMatcher([]string{"he", "she"})
+	assert(t, errors.Is(m.Rebuild(dictionary, WithMaxMemory(limit)), ErrLimitExceeded))
+	assert(t, m.ContainsString("ushers"))
+
+	// the dense table counts toward the limit
+	var wide []string
+	for i := range 2000 {
+		wide = append(wide, string(rune(0x4e00+i))+string(rune(0x4e00+i%50)))
+	}
+	limit = 4 * Analyze(wide).MemoryBytes
+	_, err = Compile(wide, WithMaxMemory(limit))
+	assert(t, err == nil)
+	_, err = Compile(wide, WithMaxMemory(limit), WithBackend(BackendDense))
+	assert(t, errors.As(err, &le) && le.Limit == "memory" && le.Index > 0 && le.Index < len(wide))
+	_, err = CompileSeq(seqOf(wide), WithMaxMemory(limit), WithBackend(BackendDense))
+	assert(t, errors.As(err, &le) && le.Limit == "memory")
+	assert(t, errors.Is(m.Rebuild(wide, WithMaxMemory(limit), WithBackend(BackendDense)), ErrLimitExceeded))
+	assert(t, m.ContainsString("ushers") && m.Backend() == BackendSorted)
+
+	// and the automatic choice only picks a table that fits
+	limit = Analyze(dictionary).MemoryBytes
+	assert(t, NewStringMatcher(dictionary, WithBackend(BackendAuto)).Backend() == BackendDense)
+	m, err = Compile(dictionary, WithMaxMemory(limit), WithBackend(BackendAuto))
+	assert(t, err == nil && m.Backend() != BackendDense)
+}
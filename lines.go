@@ -0,0 +1,47 @@
+// lines.go: matching grouped by line, for scanning logs.
+
+package ahocorasick
+
+import "strings"
+
+// LineMatches holds the occurrences found in one line of a text
+type LineMatches struct {
+	Line    int     // line number, starting at 1
+	Start   int     // byte offset of the line in the text
+	Text    string  // the line, without its terminator
+	Matches []Match // occurrences in the line, with offsets in the text
+}
+
+// MatchLines returns the occurrences found in text grouped by line, only
+// for lines containing some; lines end with "\n" or "\r\n", which are not
+// part of them, and every line is matched on its own like FindAll, so a
+// pattern never matches across a line break
+func (m *Matcher) MatchLines(text []byte) []LineMatches {
+	return m.MatchLinesString(bytesToString(text))
+}
+
+// MatchLinesString is like MatchLines for a string input
+func (m *Matcher) MatchLinesString(text string) []LineMatches {
+	var lines []LineMatches
+	for n, start := 1, 0; start < len(text); n++ {
+		end := len(text)
+		next := end
+		if i := strings.IndexByte(text[start:], '\n'); i >= 0 {
+			end = start + i
+			next = end + 1
+			if end > start && text[end-1] == '\r' {
+				end--
+			}
+		}
+		line := text[start:end]
+		if matches := m.FindAllString(line); len(matches) > 0 {
+			for k := range matches {
+				matches[k].Start += start
+				matches[k].End += start
+			}
+			lines = append(lines, LineMatches{Line: n, Start: start, Text: line, Matches: matches})
+		}
+		start = next
+	}
+	return lines
+}
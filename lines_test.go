@@ -0,0 +1,32 @@
+// lines_test.go: tests for matching grouped by line
+
+package ahocorasick
+
+import "testing"
+
+func TestMatchLines(t *testing.T) {
+	m := NewStringMatcher([]string{"error", "warn", "a\nb"})
+	text := "ok\r\nerror: disk\nnothing\r\n\r\nwarn and error\na\nb"
+
+	lines := m.MatchLinesString(text)
+	assert(t, len(lines) == 2)
+
+	first := lines[0]
+	assert(t, first.Line == 2 && first.Text == "error: disk")
+	assert(t, len(first.Matches) == 1 && first.Matches[0].Pattern == 0)
+	assert(t, text[first.Matches[0].Start:first.Matches[0].End] == "error")
+
+	second := lines[1]
+	assert(t, second.Line == 5 && second.Text == "warn and error")
+	assert(t, text[second.Start:second.Start+len(second.Text)] == second.Text)
+	assert(t, len(second.Matches) == 2)
+	assert(t, second.Matches[0].Pattern == 1 && second.Matches[1].Pattern == 0)
+	assert(t, text[second.Matches[1].Start:second.Matches[1].End] == "error")
+
+	// a carriage return alone does not end a line
+	lines = m.MatchLines([]byte("warn\rerror\n"))
+	assert(t, len(lines) == 1 && lines[0].Text == "warn\rerror" && len(lines[0].Matches) == 2)
+
+	assert(t, m.MatchLinesString("") == nil)
+	assert(t, m.MatchLinesString("\n\n") == nil)
+}
@@ -0,0 +1,102 @@
+// lru.go: a least recently used cache without container/list.
+
+package ahocorasick
+
+// lru keeps at most limit entries, evicting the least recently used one
+// entries live in one slice linked by indices rather than in container/list
+// elements, so they need no type assertions and the slots of evicted
+// entries are reused; it is not safe for concurrent use
+type lru[K comparable, V any] struct {
+	limit   int
+	index   map[K]int32
+	entries []lruEntry[K, V]
+	head    int32 // most recently used entry, -1 when empty
+	tail    int32 // least recently used entry, -1 when empty
+}
+
+type lruEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next int32 // neighbours towards the head and the tail, -1 for none
+}
+
+// newLRU returns an empty cache of at most limit entries, at least 1
+func newLRU[K comparable, V any](limit int) *lru[K, V] {
+	return &lru[K, V]{limit: max(limit, 1), index: make(map[K]int32), head: -1, tail: -1}
+}
+
+// get returns the value of k and makes it the most recently used entry
+func (c *lru[K, V]) get(k K) (V, bool) {
+	i, ok := c.index[k]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.unlink(i)
+	c.pushFront(i)
+	return c.entries[i].value, true
+}
+
+// put sets the value of k and makes it the most recently used entry,
+// evicting the least recently used one when the cache is full
+func (c *lru[K, V]) put(k K, v V) {
+	if i, ok := c.index[k]; ok {
+		c.entries[i].value = v
+		c.unlink(i)
+		c.pushFront(i)
+		return
+	}
+	var i int32
+	if len(c.entries) < c.limit {
+		i = int32(len(c.entries))
+		c.entries = append(c.entries, lruEntry[K, V]{})
+	} else {
+		i = c.tail
+		c.unlink(i)
+		delete(c.index, c.entries[i].key)
+	}
+	c.entries[i] = lruEntry[K, V]{key: k, value: v}
+	c.index[k] = i
+	c.pushFront(i)
+}
+
+// len returns the number of entries
+func (c *lru[K, V]) len() int {
+	return len(c.index)
+}
+
+// clear drops every entry
+func (c *lru[K, V]) clear() {
+	clear(c.index)
+	c.entries = c.entries[:0]
+	c.head, c.tail = -1, -1
+}
+
+// unlink takes entry i out of the recency list
+func (c *lru[K, V]) unlink(i int32) {
+	e := &c.entries[i]
+	if e.prev >= 0 {
+		c.entries[e.prev].next = e.next
+	} else {
+		c.head = e.next
+	}
+	if e.next >= 0 {
+		c.entries[e.next].prev = e.prev
+	} else {
+		c.tail = e.prev
+	}
+	e.prev, e.next = -1, -1
+}
+
+// pushFront makes entry i, unlinked, the most recently used
+func (c *lru[K, V]) pushFront(i int32) {
+	e := &c.entries[i]
+	e.prev, e.next = -1, c.head
+	if c.head >= 0 {
+		c.entries[c.head].prev = i
+	}
+	c.head = i
+	if c.tail < 0 {
+		c.tail = i
+	}
+}
@@ -0,0 +1,42 @@
+// lru_test.go: tests for the least recently used cache
+
+package ahocorasick
+
+import "testing"
+
+func TestLRU(t *testing.T) {
+	c := newLRU[string, int](2)
+	c.put("a", 1)
+	c.put("b", 2)
+	v, ok := c.get("a")
+	assert(t, ok && v == 1)
+
+	// b is the least recently used, its slot is reused for c
+	c.put("c", 3)
+	_, ok = c.get("b")
+	assert(t, !ok && c.len() == 2 && len(c.entries) == 2)
+	v, ok = c.get("c")
+	assert(t, ok && v == 3)
+
+	// replacing a value makes it the most recently used
+	c.put("a", 10)
+	c.put("d", 4)
+	v, ok = c.get("a")
+	assert(t, ok && v == 10)
+	_, ok = c.get("c")
+	assert(t, !ok)
+
+	c.clear()
+	assert(t, c.len() == 0 && c.head == -1 && c.tail == -1)
+	_, ok = c.get("a")
+	assert(t, !ok)
+	c.put("e", 5)
+	v, ok = c.get("e")
+	assert(t, ok && v == 5 && c.len() == 1)
+
+	one := newLRU[int, int](0)
+	one.put(1, 1)
+	one.put(2, 2)
+	_, ok = one.get(1)
+	assert(t, !ok && one.len() == 1)
+}
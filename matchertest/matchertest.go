@@ -0,0 +1,115 @@
+// matchertest.go: test helpers for code built on the matcher
+//
+// Package matchertest provides assertions for tests of projects using
+// github.com/itgcl/ahocorasick, so the behavior of a dictionary can be locked
+// in and reviewed whenever it is updated. Golden files hold the positions of
+// every occurrence in a set of texts; running the tests with
+// -matchertest.update rewrites them from the current results.
+
+package matchertest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/itgcl/ahocorasick"
+)
+
+var update = flag.Bool("matchertest.update", false, "rewrite golden files from the current results")
+
+// AssertMatches fails the test unless m reports exactly the patterns want
+// for text, in the order MatchString reports them
+func AssertMatches(t testing.TB, m *ahocorasick.Matcher, text string, want []int) {
+	t.Helper()
+	if got := m.MatchThreadSafeString(text); !slices.Equal(got, want) {
+		t.Errorf("matches of %q: got %v, want %v", text, got, want)
+	}
+}
+
+// AssertNoMatch fails the test if any pattern occurs in text
+func AssertNoMatch(t testing.TB, m *ahocorasick.Matcher, text string) {
+	t.Helper()
+	if got := m.FindAllString(text); len(got) > 0 {
+		t.Errorf("matches of %q: got %s, want none", text, Format(text, got))
+	}
+}
+
+// AssertFindAll fails the test unless FindAllString reports exactly the
+// occurrences want for text
+func AssertFindAll(t testing.TB, m *ahocorasick.Matcher, text string, want []ahocorasick.Match) {
+	t.Helper()
+	if got := m.FindAllString(text); !slices.Equal(got, want) {
+		t.Errorf("occurrences in %q:\ngot:\n%swant:\n%s", text, Format(text, got), Format(text, want))
+	}
+}
+
+// AssertGolden fails the test unless the occurrences FindAllString reports
+// in texts are those recorded in the golden file at path, which is written
+// instead when the tests run with -matchertest.update
+func AssertGolden(t testing.TB, m *ahocorasick.Matcher, path string, texts ...string) {
+	t.Helper()
+	var b strings.Builder
+	for i, text := range texts {
+		fmt.Fprintf(&b, "text %d %q\n", i, text)
+		b.WriteString(Format(text, m.FindAllString(text)))
+	}
+	got := b.String()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run with -matchertest.update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("occurrences differ from %s:\n%s", path, diff(string(want), got))
+	}
+}
+
+// Format describes occurrences in text one per line, as the pattern index,
+// the start and end offsets and the matched text, the golden file format
+func Format(text string, matches []ahocorasick.Match) string {
+	var b strings.Builder
+	for _, o := range matches {
+		fmt.Fprintf(&b, "%d %d %d %q\n", o.Pattern, o.Start, o.End, text[o.Start:o.End])
+	}
+	return b.String()
+}
+
+// maxDiffs is the number of differing lines diff reports
+const maxDiffs = 10
+
+// diff lists the first lines differing between want and got, prefixed with
+// - and + respectively
+func diff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	var b strings.Builder
+	ndiffs := 0
+	for i := 0; i < max(len(wantLines), len(gotLines)) && ndiffs < maxDiffs; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&b, "line %d:\n- %s\n+ %s\n", i+1, w, g)
+			ndiffs++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+// matchertest_test.go: tests for the test helpers
+
+package matchertest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itgcl/ahocorasick"
+)
+
+// recorder is a testing.TB recording failures instead of reporting them
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func assert(t *testing.T, b bool) {
+	if !b {
+		t.Fail()
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	m := ahocorasick.NewStringMatcher([]string{"he", "she", "hers"})
+
+	AssertMatches(t, m, "ushers", []int{1, 0, 2})
+	AssertNoMatch(t, m, "nothing")
+	AssertFindAll(t, m, "she", []ahocorasick.Match{{Pattern: 1, Start: 0, End: 3}, {Pattern: 0, Start: 1, End: 3}})
+
+	r := &recorder{}
+	AssertMatches(r, m, "ushers", []int{0})
+	AssertNoMatch(r, m, "he")
+	AssertFindAll(r, m, "he", nil)
+	assert(t, len(r.errors) == 3)
+	assert(t, strings.Contains(r.errors[1], `0 0 2 "he"`))
+}
+
+func TestAssertGolden(t *testing.T) {
+	m := ahocorasick.NewStringMatcher([]string{"he", "she", "hers"})
+	path := filepath.Join(t.TempDir(), "testdata", "he.golden")
+	texts := []string{"ushers", "none"}
+
+	r := &recorder{}
+	AssertGolden(r, m, path, texts...)
+	assert(t, r.fatal)
+
+	*update = true
+	AssertGolden(t, m, path, texts...)
+	*update = false
+	data, err := os.ReadFile(path)
+	assert(t, err == nil)
+	assert(t, string(data) == "text 0 \"ushers\"\n1 1 4 \"she\"\n0 2 4 \"he\"\n2 2 6 \"hers\"\ntext 1 \"none\"\n")
+	AssertGolden(t, m, path, texts...)
+
+	// a dictionary update changing the results is caught
+	m = ahocorasick.NewStringMatcher([]string{"he", "she"})
+	r = &recorder{}
+	AssertGolden(r, m, path, texts...)
+	assert(t, len(r.errors) == 1 && !r.fatal)
+	assert(t, strings.Contains(r.errors[0], "line 4:\n- 2 2 6 \"hers\"\n+ text 1"))
+}
@@ -0,0 +1,188 @@
+// matchopts.go: per-call options, so one matcher serves callers with
+// different result requirements.
+
+package ahocorasick
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// OffsetUnit is the unit offsets are reported in
+type OffsetUnit int
+
+const (
+	// ByteOffsets counts bytes of the UTF-8 input, as all other APIs do
+	ByteOffsets OffsetUnit = iota
+
+	// RuneOffsets counts runes, an invalid byte counting as one rune
+	RuneOffsets
+
+	// UTF16Offsets counts UTF-16 code units, as JavaScript and Java strings
+	// do: two for a rune outside the basic multilingual plane
+	UTF16Offsets
+)
+
+// MatchOptions adjusts a single FindAllWith call, the zero value keeping the
+// behavior of FindAll
+type MatchOptions struct {
+	// Overlap replaces the overlap policy of the matcher when not nil
+	Overlap *OverlapPolicy
+
+	// Budget bounds the work and the results, see FindAllBudget
+	Budget Budget
+
+	// Subset restricts the reported patterns to a view of the same matcher,
+	// built with Subset or WithoutCategories, when not nil
+	Subset *Subset
+
+	// Offsets is the unit of the offsets of the returned matches
+	Offsets OffsetUnit
+
+	// DedupWindow, when positive, reports a pattern at most once per sliding
+	// window of that many runes: an occurrence starting fewer runes after
+	// the last reported occurrence of the same pattern is dropped, which is
+	// how alerting systems aggregate keyword signals
+	DedupWindow int
+
+	// Transform rewrites every input rune into zero or more runes before the
+	// normalization of the matcher, if any, and the transition lookup, when
+	// not nil; unlike WithNormalizer it does not rewrite the patterns, so
+	// custom foldings can be tried without rebuilding the automaton, at the
+	// cost of the input skipping and screening the matcher does otherwise
+	Transform Normalizer
+}
+
+// FindAllWith is like FindAll with the per-call options o, so a shared
+// matcher can serve callers with different result requirements without
+// being rebuilt; the result tells whether the budget truncated it
+func (m *Matcher) FindAllWith(text []byte, o MatchOptions) Result {
+	return m.FindAllWithString(bytesToString(text), o)
+}
+
+// FindAllWithString is like FindAllWith for a string input
+func (m *Matcher) FindAllWithString(text string, o MatchOptions) Result {
+	b := o.Budget
+	r := Result{Scanned: len(text)}
+	if b.MaxBytes > 0 && b.MaxBytes < len(text) {
+		r.Scanned = runeStart(text, b.MaxBytes)
+	}
+	policy := m.overlap
+	if o.Overlap != nil {
+		policy = *o.Overlap
+	}
+
+	// without an overlap policy, occurrences are deduplicated as they are
+	// found, and those past the budget are counted but never stored
+	store := policy == ReportAll && b.MaxMatches > 0
+	var dd *dedup
+	if o.DedupWindow > 0 {
+		dd = newDedup(text, o.DedupWindow)
+	}
+	var transform pipeline
+	if o.Transform != nil {
+		transform = pipeline{o.Transform.Normalize}
+	}
+	m.findWith(text[:r.Scanned], transform, func(pattern int32, start, end int) bool {
+		if o.Subset != nil && !o.Subset.allows(int(pattern)) {
+			return true
+		}
+		if policy == ReportAll && dd != nil && dd.drop(int(pattern), start) {
+			return true
+		}
+		if store && len(r.Matches) == b.MaxMatches {
+			r.Dropped++
+		} else {
+			r.Matches = append(r.Matches, Match{Pattern: int(pattern), Start: start, End: end})
+		}
+		return true
+	})
+	if policy != ReportAll {
+		r.Matches = resolveOverlaps(r.Matches, policy)
+		if dd != nil {
+			kept := r.Matches[:0]
+			for _, o := range r.Matches {
+				if !dd.drop(o.Pattern, o.Start) {
+					kept = append(kept, o)
+				}
+			}
+			r.Matches = kept
+		}
+	}
+	if b.MaxMatches > 0 && len(r.Matches) > b.MaxMatches {
+		r.Dropped = len(r.Matches) - b.MaxMatches
+		r.Matches = r.Matches[:b.MaxMatches:b.MaxMatches]
+	}
+	r.Truncated = r.Dropped > 0 || r.Scanned < len(text)
+	m.countHits(r.Matches)
+	if o.Offsets != ByteOffsets {
+		convertOffsets(text, r.Matches, o.Offsets)
+	}
+	return r
+}
+
+// dedup drops every occurrence starting fewer than window runes after the
+// last one kept for its pattern, counting the runes of text up to the
+// starts as they come
+type dedup struct {
+	text   string
+	window int
+	pos    int         // byte offset the runes are counted up to
+	runes  int         // number of runes before pos
+	last   map[int]int // rune offset of the last start kept per pattern
+}
+
+func newDedup(text string, window int) *dedup {
+	return &dedup{text: text, window: window, last: make(map[int]int)}
+}
+
+// drop reports whether the occurrence of pattern at byte offset start is
+// dropped, recording it as kept otherwise; starts are rune starts and
+// mostly increasing, so the count moves little
+func (d *dedup) drop(pattern, start int) bool {
+	if start >= d.pos {
+		d.runes += utf8.RuneCountInString(d.text[d.pos:start])
+	} else {
+		d.runes -= utf8.RuneCountInString(d.text[start:d.pos])
+	}
+	d.pos = start
+	if prev, ok := d.last[pattern]; ok && d.runes-prev < d.window {
+		return true
+	}
+	d.last[pattern] = d.runes
+	return false
+}
+
+// convertOffsets rewrites the byte offsets of matches in text into unit
+func convertOffsets(text string, matches []Match, unit OffsetUnit) {
+	offsets := make([]int, 0, 2*len(matches))
+	for _, o := range matches {
+		offsets = append(offsets, o.Start, o.End)
+	}
+	converted := countUnits(text, offsets, unit)
+	for k := range matches {
+		matches[k].Start = converted[matches[k].Start]
+		matches[k].End = converted[matches[k].End]
+	}
+}
+
+// countUnits maps every byte offset of text in offsets, which it sorts, to
+// the same offset in unit, walking text once up to the last offset
+func countUnits(text string, offsets []int, unit OffsetUnit) map[int]int {
+	sort.Ints(offsets)
+	converted := make(map[int]int, len(offsets))
+	pos, count := 0, 0
+	for _, off := range offsets {
+		for pos < off {
+			r, size := utf8.DecodeRuneInString(text[pos:])
+			pos += size
+			if unit == UTF16Offsets && r >= 0x10000 {
+				count += 2
+			} else {
+				count++
+			}
+		}
+		converted[off] = count
+	}
+	return converted
+}
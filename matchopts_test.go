@@ -0,0 +1,97 @@
+// matchopts_test.go: tests for per-call options
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestFindAllWith(t *testing.T) {
+	m := NewStringMatcher([]string{"Mac", "Macintosh", "tosh", "😀"}, WithCategories(map[string][]int{"short": {0, 2}}))
+	text := "Macintosh 😀 Mac"
+
+	r := m.FindAllWithString(text, MatchOptions{})
+	want := m.FindAllString(text)
+	assert(t, !r.Truncated && len(r.Matches) == len(want) && len(want) == 5)
+
+	longest := LongestWins
+	r = m.FindAllWithString(text, MatchOptions{Overlap: &longest})
+	assert(t, len(r.Matches) == 3 && r.Matches[0].Pattern == 1)
+
+	r = m.FindAllWith([]byte(text), MatchOptions{Subset: m.WithoutCategories("short")})
+	assert(t, len(r.Matches) == 2 && r.Matches[0].Pattern == 1 && r.Matches[1].Pattern == 3)
+
+	r = m.FindAllWithString(text, MatchOptions{Subset: m.Subset([]int{0}), Budget: Budget{MaxMatches: 1}})
+	assert(t, r.Truncated && r.Dropped == 1 && r.Matches[0] == Match{Pattern: 0, Start: 0, End: 3})
+
+	// offsets in runes and UTF-16 code units
+	r = m.FindAllWithString(text, MatchOptions{Overlap: &longest, Offsets: RuneOffsets})
+	assert(t, r.Matches[1] == Match{Pattern: 3, Start: 10, End: 11})
+	assert(t, r.Matches[2] == Match{Pattern: 0, Start: 12, End: 15})
+	r = m.FindAllWithString(text, MatchOptions{Overlap: &longest, Offsets: UTF16Offsets})
+	assert(t, r.Matches[1] == Match{Pattern: 3, Start: 10, End: 12})
+	assert(t, r.Matches[2] == Match{Pattern: 0, Start: 13, End: 16})
+
+	// the matcher is left as it was
+	assert(t, len(m.FindAllString(text)) == 5)
+}
+
+func TestFindAllWithTransform(t *testing.T) {
+	m := NewStringMatcher([]string{"hello", "and"})
+	leet := MapRune(func(r rune) rune {
+		switch r {
+		case '3':
+			return 'e'
+		case '0':
+			return 'o'
+		}
+		return unicode.ToLower(r)
+	})
+
+	text := "H3LL0 world"
+	assert(t, len(m.FindAllString(text)) == 0)
+	r := m.FindAllWithString(text, MatchOptions{Transform: leet})
+	assert(t, len(r.Matches) == 1 && r.Matches[0] == Match{Pattern: 0, Start: 0, End: 5})
+
+	// a rune can expand into several, and inputs shorter than the patterns
+	// are scanned
+	expand := NormalizerFunc(func(dst []rune, _, r rune) []rune {
+		if r == '&' {
+			return append(dst, 'a', 'n', 'd')
+		}
+		return append(dst, r)
+	})
+	r = m.FindAllWithString("&", MatchOptions{Transform: expand})
+	assert(t, len(r.Matches) == 1 && r.Matches[0] == Match{Pattern: 1, Start: 0, End: 1})
+}
+
+func TestFindAllWithDedup(t *testing.T) {
+	m := NewStringMatcher([]string{"fire", "smoke"})
+	// "fire" starts at runes 0, 5, 16 and 21, "smoke" at 10
+	text := "fire fire smoke fire fire"
+
+	r := m.FindAllWithString(text, MatchOptions{DedupWindow: 6})
+	assert(t, len(r.Matches) == 3)
+	assert(t, r.Matches[0].Start == 0 && r.Matches[1].Pattern == 1 && r.Matches[2].Start == 16)
+
+	r = m.FindAllWithString(text, MatchOptions{DedupWindow: 5})
+	assert(t, len(r.Matches) == 5)
+	r = m.FindAllWithString(text, MatchOptions{DedupWindow: 100, Budget: Budget{MaxMatches: 1}})
+	assert(t, len(r.Matches) == 1 && r.Dropped == 1)
+
+	// the budget still bounds what is stored
+	long := strings.Repeat("fire ", 100000)
+	o := MatchOptions{DedupWindow: 3, Budget: Budget{MaxMatches: 10}}
+	r = m.FindAllWithString(long, o)
+	assert(t, len(r.Matches) == 10 && r.Dropped == 100000-10 && r.Matches[9].Start == 45)
+	allocs := testing.AllocsPerRun(2, func() { m.FindAllWithString(long, o) })
+	assert(t, allocs < 50)
+
+	// windows count runes, not bytes
+	r = m.FindAllWithString("fire éééé fire", MatchOptions{DedupWindow: 10})
+	assert(t, len(r.Matches) == 2)
+	r = m.FindAllWithString("fire éééé fire", MatchOptions{DedupWindow: 11, Offsets: RuneOffsets})
+	assert(t, len(r.Matches) == 1 && r.Matches[0].End == 4)
+}
@@ -0,0 +1,302 @@
+// metadata.go: per-pattern metadata carried by a matcher and saved with it.
+//
+// Metadata is stored after the edge array of a saved automaton as a little
+// endian 32 bit length followed by the section itself, laid out like the
+// rest of the format in little-endian 32 bit integers:
+//   - the schema of the section, versioned independently of the automaton
+//     so fields can be added without touching its layout, and the number
+//     of patterns
+//   - the number of pattern IDs, noIDs when the outputs are the patterns,
+//     followed by the ID of every output, at a fixed offset so OpenDisk can
+//     map outputs without reading the rest
+//   - the info of the patterns: a count, then for each the severity as a
+//     64 bit integer and the length and bytes of the payload
+//   - the equivalence classes: a count, then the length and bytes of each
+//   - the categories in name order: a count, then for each the length and
+//     bytes of its name and the count and indices of its patterns
+// Files with metadata use format version 5, files without it keep version 3.
+
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	metaFormatVersion = 5 // format version of files carrying metadata
+	metaSchema        = 1 // version of the metadata section
+	metaLengthSize    = 4
+	metaIDsOffset     = 16 // offset of the IDs in the section, its length included
+	noIDs             = ^uint32(0)
+)
+
+// PatternInfo is metadata attached to a pattern, saved along with the
+// automaton so a single file fully describes a deployed policy
+type PatternInfo struct {
+	Severity int         `json:"severity,omitempty"`
+	Payload  jsonPayload `json:"payload,omitempty"` // arbitrary caller data, a JSON value
+}
+
+// WithPatternInfo attaches metadata to the patterns, info[i] describing
+// pattern i; it may be shorter than the dictionary
+func WithPatternInfo(info []PatternInfo) Option {
+	return func(o *options) {
+		o.info = info
+	}
+}
+
+// PatternInfo returns the metadata of pattern i, zero when none was attached
+func (m *Matcher) PatternInfo(i int) PatternInfo {
+	if i < 0 || i >= len(m.info) {
+		return PatternInfo{}
+	}
+	return m.info[i]
+}
+
+// metadata is the section saved after the automaton
+type metadata struct {
+	Schema     int
+	Patterns   int
+	Categories map[string][]int
+	Info       []PatternInfo
+	IDs        []int32
+
+	// Equivalence holds the rune classes, which the transitions already
+	// include, so the walks over the trie can tell aliases apart
+	Equivalence []string
+}
+
+// hasMetadata reports whether the matcher carries anything the metadata
+// section must hold
+func (m *Matcher) hasMetadata() bool {
+	return m.categories != nil || len(m.info) > 0 || m.ids != nil || m.equiv != nil
+}
+
+// encodeMetadata writes the metadata section
+func (m *Matcher) encodeMetadata(w io.Writer) error {
+	doc := metadata{Schema: metaSchema, Patterns: m.npatterns, Info: m.info, IDs: m.ids}
+	if m.equiv != nil {
+		doc.Equivalence = m.equiv.classes
+	}
+	if c := m.categories; c != nil {
+		doc.Categories = make(map[string][]int, len(c.names))
+		for bit, name := range c.names {
+			doc.Categories[name] = []int{}
+			for p, mask := range c.masks {
+				if mask&(1<<uint(bit)) != 0 {
+					doc.Categories[name] = append(doc.Categories[name], p)
+				}
+			}
+		}
+	}
+	if n := m.impliedPatterns(&doc); n < doc.Patterns {
+		// patterns that left no trace, such as trailing null patterns of a
+		// JSON dump, are accounted for by empty info
+		doc.Info = append(append([]PatternInfo(nil), doc.Info...), make([]PatternInfo, doc.Patterns-len(doc.Info))...)
+	}
+	body := doc.marshal()
+	if _, err := w.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(body)))); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// marshal returns the section holding doc
+func (doc *metadata) marshal() []byte {
+	le := binary.LittleEndian
+	b := le.AppendUint32(nil, uint32(doc.Schema))
+	b = le.AppendUint32(b, uint32(doc.Patterns))
+	if doc.IDs == nil {
+		b = le.AppendUint32(b, noIDs)
+	} else {
+		b = le.AppendUint32(b, uint32(len(doc.IDs)))
+		for _, id := range doc.IDs {
+			b = le.AppendUint32(b, uint32(id))
+		}
+	}
+	appendBytes := func(b, s []byte) []byte {
+		return append(le.AppendUint32(b, uint32(len(s))), s...)
+	}
+	b = le.AppendUint32(b, uint32(len(doc.Info)))
+	for _, info := range doc.Info {
+		b = le.AppendUint64(b, uint64(info.Severity))
+		b = appendBytes(b, info.Payload)
+	}
+	b = le.AppendUint32(b, uint32(len(doc.Equivalence)))
+	for _, class := range doc.Equivalence {
+		b = appendBytes(b, []byte(class))
+	}
+	names := make([]string, 0, len(doc.Categories))
+	for name := range doc.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b = le.AppendUint32(b, uint32(len(names)))
+	for _, name := range names {
+		b = appendBytes(b, []byte(name))
+		b = le.AppendUint32(b, uint32(len(doc.Categories[name])))
+		for _, p := range doc.Categories[name] {
+			b = le.AppendUint32(b, uint32(p))
+		}
+	}
+	return b
+}
+
+// metaReader reads the integers and byte strings of a metadata section,
+// recording rather than returning the first error
+type metaReader struct {
+	data []byte
+	bad  bool
+}
+
+func (r *metaReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.bad = true
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *metaReader) uint64() uint64 {
+	lo := r.uint32()
+	return uint64(r.uint32())<<32 | uint64(lo)
+}
+
+// count reads a number of items of at least size bytes each, which the
+// rest of the section must be able to hold before anything is allocated
+func (r *metaReader) count(size int) int {
+	n := r.uint32()
+	if uint64(n)*uint64(size) > uint64(len(r.data)) {
+		r.bad = true
+		return 0
+	}
+	return int(n)
+}
+
+// bytes reads a byte string, which aliases the section
+func (r *metaReader) bytes() []byte {
+	n := r.count(1)
+	b := r.data[:n:n]
+	r.data = r.data[n:]
+	return b
+}
+
+// unmarshal reads doc from the section data
+func (doc *metadata) unmarshal(data []byte) error {
+	r := &metaReader{data: data}
+	doc.Schema = int(r.uint32())
+	if !r.bad && (doc.Schema < 1 || doc.Schema > metaSchema) {
+		return fmt.Errorf("unknown metadata schema %d", doc.Schema)
+	}
+	doc.Patterns = int(r.uint32())
+	if len(r.data) >= 4 && binary.LittleEndian.Uint32(r.data) == noIDs {
+		r.uint32()
+	} else {
+		doc.IDs = make([]int32, r.count(4))
+		for i := range doc.IDs {
+			doc.IDs[i] = int32(r.uint32())
+		}
+	}
+	if n := r.count(12); n > 0 {
+		doc.Info = make([]PatternInfo, n)
+		for i := range doc.Info {
+			doc.Info[i].Severity = int(int64(r.uint64()))
+			if payload := r.bytes(); len(payload) > 0 {
+				doc.Info[i].Payload = append(jsonPayload(nil), payload...)
+			}
+		}
+	}
+	if n := r.count(4); n > 0 {
+		doc.Equivalence = make([]string, n)
+		for i := range doc.Equivalence {
+			doc.Equivalence[i] = string(r.bytes())
+		}
+	}
+	if n := r.count(8); n > 0 {
+		doc.Categories = make(map[string][]int, n)
+		for range n {
+			name := string(r.bytes())
+			patterns := make([]int, r.count(4))
+			for i := range patterns {
+				patterns[i] = int(r.uint32())
+			}
+			doc.Categories[name] = patterns
+		}
+	}
+	if r.bad || len(r.data) > 0 {
+		return errors.New("truncated or oversized metadata")
+	}
+	return nil
+}
+
+// impliedPatterns returns the number of patterns the outputs of m, or the
+// entries and IDs of doc they map to, and the info and categories of doc
+// refer to
+func (m *Matcher) impliedPatterns(doc *metadata) int {
+	n := len(doc.Info)
+	if doc.IDs != nil {
+		n = max(n, len(doc.IDs))
+		for _, id := range doc.IDs {
+			n = max(n, int(id)+1)
+		}
+	} else {
+		for _, out := range m.outputs {
+			n = max(n, int(out)+1)
+		}
+	}
+	for _, patterns := range doc.Categories {
+		for _, p := range patterns {
+			n = max(n, p+1)
+		}
+	}
+	return n
+}
+
+// decodeMetadata restores the metadata section data into m
+func (m *Matcher) decodeMetadata(data []byte) error {
+	if len(data) < metaLengthSize || uint64(len(data)-metaLengthSize) != uint64(binary.LittleEndian.Uint32(data)) {
+		return ErrInvalidFormat
+	}
+	var doc metadata
+	if err := doc.unmarshal(data[metaLengthSize:]); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if doc.Patterns < 0 || len(doc.Info) > doc.Patterns {
+		return ErrInvalidFormat
+	}
+	for _, id := range doc.IDs {
+		if id < 0 || int(id) >= doc.Patterns {
+			return ErrInvalidFormat
+		}
+	}
+	// per-pattern tables are sized by the count, so it may not exceed what
+	// the automaton and the document account for
+	if doc.Patterns > m.impliedPatterns(&doc) {
+		return fmt.Errorf("%w: %d patterns claimed", ErrInvalidFormat, doc.Patterns)
+	}
+	m.npatterns = doc.Patterns
+	m.info = doc.Info
+	m.ids = doc.IDs
+	if len(doc.Equivalence) > 0 {
+		equiv, err := newEquivalence(doc.Equivalence)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+		}
+		m.equiv = equiv
+	}
+	if doc.Categories != nil {
+		c, err := newCategories(doc.Categories, doc.Patterns)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+		}
+		m.categories = c
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// metadata_test.go: tests for pattern metadata and its persistence
+
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatternInfo(t *testing.T) {
+	m := NewStringMatcher(dictionary, WithPatternInfo([]PatternInfo{
+		{Severity: 2},
+		{Severity: 5, Payload: json.RawMessage(`{"action":"block"}`)},
+	}))
+	assert(t, m.PatternInfo(0).Severity == 2)
+	assert(t, string(m.PatternInfo(1).Payload) == `{"action":"block"}`)
+	assert(t, m.PatternInfo(4).Severity == 0)
+	assert(t, m.PatternInfo(-1).Payload == nil)
+
+	_, err := Compile([]string{"a"}, WithPatternInfo(make([]PatternInfo, 2)))
+	assert(t, err != nil)
+}
+
+func TestSaveLoadMetadata(t *testing.T) {
+	m := NewStringMatcher(dictionary,
+		WithCategories(map[string][]int{"browser": {0, 3}, "os": {1, 2}, "empty": {}}),
+		WithPatternInfo([]PatternInfo{{Severity: 1}, {Severity: 3, Payload: json.RawMessage(`"mac"`)}}))
+	var buf strings.Builder
+	assert(t, m.Save(&buf) == nil)
+	data := []byte(buf.String())
+	assert(t, data[len(formatMagic)] == metaFormatVersion)
+
+	loaded, err := LoadBytes(data)
+	assert(t, err == nil)
+	assert(t, len(loaded.MatchString(sbytes)) == 4)
+	cats := loaded.Categories()
+	assert(t, len(cats) == 3 && cats[0] == "browser" && cats[1] == "empty")
+	hits := loaded.WithoutCategories("os").MatchString(sbytes)
+	assert(t, len(hits) == 2 && hits[0] == 0 && hits[1] == 3)
+	assert(t, loaded.PatternInfo(1).Severity == 3)
+	assert(t, string(loaded.PatternInfo(1).Payload) == `"mac"`)
+
+	// saving again gives the same file
+	var again strings.Builder
+	assert(t, loaded.Save(&again) == nil)
+	assert(t, again.String() == buf.String())
+
+	// the automaton itself is also usable from disk
+	d, err := OpenDisk(strings.NewReader(buf.String()), int64(buf.Len()))
+	assert(t, err == nil)
+	ok, err := d.ContainsString(sbytes)
+	assert(t, err == nil && ok)
+
+	// matchers without metadata keep the previous version
+	buf.Reset()
+	assert(t, precomputed.Save(&buf) == nil)
+	assert(t, buf.String()[len(formatMagic)] == formatVersion)
+}
+
+func TestLoadMetadataErrors(t *testing.T) {
+	m := NewStringMatcher(dictionary, WithPatternInfo([]PatternInfo{{Severity: 1}}))
+	var buf strings.Builder
+	assert(t, m.Save(&buf) == nil)
+	data := []byte(buf.String())
+
+	_, err := LoadBytes(data[:len(data)-1])
+	assert(t, errors.Is(err, ErrInvalidFormat))
+	_, err = OpenDisk(strings.NewReader(string(data[:len(data)-1])), int64(len(data)-1))
+	assert(t, errors.Is(err, ErrInvalidFormat))
+
+	// withSection returns the saved automaton followed by the section of doc
+	automaton := headerSize + len(m.states)*(stateSize+outputSize) + len(m.edges)*edgeSize
+	withSection := func(doc metadata) []byte {
+		body := doc.marshal()
+		b := binary.LittleEndian.AppendUint32(append([]byte(nil), data[:automaton]...), uint32(len(body)))
+		return append(b, body...)
+	}
+	_, err = LoadBytes(withSection(metadata{Schema: metaSchema, Patterns: len(dictionary)}))
+	assert(t, err == nil)
+
+	for _, doc := range []metadata{
+		// a section from a future schema
+		{Schema: 99, Patterns: len(dictionary)},
+		// outputs must refer to the patterns the metadata declares
+		{Schema: metaSchema, Patterns: 2},
+		// nor may the metadata declare patterns nothing refers to, which
+		// would size the per-pattern tables
+		{Schema: metaSchema, Patterns: 2000000000, Categories: map[string][]int{"c": {}}},
+		{Schema: metaSchema, Patterns: 6, IDs: []int32{0, 1, 2, 3, 4}},
+		{Schema: metaSchema, Patterns: 6, Categories: map[string][]int{"c": {4}}},
+	} {
+		_, err = LoadBytes(withSection(doc))
+		assert(t, errors.Is(err, ErrInvalidFormat))
+	}
+}
+
+func TestMetadataSection(t *testing.T) {
+	doc := metadata{
+		Schema:     metaSchema,
+		Patterns:   4,
+		Categories: map[string][]int{"z": {3}, "a": {0, 1}, "empty": {}},
+		Info: []PatternInfo{
+			{},
+			{Severity: -2},
+			{Payload: jsonPayload(`{"k": [1, "v"]}`)},
+			{Severity: math.MaxInt},
+		},
+		IDs:         []int32{0, 2, 2, 1},
+		Equivalence: []string{"0o", "1lI|"},
+	}
+	section := doc.marshal()
+	le := binary.LittleEndian
+	assert(t, le.Uint32(section[metaIDsOffset-metaLengthSize-4:]) == 4)
+	assert(t, le.Uint32(section[metaIDsOffset-metaLengthSize+4:]) == 2)
+
+	var back metadata
+	assert(t, back.unmarshal(section) == nil)
+	assert(t, reflect.DeepEqual(back, doc))
+	assert(t, string(back.marshal()) == string(section))
+
+	// a section without IDs or any list
+	plain := metadata{Schema: metaSchema, Patterns: 3}
+	back = metadata{}
+	assert(t, back.unmarshal(plain.marshal()) == nil && reflect.DeepEqual(back, plain))
+
+	// every truncation is detected, as is trailing data
+	for n := range len(section) {
+		assert(t, new(metadata).unmarshal(section[:n]) != nil)
+	}
+	assert(t, new(metadata).unmarshal(append(section, 0)) != nil)
+
+	// counts beyond the section fail before anything is allocated for them
+	huge := le.AppendUint32(le.AppendUint32(nil, metaSchema), 1)
+	huge = le.AppendUint32(huge, 1<<30)
+	assert(t, new(metadata).unmarshal(huge) != nil)
+}
@@ -0,0 +1,120 @@
+// minimize.go: merging equivalent states of a built automaton.
+
+package ahocorasick
+
+import "encoding/binary"
+
+// WithMinimize merges, once the automaton is built, the states that behave
+// alike: same depth, same reported pattern, same links and transitions to
+// merged states, which turns the trie into a smaller graph matching exactly
+// as before
+// the depth of a state locates the start of its occurrences and every
+// pattern index has its own state, so only states reporting the same ID can
+// merge: the gain comes from dictionaries of many surface forms of equal
+// length per ID, such as spelling or obfuscation variants given to
+// CompileSynonyms, CompileIDs or LoadKeywords
+// it is ignored with WithLazyLinks and with case-insensitive patterns, and a
+// minimized matcher cannot be exported with ExportDump
+func WithMinimize() Option {
+	return func(o *options) {
+		o.minimize = true
+	}
+}
+
+// minimize merges the equivalent states of m by partition refinement:
+// states start grouped by depth and reported pattern, then groups are split
+// by the groups of their links and transition targets until stable
+// states are renumbered in the order of their first member, which keeps the
+// breadth-first property that links point to smaller IDs; it reports
+// whether any state was merged
+func (m *Matcher) minimize() bool {
+	if m.lazy != nil || m.fold != nil || len(m.states) < 2 {
+		return false
+	}
+	n := len(m.states)
+	depth := make([]uint32, n)
+	for s := range m.states {
+		st := &m.states[s]
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			depth[e.next] = depth[s] + 1
+		}
+	}
+
+	class := make([]uint32, n)
+	next := make([]uint32, n)
+	groups := make(map[string]uint32)
+	var key []byte
+	refine := func(signature func(s int, key []byte) []byte) int {
+		clear(groups)
+		for s := range m.states {
+			key = signature(s, key[:0])
+			c, ok := groups[string(key)]
+			if !ok {
+				c = uint32(len(groups))
+				groups[string(key)] = c
+			}
+			next[s] = c
+		}
+		class, next = next, class
+		return len(groups)
+	}
+	count := refine(func(s int, key []byte) []byte {
+		key = binary.AppendUvarint(key, uint64(depth[s]))
+		out := m.outputs[s]
+		if out >= 0 {
+			out = m.id(out)
+		}
+		return binary.AppendVarint(key, int64(out))
+	})
+	for {
+		split := refine(func(s int, key []byte) []byte {
+			st := &m.states[s]
+			key = binary.AppendUvarint(key, uint64(class[s]))
+			key = binary.AppendUvarint(key, uint64(class[st.fail]))
+			key = binary.AppendUvarint(key, uint64(class[st.suffix]))
+			for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+				key = binary.AppendVarint(key, int64(e.label))
+				key = binary.AppendUvarint(key, uint64(class[e.next]))
+			}
+			return key
+		})
+		if split == count {
+			break
+		}
+		count = split
+	}
+	if count == n {
+		return false
+	}
+
+	// the first member of every class represents it
+	id := next[:count] // new ID of every class, next is free again
+	for c := range id {
+		id[c] = ^uint32(0)
+	}
+	reps := make([]uint32, 0, count)
+	for s := range m.states {
+		if c := class[s]; id[c] == ^uint32(0) {
+			id[c] = uint32(len(reps))
+			reps = append(reps, uint32(s))
+		}
+	}
+	states := make([]state, count)
+	outputs := make([]int32, count)
+	var edges []edge
+	for i, r := range reps {
+		st := &m.states[r]
+		states[i] = state{
+			edges:  uint32(len(edges)),
+			nedges: st.nedges,
+			fail:   id[class[st.fail]],
+			suffix: id[class[st.suffix]],
+		}
+		outputs[i] = m.outputs[r]
+		for _, e := range m.edges[st.edges : st.edges+st.nedges] {
+			edges = append(edges, edge{label: e.label, next: id[class[e.next]]})
+		}
+	}
+	m.states, m.outputs, m.edges = states, outputs, edges
+	return true
+}
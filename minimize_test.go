@@ -0,0 +1,65 @@
+// minimize_test.go: tests for the minimization of automata
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+// leetVariants returns the spellings of word where letters are replaced by
+// look-alike symbols
+func leetVariants(word string) []string {
+	subs := map[rune]string{'a': "a4@", 'e': "e3", 'i': "i1!", 'o': "o0", 's': "s5$"}
+	variants := []string{""}
+	for _, r := range word {
+		alts := subs[r]
+		if alts == "" {
+			alts = string(r)
+		}
+		var next []string
+		for _, v := range variants {
+			for _, a := range alts {
+				next = append(next, v+string(a))
+			}
+		}
+		variants = next
+	}
+	return variants
+}
+
+func TestMinimize(t *testing.T) {
+	groups := [][]string{leetVariants("password"), leetVariants("secret"), leetVariants("admin")}
+	plain, err := CompileSynonyms(groups)
+	assert(t, err == nil)
+	min, err := CompileSynonyms(groups, WithMinimize())
+	assert(t, err == nil)
+	assert(t, len(min.states)*3 < len(plain.states))
+
+	text := "my p4$$w0rd is s3cr3t, ask @dm1n or adm!n, passw0rd5 " + strings.Join(groups[0][:20], " ")
+	for _, m := range []*Matcher{plain, min} {
+		assert(t, len(m.MatchString(text)) == 3)
+	}
+	want := plain.FindAllString(text)
+	got := min.FindAllString(text)
+	assert(t, len(got) == len(want) && len(got) > 20)
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+
+	// a minimized automaton is saved and loaded like any other
+	var sb strings.Builder
+	assert(t, min.Save(&sb) == nil)
+	loaded, err := LoadBytes([]byte(sb.String()))
+	assert(t, err == nil)
+	got = loaded.FindAllString(text)
+	assert(t, len(got) == len(want))
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+
+	// distinct patterns never merge
+	m := NewStringMatcher(dictionary, WithMinimize())
+	assert(t, len(m.states) == len(precomputed.states))
+	assert(t, m.minimize() == false)
+}
@@ -0,0 +1,57 @@
+// mmap.go: serving a saved automaton directly from a memory-mapped file.
+
+package ahocorasick
+
+import (
+	"io"
+	"os"
+)
+
+// Open loads a matcher saved to the file at path by Save
+// an uncompressed file is mapped read-only into memory and matched from the
+// mapping without copying it, so processes opening the same file share a
+// single physical copy of the automaton through the page cache; compressed
+// files, and platforms without mmap, are read into memory instead
+// Close releases the mapping once the matcher is no longer used
+func Open(path string, opts ...LoadOption) (*Matcher, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, unmap, err := mapFile(f)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil || len(data) < len(formatMagic) || string(data[:len(formatMagic)]) != formatMagic {
+		// not mappable: compressed or unsupported by the platform
+		if unmap != nil {
+			unmap()
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return Load(f, opts...)
+	}
+
+	m, err := LoadBytes(data, opts...)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	m.unmap = unmap
+	return m, nil
+}
+
+// Close releases the file mapping of a matcher returned by Open, the matcher
+// must not be used afterwards; it does nothing for other matchers
+func (m *Matcher) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap()
+	m.unmap = nil
+	m.states, m.edges, m.outputs, m.data = nil, nil, nil, nil
+	return err
+}
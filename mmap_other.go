@@ -0,0 +1,12 @@
+//go:build !unix
+
+// mmap_other.go: platforms without mmap support read the file instead.
+
+package ahocorasick
+
+import "os"
+
+// mapFile reports that no mapping is available, Open then reads the file
+func mapFile(*os.File) ([]byte, func() error, error) {
+	return nil, nil, nil
+}
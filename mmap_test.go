@@ -0,0 +1,46 @@
+// mmap_test.go: tests for opening saved matchers from files
+
+package ahocorasick
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// saveFile saves m to a temporary file and returns its path
+func saveFile(t *testing.T, m *Matcher, opts ...SaveOption) string {
+	path := filepath.Join(t.TempDir(), "matcher.act")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(f, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	for _, opts := range [][]SaveOption{nil, {WithCompression(CompressionGzip)}} {
+		m, err := Open(saveFile(t, precomputed6, opts...))
+		assert(t, err == nil)
+		assert(t, len(m.Match(bytes2)) == 105)
+		assert(t, m.Close() == nil)
+		assert(t, m.Close() == nil)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	assert(t, os.WriteFile(empty, nil, 0o644) == nil)
+	_, err := Open(empty)
+	assert(t, err == ErrInvalidFormat)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing"))
+	assert(t, os.IsNotExist(err))
+
+	// matchers that were not opened from a file have nothing to release
+	assert(t, precomputed.Close() == nil)
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+// mmap_unix.go: read-only file mappings on unix systems.
+
+package ahocorasick
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the whole file read-only and returns the mapping together
+// with the function releasing it, an empty file yields no mapping
+func mapFile(f *os.File) ([]byte, func() error, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := fi.Size()
+	if size == 0 || int64(int(size)) != size {
+		return nil, nil, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
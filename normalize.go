@@ -0,0 +1,128 @@
+// normalize.go: rewriting patterns and input rune by rune before matching.
+//
+// Normalization makes different spellings of the same text match each other,
+// for instance by stripping diacritics. Every stage of the pipeline rewrites
+// one rune into zero or more runes; patterns are normalized once when the
+// matcher is built and the input is normalized while it is scanned. The
+// runes produced from an input rune are attributed to that rune, so the
+// offsets reported by FindAll always refer to the original input.
+//
+// The built-in normalizations are options of their own; anything else, such
+// as transliteration or stemming, plugs in through the Normalizer interface.
+
+package ahocorasick
+
+import "strings"
+
+// Normalizer rewrites patterns and input before they are matched, it is
+// applied symmetrically: once to every pattern when the matcher is built and
+// to the input while it is scanned
+type Normalizer interface {
+	// Normalize appends the replacement of r, zero or more runes, to dst and
+	// returns the extended slice; prev is the last rune this normalizer
+	// produced so far, -1 at the start of a text, which allows collapsing
+	// runs; it must be safe for concurrent use
+	Normalize(dst []rune, prev, r rune) []rune
+}
+
+// NormalizerFunc adapts a function to the Normalizer interface
+type NormalizerFunc func(dst []rune, prev, r rune) []rune
+
+// Normalize calls f(dst, prev, r)
+func (f NormalizerFunc) Normalize(dst []rune, prev, r rune) []rune {
+	return f(dst, prev, r)
+}
+
+// WithNormalizer adds n to the normalization pipeline, after the
+// normalizations given before it
+func WithNormalizer(n Normalizer) Option {
+	return func(o *options) {
+		o.addNormalizer("func", n.Normalize)
+	}
+}
+
+// MapRune adapts a mapping of single runes to the Normalizer interface,
+// for instance to try a custom folding on the input with
+// MatchOptions.Transform
+func MapRune(f func(rune) rune) Normalizer {
+	return NormalizerFunc(func(dst []rune, _, r rune) []rune {
+		return append(dst, f(r))
+	})
+}
+
+// normalizer is a stage of a normalization pipeline: it appends the
+// replacement of r to dst and returns the extended slice; prev is the last
+// rune the stage produced so far, -1 at the start of the text
+type normalizer func(dst []rune, prev, r rune) []rune
+
+// pipeline is a sequence of normalization stages applied in order
+type pipeline []normalizer
+
+// normScanner applies a pipeline to a stream of runes
+type normScanner struct {
+	stages pipeline
+	prev   []rune // last rune produced by every stage
+	cur    []rune // runes produced for the last input rune
+	tmp    []rune
+}
+
+// scanner returns a scanner at the start of a text
+func (p pipeline) scanner() *normScanner {
+	s := &normScanner{stages: p, prev: make([]rune, len(p))}
+	for k := range s.prev {
+		s.prev[k] = -1
+	}
+	return s
+}
+
+// next returns the runes the pipeline produces for r, the slice is only
+// valid until the next call
+func (s *normScanner) next(r rune) []rune {
+	in := append(s.cur[:0], r)
+	out := s.tmp
+	for k, stage := range s.stages {
+		out = out[:0]
+		for _, c := range in {
+			n := len(out)
+			out = stage(out, s.prev[k], c)
+			if len(out) > n {
+				s.prev[k] = out[len(out)-1]
+			}
+		}
+		in, out = out, in
+	}
+	s.cur, s.tmp = in, out
+	return in
+}
+
+// apply returns the normalized form of s
+func (p pipeline) apply(s string) string {
+	var b strings.Builder
+	sc := p.scanner()
+	for _, r := range s {
+		for _, c := range sc.next(r) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// transformed reports whether the input is rewritten before it reaches the
+// automaton
+func (m *Matcher) transformed() bool {
+	return m.fold != nil || m.norm != nil
+}
+
+// generic reports whether all matching goes through find, because the input
+// is transformed, patterns are reported under other IDs or occurrences are
+// validated or positioned
+func (m *Matcher) generic() bool {
+	return m.transformed() || m.ids != nil || m.validators != nil || m.positions != nil
+}
+
+// addNormalizer appends a stage to the pipeline of the options, kind
+// identifying what it does for Fingerprint
+func (o *options) addNormalizer(kind string, n normalizer) {
+	o.normalizers = append(o.normalizers, n)
+	o.normalizerKinds = append(o.normalizerKinds, kind)
+}
@@ -0,0 +1,54 @@
+// normalize_test.go: tests for the normalization pipeline
+
+package ahocorasick
+
+import (
+	"testing"
+	"unicode"
+)
+
+// leet undoes common digit-for-letter substitutions
+var leet = NormalizerFunc(func(dst []rune, _, r rune) []rune {
+	switch r {
+	case '0':
+		r = 'o'
+	case '1':
+		r = 'i'
+	case '3':
+		r = 'e'
+	case '@':
+		r = 'a'
+	}
+	return append(dst, r)
+})
+
+// dropPunct removes punctuation
+type dropPunct struct{}
+
+func (dropPunct) Normalize(dst []rune, _, r rune) []rune {
+	if unicode.IsPunct(r) {
+		return dst
+	}
+	return append(dst, r)
+}
+
+func TestNormalizer(t *testing.T) {
+	m := NewStringMatcher([]string{"noise"}, WithNormalizer(leet), WithNormalizer(dropPunct{}))
+	text := "what a n.0.1.s.3!"
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 1)
+	assert(t, text[matches[0].Start:matches[0].End] == "n.0.1.s.3!")
+
+	// stages run in order and compose with the built-in ones
+	m = NewStringMatcher([]string{"cafe"}, WithDiacriticFolding(), WithNormalizer(leet))
+	assert(t, m.ContainsString("c@fé"))
+	assert(t, len(m.MatchThreadSafeString("c@fé c@fe")) == 1)
+}
+
+func TestPipeline(t *testing.T) {
+	// a stage expanding runes feeds every rune to the next stage
+	double := func(dst []rune, _, r rune) []rune { return append(dst, r, r) }
+	p := pipeline{double, collapseWhitespace, double}
+	assert(t, p.apply("a  b") == "aaaa  bbbb")
+	assert(t, p.apply("") == "")
+}
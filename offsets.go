@@ -0,0 +1,93 @@
+// offsets.go: mapping positions of the transformed input back to the
+// original text.
+
+package ahocorasick
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// OffsetMap maps byte offsets of a transformed text back to the text it was
+// produced from, following the rule find uses for the occurrences it
+// reports: the runes produced for an input rune span the bytes of that
+// rune and of the following input runes that produce nothing, such as
+// dropped combining marks
+type OffsetMap struct {
+	offsets []int // offset in the transformed text of every produced rune
+	starts  []int // offset in the original text of its input rune
+	ends    []int // offset in the original text where its span ends
+	length  int   // length of the transformed text
+	source  int   // length of the original text
+}
+
+// Transform returns text as the automaton sees it, normalized and case
+// folded if the matcher does so, together with the map from its offsets to
+// the offsets of text; it is the identity for a matcher without transforms
+func (m *Matcher) Transform(text string) (string, *OffsetMap) {
+	om := &OffsetMap{source: len(text)}
+	var norm *normScanner
+	if m.norm != nil {
+		norm = m.norm.scanner()
+	}
+	b := make([]byte, 0, len(text))
+	var single [1]rune
+	for i := 0; i < len(text); {
+		r, size := decodeRune(text, i)
+		start := i
+		i += size
+		runes := single[:]
+		if norm != nil {
+			runes = norm.next(r)
+		} else {
+			single[0] = r
+		}
+		if len(runes) == 0 {
+			continue
+		}
+		// the previous input rune ends where this one starts
+		for k := len(om.ends) - 1; k >= 0 && om.ends[k] < 0; k-- {
+			om.ends[k] = start
+		}
+		for _, r := range runes {
+			if m.fold != nil {
+				r = foldRune(r)
+			}
+			om.offsets = append(om.offsets, len(b))
+			om.starts = append(om.starts, start)
+			om.ends = append(om.ends, -1)
+			b = utf8.AppendRune(b, r)
+		}
+	}
+	for k := len(om.ends) - 1; k >= 0 && om.ends[k] < 0; k-- {
+		om.ends[k] = len(text)
+	}
+	om.length = len(b)
+	return string(b), om
+}
+
+// Original returns the span of the original text that produced the span
+// [start, end) of the transformed text; offsets inside a rune are moved to
+// the start of that rune
+func (om *OffsetMap) Original(start, end int) (int, int) {
+	if start >= end {
+		p := om.point(start)
+		return p, p
+	}
+	first := om.rune(start)
+	last := om.rune(end - 1)
+	return om.starts[first], om.ends[last]
+}
+
+// point maps an offset between two runes of the transformed text
+func (om *OffsetMap) point(off int) int {
+	if off >= om.length {
+		return om.source
+	}
+	return om.starts[om.rune(off)]
+}
+
+// rune returns the index of the produced rune containing offset off
+func (om *OffsetMap) rune(off int) int {
+	return max(sort.SearchInts(om.offsets, off+1)-1, 0)
+}
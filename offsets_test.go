@@ -0,0 +1,65 @@
+// offsets_test.go: tests for Transform and OffsetMap
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	words := []string{"cafe", "deja  vu"}
+	m := NewStringMatcher(words, WithCaseInsensitive(0, 1), WithDiacriticFolding())
+	text := "CAFÉ or déjà  vu?"
+	out, om := m.Transform(text)
+	assert(t, out == "cafe or deja  vu?")
+
+	start, end := om.Original(0, 4)
+	assert(t, text[start:end] == "CAFÉ")
+	i := strings.Index(out, "deja")
+	start, end = om.Original(i, i+4)
+	assert(t, text[start:end] == "déjà")
+	start, end = om.Original(len(out), len(out))
+	assert(t, start == len(text) && end == len(text))
+
+	// the occurrences reported by find agree with the map
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 2)
+	for _, o := range matches {
+		w := strings.Index(out, words[o.Pattern])
+		start, end := om.Original(w, w+len(words[o.Pattern]))
+		assert(t, start == o.Start && end == o.End)
+	}
+}
+
+func TestTransformIdentity(t *testing.T) {
+	out, om := precomputed.Transform(sbytes)
+	assert(t, out == sbytes)
+	start, end := om.Original(13, 22)
+	assert(t, start == 13 && end == 22)
+
+	out, om = precomputed.Transform("")
+	assert(t, out == "")
+	start, end = om.Original(0, 0)
+	assert(t, start == 0 && end == 0)
+}
+
+func TestTransformMultipleRunes(t *testing.T) {
+	// one input rune producing several runes maps back as a whole
+	m := NewStringMatcher([]string{"ae"}, WithNormalizer(NormalizerFunc(func(dst []rune, prev, r rune) []rune {
+		if r == 'æ' {
+			return append(dst, 'a', 'e')
+		}
+		return append(dst, r)
+	})))
+	text := "xæy"
+	out, om := m.Transform(text)
+	assert(t, out == "xaey")
+	start, end := om.Original(2, 3)
+	assert(t, text[start:end] == "æ")
+	start, end = om.Original(1, 4)
+	assert(t, text[start:end] == "æy")
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 1)
+	assert(t, matches[0].Start == 1 && matches[0].End == 3)
+}
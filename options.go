@@ -0,0 +1,68 @@
+// options.go: build-time options of a Matcher.
+
+package ahocorasick
+
+import "context"
+
+// Option configures how a Matcher is built, options are passed to the
+// constructors after the dictionary
+type Option func(*options)
+
+// options holds the build-time configuration of a Matcher
+type options struct {
+	transitionCache int          // memoized transitions per state, 0 disables the cache
+	backend         Backend      // index of the transitions used while matching
+	minimize        bool         // merge equivalent states once built
+	lazyLinks       bool         // compute fail and suffix links on first use
+	hitCounters     bool         // count the hits of every pattern
+	bloomFilter     bool         // screen inputs with a bloom filter of q-grams
+	caseInsensitive []int        // patterns matched regardless of case
+	normalizers     []normalizer // stages rewriting patterns and input
+	normalizerKinds []string     // what every stage does, see addNormalizer
+	overlap         OverlapPolicy
+	categories      map[string][]int // named groups of patterns
+	info            []PatternInfo    // metadata of the patterns
+	validators      []patternValidator
+	positions       []patternPosition
+	equivalence     []string          // classes of runes matching each other
+	fileCategories  map[string]string // category of every dictionary file, see NewMatcherFromFS
+	buildStats      bool              // record BuildStats
+	progress        func(BuildProgress)
+	ctx             context.Context // aborts the build when done, see CompileContext
+
+	// safety limits checked before building, 0 means unlimited
+	maxPatterns      int
+	maxPatternLength int
+	maxTotalRunes    int
+	maxMemory        int // bytes of the automaton, see WithMaxMemory
+}
+
+// newOptions applies opts over the default configuration
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTransitionCache makes the matcher memoize, per state, up to perState
+// transitions that had to be resolved by walking the fail chain at match
+// time, so repeated inputs with the same miss patterns stop re-walking it
+// useful for long-lived matchers on skewed traffic, the cache is safe for
+// concurrent use and stops growing once a state's memo is full
+func WithTransitionCache(perState int) Option {
+	return func(o *options) {
+		o.transitionCache = perState
+	}
+}
+
+// WithLazyLinks defers the computation of fail and suffix links to the first
+// time a state is visited during matching, like a lazy DFA
+// this cuts build time for enormous dictionaries where only a fraction of
+// the states are ever reached, at the cost of a slightly slower first visit
+func WithLazyLinks() Option {
+	return func(o *options) {
+		o.lazyLinks = true
+	}
+}
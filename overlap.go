@@ -0,0 +1,184 @@
+// overlap.go: resolution of overlapping occurrences.
+
+package ahocorasick
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// OverlapPolicy decides which occurrences are reported when they overlap
+type OverlapPolicy int
+
+const (
+	// ReportAll reports every occurrence, overlapping or not
+	ReportAll OverlapPolicy = iota
+
+	// LongestWins keeps the leftmost occurrence, the longest one when
+	// several start at the same offset, then repeats after its end
+	LongestWins
+
+	// EarliestWins keeps the occurrence completed first while scanning, the
+	// longest one when several end at the same offset, then repeats after
+	// its end
+	EarliestWins
+
+	// PriorityByIndex keeps occurrences in order of their pattern index,
+	// dropping every occurrence overlapping one already kept, so earlier
+	// dictionary entries take precedence
+	PriorityByIndex
+)
+
+// WithOverlapPolicy makes the matcher resolve overlapping occurrences with p
+// in every call reporting them: the Match family reports the patterns of
+// the occurrences that are kept, FindAll and MatchParallel the kept
+// occurrences and ReplaceAll replaces them; Contains, MatchFirst and
+// FindLongest are not affected, and the policy is not saved by Save
+func WithOverlapPolicy(p OverlapPolicy) Option {
+	return func(o *options) {
+		o.overlap = p
+	}
+}
+
+// resolveOverlaps applies the overlap policy of m to matches in FindAll
+// order, the result is non-overlapping and ordered by start offset unless
+// the policy is ReportAll
+func (m *Matcher) resolveOverlaps(matches []Match) []Match {
+	return resolveOverlaps(matches, m.overlap)
+}
+
+// resolveOverlaps applies policy to matches in FindAll order
+func resolveOverlaps(matches []Match, policy OverlapPolicy) []Match {
+	if policy == ReportAll || len(matches) < 2 {
+		return matches
+	}
+	kept := matches[:0:0]
+	switch policy {
+	case EarliestWins:
+		// FindAll order already is by end, longest first
+		for _, o := range matches {
+			if len(kept) == 0 || o.Start >= kept[len(kept)-1].End {
+				kept = append(kept, o)
+			}
+		}
+	case LongestWins:
+		sorted := append([]Match(nil), matches...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].Start != sorted[j].Start {
+				return sorted[i].Start < sorted[j].Start
+			}
+			return sorted[i].End > sorted[j].End
+		})
+		for _, o := range sorted {
+			if len(kept) == 0 || o.Start >= kept[len(kept)-1].End {
+				kept = append(kept, o)
+			}
+		}
+	case PriorityByIndex:
+		sorted := append([]Match(nil), matches...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pattern < sorted[j].Pattern })
+		for _, o := range sorted {
+			// kept is ordered by start, the neighbors decide about overlaps
+			i := sort.Search(len(kept), func(i int) bool { return kept[i].Start >= o.Start })
+			if i > 0 && kept[i-1].End > o.Start {
+				continue
+			}
+			if i < len(kept) && o.End > kept[i].Start {
+				continue
+			}
+			if i < len(kept) && kept[i].Start == o.Start {
+				continue // an empty occurrence at the same offset
+			}
+			kept = append(kept, Match{})
+			copy(kept[i+1:], kept[i:])
+			kept[i] = o
+		}
+	}
+	return kept
+}
+
+// Span is a range of byte offsets in a text
+type Span struct {
+	Start int // offset of the first byte
+	End   int // offset just after the last byte
+}
+
+// MergeSpans coalesces the ranges covered by matches, in any order, into
+// disjoint spans sorted by offset; overlapping and adjacent ranges are
+// merged, which is what masking and highlighting need before rendering
+func MergeSpans(matches []Match) []Span {
+	if len(matches) == 0 {
+		return nil
+	}
+	spans := make([]Span, len(matches))
+	for i, o := range matches {
+		spans[i] = Span{Start: o.Start, End: o.End}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	merged := spans[:1]
+	for _, sp := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if sp.Start <= last.End {
+			last.End = max(last.End, sp.End)
+			continue
+		}
+		merged = append(merged, sp)
+	}
+	return merged
+}
+
+// Coverage returns the merged spans of all occurrences in text together
+// with the fraction of the runes of text they cover, a common spam signal
+// the ratio is 0 for an empty text
+func (m *Matcher) Coverage(text []byte) (spans []Span, ratio float64) {
+	return m.CoverageString(bytesToString(text))
+}
+
+// CoverageString is like Coverage for a string input
+func (m *Matcher) CoverageString(text string) (spans []Span, ratio float64) {
+	spans = MergeSpans(m.collect(text))
+	total := utf8.RuneCountInString(text)
+	if total == 0 {
+		return spans, 0
+	}
+	covered := 0
+	for _, sp := range spans {
+		covered += utf8.RuneCountInString(text[sp.Start:sp.End])
+	}
+	return spans, float64(covered) / float64(total)
+}
+
+// ReplaceAll returns a copy of text where the occurrences kept by the overlap
+// policy are replaced by the result of replace; with ReportAll, which cannot
+// replace overlapping occurrences, LongestWins is used instead
+func (m *Matcher) ReplaceAll(text []byte, replace func(Match) string) []byte {
+	return []byte(m.ReplaceAllString(string(text), replace))
+}
+
+// ReplaceAllString is like ReplaceAll for a string input
+func (m *Matcher) ReplaceAllString(text string, replace func(Match) string) string {
+	matches := m.FindAllString(text)
+	if m.overlap == ReportAll {
+		matches = resolveOverlaps(matches, LongestWins)
+	}
+	var b strings.Builder
+	last := 0
+	for _, o := range matches {
+		b.WriteString(text[last:o.Start])
+		b.WriteString(replace(o))
+		last = o.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// countHits records the occurrences reported to the caller
+func (m *Matcher) countHits(matches []Match) {
+	if m.hits == nil {
+		return
+	}
+	for _, o := range matches {
+		m.hits.add(int32(o.Pattern))
+	}
+}
@@ -0,0 +1,102 @@
+// overlap_test.go: tests for overlap resolution
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOverlapPolicy(t *testing.T) {
+	dict := []string{"he", "she", "hers", "ers", "s"}
+	text := "ushers"
+
+	all := NewStringMatcher(dict).FindAllString(text)
+	assert(t, len(all) == 6)
+
+	m := NewStringMatcher(dict, WithOverlapPolicy(LongestWins))
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 2)
+	assert(t, matches[0] == Match{Pattern: 1, Start: 1, End: 4}) // she
+	assert(t, matches[1] == Match{Pattern: 4, Start: 5, End: 6}) // s
+
+	m = NewStringMatcher(dict, WithOverlapPolicy(EarliestWins))
+	matches = m.FindAllString(text)
+	assert(t, len(matches) == 3)
+	assert(t, matches[0] == Match{Pattern: 4, Start: 1, End: 2}) // s, completed first
+	assert(t, matches[1] == Match{Pattern: 0, Start: 2, End: 4}) // he
+	assert(t, matches[2] == Match{Pattern: 4, Start: 5, End: 6})
+
+	m = NewStringMatcher(dict[:4], WithOverlapPolicy(PriorityByIndex))
+	matches = m.FindAllString(text)
+	assert(t, len(matches) == 1)
+	assert(t, matches[0] == Match{Pattern: 0, Start: 2, End: 4}) // he beats she and hers
+
+	// the policy applies to every call reporting occurrences
+	m = NewStringMatcher(dict, WithOverlapPolicy(PriorityByIndex))
+	hits := m.MatchString(text)
+	assert(t, len(hits) == 2)
+	assert(t, hits[0] == 4 && hits[1] == 0)
+	assert(t, m.MatchThreadSafeString(text)[1] == 0)
+	assert(t, len(m.MatchScratchString(m.NewScratch(), text)) == 2)
+	assert(t, m.MatchSetString(text).Len() == 2)
+	var c Columns
+	m.FindAllColumnsString(&c, text)
+	assert(t, c.Len() == 3 && c.Patterns[1] == 0)
+	assert(t, m.ReplaceAllString(text, func(Match) string { return "_" }) == "u__r_")
+}
+
+func TestReplaceAll(t *testing.T) {
+	m := NewStringMatcher([]string{"Mac", "Macintosh", "Safari"})
+	upper := func(o Match) string { return strings.ToUpper(sbytes[o.Start:o.End]) }
+	replaced := string(m.ReplaceAll(bytes, upper))
+	assert(t, strings.Contains(replaced, "MACINTOSH; Intel MAC OS X"))
+	assert(t, strings.Contains(replaced, "SAFARI/537.36"))
+	assert(t, m.ReplaceAllString("no match", upper) == "no match")
+}
+
+func TestResolveOverlapsParallel(t *testing.T) {
+	text := strings.Repeat(string(bytes2), 40)
+	m := NewStringMatcher(dictionary6, WithOverlapPolicy(LongestWins))
+	want := m.FindAllString(text)
+	got := m.MatchParallelString(text, 4)
+	assert(t, len(got) == len(want))
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+}
+
+func TestMergeSpans(t *testing.T) {
+	spans := MergeSpans([]Match{
+		{Pattern: 0, Start: 5, End: 8},
+		{Pattern: 1, Start: 2, End: 10},
+		{Pattern: 2, Start: 10, End: 12}, // adjacent
+		{Pattern: 3, Start: 14, End: 15},
+		{Pattern: 4, Start: 14, End: 14},
+	})
+	assert(t, len(spans) == 2)
+	assert(t, spans[0] == Span{Start: 2, End: 12})
+	assert(t, spans[1] == Span{Start: 14, End: 15})
+	assert(t, MergeSpans(nil) == nil)
+
+	// spans of FindAll cover exactly the matched text
+	m := NewStringMatcher([]string{"Mac", "Macintosh", "tosh; ", "Intel Mac OS"})
+	spans = MergeSpans(m.FindAll(bytes))
+	assert(t, len(spans) == 1)
+	assert(t, sbytes[spans[0].Start:spans[0].End] == "Macintosh; Intel Mac OS")
+}
+
+func TestCoverage(t *testing.T) {
+	m := NewStringMatcher([]string{"buy", "cheap", "ea", "中文"})
+	spans, ratio := m.CoverageString("buy cheap 中文!")
+	assert(t, len(spans) == 3)
+	assert(t, spans[1] == Span{Start: 4, End: 9})
+	assert(t, ratio == 10.0/13)
+
+	spans, ratio = m.Coverage([]byte("nothing here"))
+	assert(t, spans == nil && ratio == 0)
+	_, ratio = m.CoverageString("")
+	assert(t, ratio == 0)
+	_, ratio = m.CoverageString("buycheap")
+	assert(t, ratio == 1)
+}
@@ -0,0 +1,100 @@
+// parallel.go: matching a single huge text with several goroutines.
+
+package ahocorasick
+
+import (
+	"runtime"
+	"sync"
+	"unicode/utf8"
+)
+
+// minParallelChunk is the smallest chunk of text worth a goroutine of its own
+const minParallelChunk = 64 << 10
+
+// MatchParallel reports every occurrence of every pattern in text like
+// FindAll, splitting the text into chunks scanned by up to workers goroutines
+// (GOMAXPROCS when workers <= 0), for multi-gigabyte inputs on many-core
+// machines
+// every chunk is scanned from an overlap as long as the longest pattern
+// before its start, so occurrences crossing chunk boundaries are found, and
+// each occurrence is reported by the chunk it ends in only; the result is
+// identical to FindAll
+func (m *Matcher) MatchParallel(text []byte, workers int) []Match {
+	return m.MatchParallelString(bytesToString(text), workers)
+}
+
+// MatchParallelString is like MatchParallel for a string input
+func (m *Matcher) MatchParallelString(text string, workers int) []Match {
+	m.depthOnce.Do(m.computeDepths)
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// an occurrence spans at most utf8.UTFMax bytes per rune
+	overlap := m.maxDepth * utf8.UTFMax
+	chunk := max(len(text)/max(workers, 1)+1, minParallelChunk, 2*overlap)
+	// normalization decouples pattern length from input length, so no overlap
+	// is known to be enough, validators may look past a chunk and positions
+	// are resolved against the whole text
+	if workers <= 1 || len(text) <= chunk || m.norm != nil || m.validators != nil || m.positions != nil {
+		return m.FindAllString(text)
+	}
+
+	// chunk boundaries are moved to rune starts, so every chunk decodes its
+	// runes exactly like a scan of the whole text
+	var bounds []int
+	for b := 0; b < len(text); b += chunk {
+		bounds = append(bounds, runeStart(text, b))
+	}
+	bounds = append(bounds, len(text))
+
+	results := make([][]Match, len(bounds)-1)
+	wg := sync.WaitGroup{}
+	for k := range results {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			lo, hi := bounds[k], bounds[k+1]
+			from := runeStart(text, max(lo-overlap, 0))
+			m.find(text[from:hi], func(pattern int32, start, end int) bool {
+				if from+end > lo {
+					results[k] = append(results[k], Match{Pattern: int(pattern), Start: from + start, End: from + end})
+				}
+				return true
+			})
+		}(k)
+	}
+	wg.Wait()
+	return m.mergeChunks(results)
+}
+
+// mergeChunks concatenates the occurrences found in consecutive chunks, then
+// applies the overlap policy and counts the hits once, like FindAll
+func (m *Matcher) mergeChunks(results [][]Match) []Match {
+	n := 0
+	for _, r := range results {
+		n += len(r)
+	}
+	matches := make([]Match, 0, n)
+	for _, r := range results {
+		matches = append(matches, r...)
+	}
+	matches = m.resolveOverlaps(matches)
+	m.countHits(matches)
+	return matches
+}
+
+// runeStart returns the offset of the rune containing byte p when text is
+// decoded from its beginning like a range loop does, invalid bytes included
+// a byte that is not a continuation byte always starts a rune, so only the
+// few bytes before p need to be looked at
+func runeStart(text string, p int) int {
+	for q := p; q >= 0 && q > p-utf8.UTFMax; q-- {
+		if q < len(text) && utf8.RuneStart(text[q]) {
+			if _, size := utf8.DecodeRuneInString(text[q:]); q+size > p {
+				return q
+			}
+			return p // the bytes after the rune at q are decoded one by one
+		}
+	}
+	return p
+}
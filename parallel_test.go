@@ -0,0 +1,48 @@
+// parallel_test.go: tests for matching a text with several goroutines
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchParallel(t *testing.T) {
+	// chunks are large, so a long text with invalid and multi-byte runes
+	// makes sure boundaries fall inside runes and occurrences
+	text := strings.Repeat(string(bytes2)+"中文\xe4\xb8 Mac\xff", 300)
+	assert(t, len(text) > 4*minParallelChunk)
+	dict := append([]string{"中文", "� M", "c�"}, dictionary6...)
+	m := NewStringMatcher(dict, WithHitCounters())
+
+	want := m.FindAllString(text)
+	assert(t, len(want) > 0)
+	m.Hits().Reset()
+	for _, workers := range []int{0, 1, 3, 8} {
+		got := m.MatchParallel([]byte(text), workers)
+		assert(t, len(got) == len(want))
+		for i := range got {
+			assert(t, got[i] == want[i])
+		}
+	}
+
+	// occurrences in the overlaps are counted once
+	total := uint64(0)
+	for _, c := range m.Hits().Snapshot() {
+		total += c
+	}
+	assert(t, total == 4*uint64(len(want)))
+
+	assert(t, len(m.MatchParallelString("", 4)) == 0)
+}
+
+func TestRuneStart(t *testing.T) {
+	text := "a中\xe4\xb8b\x80\x80\x80\x80"
+	assert(t, runeStart(text, 0) == 0)
+	assert(t, runeStart(text, 2) == 1)
+	assert(t, runeStart(text, 3) == 1)
+	assert(t, runeStart(text, 5) == 5) // truncated sequence, decoded byte by byte
+	assert(t, runeStart(text, 7) == 7) // stray continuation bytes
+	assert(t, runeStart(text, 10) == 10)
+	assert(t, runeStart(text, len(text)) == len(text))
+}
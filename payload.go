@@ -0,0 +1,10 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// payload.go: the type of the JSON payload of PatternInfo.
+
+package ahocorasick
+
+import "encoding/json"
+
+// jsonPayload is json.RawMessage, so payloads marshal as the JSON they hold
+type jsonPayload = json.RawMessage
@@ -0,0 +1,9 @@
+//go:build tinygo || ahocorasick_tiny
+
+// payload_tiny.go: the type of the JSON payload of PatternInfo without
+// encoding/json.
+
+package ahocorasick
+
+// jsonPayload holds the JSON text of a payload
+type jsonPayload = []byte
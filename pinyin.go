@@ -0,0 +1,36 @@
+// pinyin.go: matching Chinese text by pronunciation.
+
+package ahocorasick
+
+import "unicode"
+
+// pinyinSeparator ends every syllable produced by WithPinyin, so that
+// syllable boundaries stay unambiguous ("xi'an" is not "xian")
+const pinyinSeparator = '\''
+
+// WithPinyin matches Chinese patterns by pronunciation: Han characters of the
+// patterns and of the input are replaced by their pinyin, so homophone
+// substitutions used to evade filters (the 草泥马 kind) match as well as the
+// original characters
+// pinyin returns the toneless, lowercase pinyin of a Han character, or ""
+// when it has none, e.g. a lookup into CC-CEDICT or a pinyin library; the
+// package ships no table to stay free of dependencies
+// characters with several readings should be mapped to the reading used by
+// the dictionary, matching is exact on the syllables produced
+func WithPinyin(pinyin func(r rune) string) Option {
+	return func(o *options) {
+		o.addNormalizer("pinyin", func(dst []rune, _, r rune) []rune {
+			if !unicode.Is(unicode.Han, r) {
+				return append(dst, r)
+			}
+			syllable := pinyin(r)
+			if syllable == "" {
+				return append(dst, r)
+			}
+			for _, c := range syllable {
+				dst = append(dst, c)
+			}
+			return append(dst, pinyinSeparator)
+		})
+	}
+}
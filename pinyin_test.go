@@ -0,0 +1,32 @@
+// pinyin_test.go: tests for matching by pronunciation
+
+package ahocorasick
+
+import "testing"
+
+// testPinyin is a tiny pinyin table for the tests
+func testPinyin(r rune) string {
+	return map[rune]string{
+		'草': "cao", '操': "cao", '泥': "ni", '你': "ni", '马': "ma", '妈': "ma",
+		'西': "xi", '安': "an", '先': "xian",
+	}[r]
+}
+
+func TestPinyin(t *testing.T) {
+	m := NewStringMatcher([]string{"操你妈", "西安"}, WithPinyin(testPinyin))
+
+	text := "他说草泥马,又说操你妈"
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 2)
+	assert(t, text[matches[0].Start:matches[0].End] == "草泥马")
+	assert(t, text[matches[1].Start:matches[1].End] == "操你妈")
+
+	// syllable boundaries are kept
+	assert(t, m.ContainsString("西安"))
+	assert(t, !m.ContainsString("先"))
+
+	// characters without a reading are matched as they are
+	m = NewStringMatcher([]string{"中马"}, WithPinyin(testPinyin))
+	assert(t, m.ContainsString("中妈"))
+	assert(t, !m.ContainsString("钟妈"))
+}
@@ -0,0 +1,363 @@
+// policy.go: declarative screening policies, rules with actions loaded from
+// a JSON configuration and evaluated into a structured decision.
+//
+// A policy lists the words of every category and the rules, each rule having
+// a condition written in a small expression language and a list of actions:
+//
+//	{
+//	  "categories": {"spam": ["buy", "cheap"], "drugs": ["pills"]},
+//	  "rules": [
+//	    {"name": "pharma", "when": "category(drugs) and category(spam)", "actions": ["block"]},
+//	    {"name": "spammy", "when": "category(spam, 2) or word(\"act now\")", "actions": ["flag", "score+=5"]},
+//	    {"name": "hide", "when": "category(drugs)", "actions": ["mask"]}
+//	  ]
+//	}
+//
+// Conditions combine word("text"), category(name), category(name, n) and
+// atleast(n, expr, ...) with and, or, not and parentheses. Actions are
+// block, flag, mask and score+=n (or score-=n).
+
+package ahocorasick
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ActionKind is the effect of an action on a Decision
+type ActionKind int
+
+const (
+	ActionBlock ActionKind = iota + 1 // reject the text
+	ActionFlag                        // report the rule in Decision.Flags
+	ActionMask                        // mask the occurrences of the rule's patterns
+	ActionScore                       // add Score to Decision.Score
+)
+
+// Action is what a rule does when it fires
+type Action struct {
+	Kind  ActionKind
+	Score int // amount added to the decision score by ActionScore
+}
+
+// ParseAction parses block, flag, mask, score+=n or score-=n
+func ParseAction(s string) (Action, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "block":
+		return Action{Kind: ActionBlock}, nil
+	case "flag":
+		return Action{Kind: ActionFlag}, nil
+	case "mask":
+		return Action{Kind: ActionMask}, nil
+	}
+	for _, op := range []string{"+=", "-="} {
+		if v, ok := strings.CutPrefix(s, "score"+op); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return Action{}, fmt.Errorf("ahocorasick: invalid score in action %q", s)
+			}
+			if op == "-=" {
+				n = -n
+			}
+			return Action{Kind: ActionScore, Score: n}, nil
+		}
+	}
+	return Action{}, fmt.Errorf("ahocorasick: unknown action %q", s)
+}
+
+// Decision is the outcome of applying the actions of every fired rule
+type Decision struct {
+	Fired  []string // names of the rules that fired, in rule order
+	Block  bool     // whether a fired rule blocks the text
+	Flags  []string // names of the fired rules with a flag action
+	Score  int      // sum of the score actions of the fired rules
+	Masked string   // the text with the patterns of fired mask rules replaced by '*'
+}
+
+// Decide scans text once, evaluates every rule and applies the actions of
+// the rules that fired
+// it is safe for concurrent use
+func (rs *RuleSet) Decide(text []byte) Decision {
+	return rs.DecideString(bytesToString(text))
+}
+
+// DecideString is like Decide for a string input
+func (rs *RuleSet) DecideString(text string) Decision {
+	matches := rs.matcher.FindAllString(text)
+	var hits PatternSet
+	for _, match := range matches {
+		hits.Add(match.Pattern)
+	}
+
+	d := Decision{Masked: text}
+	env := ruleEnv{hits: &hits, categories: rs.categories}
+	var masked PatternSet
+	for _, r := range rs.rules {
+		if r.When == nil || !r.When.eval(&env) {
+			continue
+		}
+		d.Fired = append(d.Fired, r.Name)
+		for _, a := range r.Actions {
+			switch a.Kind {
+			case ActionBlock:
+				d.Block = true
+			case ActionFlag:
+				d.Flags = append(d.Flags, r.Name)
+			case ActionScore:
+				d.Score += a.Score
+			case ActionMask:
+				r.When.collect(rs.categories, masked.Add)
+			}
+		}
+	}
+	if masked.Len() > 0 {
+		d.Masked = maskMatches(text, matches, &masked)
+	}
+	return d
+}
+
+// maskMatches replaces every rune covered by an occurrence of a pattern of
+// set with '*'
+func maskMatches(text string, matches []Match, set *PatternSet) string {
+	// occurrences come in end order, where a longer one can start before a
+	// shorter one reported earlier, so they are masked in start order
+	selected := matches[:0:0]
+	for _, match := range matches {
+		if set.Has(match.Pattern) {
+			selected = append(selected, match)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Start < selected[j].Start })
+	var b strings.Builder
+	b.Grow(len(text))
+	pos := 0
+	for _, match := range selected {
+		if match.End <= pos {
+			continue
+		}
+		start := max(match.Start, pos)
+		b.WriteString(text[pos:start])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[start:match.End])))
+		pos = match.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// policyParser parses rule conditions, assigning pattern indices to words
+type policyParser struct {
+	words []string
+	index map[string]int
+
+	tokens []string
+	pos    int
+}
+
+// word returns the pattern index of w, adding it to the dictionary if needed
+func (p *policyParser) word(w string) int {
+	i, ok := p.index[w]
+	if !ok {
+		i = len(p.words)
+		p.index[w] = i
+		p.words = append(p.words, w)
+	}
+	return i
+}
+
+// parse parses a complete condition
+func (p *policyParser) parse(s string) (Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p.tokens, p.pos = tokens, 0
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+func (p *policyParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *policyParser) expect(tok string) error {
+	if p.peek() != tok {
+		if p.pos >= len(p.tokens) {
+			return fmt.Errorf("expected %q at end of condition", tok)
+		}
+		return fmt.Errorf("expected %q, found %q", tok, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *policyParser) or() (Expr, error) {
+	e, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []Expr{e}
+	for strings.EqualFold(p.peek(), "or") {
+		p.pos++
+		if e, err = p.and(); err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return Or(exprs...), nil
+}
+
+func (p *policyParser) and() (Expr, error) {
+	e, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []Expr{e}
+	for strings.EqualFold(p.peek(), "and") {
+		p.pos++
+		if e, err = p.unary(); err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return And(exprs...), nil
+}
+
+func (p *policyParser) unary() (Expr, error) {
+	if strings.EqualFold(p.peek(), "not") {
+		p.pos++
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(e), nil
+	}
+	return p.primary()
+}
+
+func (p *policyParser) primary() (Expr, error) {
+	tok := p.peek()
+	p.pos++
+	switch strings.ToLower(tok) {
+	case "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	case "word":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		w, err := strconv.Unquote(p.peek())
+		if err != nil {
+			return nil, fmt.Errorf("word expects a quoted string, found %q", p.peek())
+		}
+		p.pos++
+		return Pattern(p.word(w)), p.expect(")")
+	case "category":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		name := p.peek()
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+		p.pos++
+		n := 1
+		if p.peek() == "," {
+			p.pos++
+			var err error
+			if n, err = p.number(); err != nil {
+				return nil, err
+			}
+		}
+		return CategoryAtLeast(name, n), p.expect(")")
+	case "atleast":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		n, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		var exprs []Expr
+		for p.peek() == "," {
+			p.pos++
+			e, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, e)
+		}
+		return AtLeast(n, exprs...), p.expect(")")
+	case "":
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
+
+func (p *policyParser) number() (int, error) {
+	n, err := strconv.Atoi(p.peek())
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, found %q", p.peek())
+	}
+	p.pos++
+	return n, nil
+}
+
+// tokenize splits a condition into identifiers, numbers, quoted strings and
+// punctuation
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, string(r))
+			i += size
+		case r == '"':
+			q, err := strconv.QuotedPrefix(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, q)
+			i += len(q)
+		case r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if r != '_' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", r, i)
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,24 @@
+// policy_test.go: tests for rule actions and masking
+
+package ahocorasick
+
+import "testing"
+
+func TestParseAction(t *testing.T) {
+	a, err := ParseAction(" score-=3 ")
+	assert(t, err == nil)
+	assert(t, a == Action{Kind: ActionScore, Score: -3})
+	a, err = ParseAction("block")
+	assert(t, err == nil && a.Kind == ActionBlock)
+}
+
+func TestMaskMatches(t *testing.T) {
+	// in end order, "abcd" starting before "bc"
+	matches := []Match{{1, 2, 4}, {0, 1, 5}, {2, 6, 7}}
+	var set PatternSet
+	set.Add(0)
+	set.Add(1)
+	assert(t, maskMatches("xabcdxy", matches, &set) == "x****xy")
+	set.Add(2)
+	assert(t, maskMatches("xabcdxy", matches, &set) == "x****x*")
+}
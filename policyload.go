@@ -0,0 +1,71 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// policyload.go: loading policies from their JSON configuration, see policy.go.
+
+package ahocorasick
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// policyConfig is the JSON form of a policy
+type policyConfig struct {
+	Categories map[string][]string `json:"categories"`
+	Rules      []struct {
+		Name    string   `json:"name"`
+		When    string   `json:"when"`
+		Actions []string `json:"actions"`
+	} `json:"rules"`
+}
+
+// LoadPolicy reads a JSON policy and compiles its words into a matcher with
+// opts, returning the rule set ready for Decide
+func LoadPolicy(r io.Reader, opts ...Option) (*RuleSet, error) {
+	var cfg policyConfig
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("ahocorasick: invalid policy: %w", err)
+	}
+
+	// every distinct word gets one pattern index, categories are processed in
+	// name order so indices are stable
+	p := &policyParser{index: make(map[string]int)}
+	names := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	categories := make(map[string][]int, len(cfg.Categories))
+	for _, name := range names {
+		for _, word := range cfg.Categories[name] {
+			categories[name] = append(categories[name], p.word(word))
+		}
+	}
+
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for _, rc := range cfg.Rules {
+		when, err := p.parse(rc.When)
+		if err != nil {
+			return nil, fmt.Errorf("ahocorasick: rule %q: %w", rc.Name, err)
+		}
+		rule := Rule{Name: rc.Name, When: when}
+		for _, s := range rc.Actions {
+			a, err := ParseAction(s)
+			if err != nil {
+				return nil, fmt.Errorf("ahocorasick: rule %q: %w", rc.Name, err)
+			}
+			rule.Actions = append(rule.Actions, a)
+		}
+		rules = append(rules, rule)
+	}
+
+	m, err := Compile(p.words, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewRuleSet(m, categories, rules...), nil
+}
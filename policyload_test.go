@@ -0,0 +1,65 @@
+//go:build !tinygo && !ahocorasick_tiny
+
+// policyload_test.go: tests for policies loaded from configuration
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPolicy = `{
+  "categories": {"spam": ["buy", "cheap", "now"], "drugs": ["pills", "药"]},
+  "rules": [
+    {"name": "pharma", "when": "category(drugs) and category(spam)", "actions": ["block", "score+=10"]},
+    {"name": "spammy", "when": "category(spam, 2) or word(\"act fast\")", "actions": ["flag", "score+=5"]},
+    {"name": "hide", "when": "category(\"drugs\")", "actions": ["mask"]},
+    {"name": "polite", "when": "not atleast(1, word(\"idiot\"), word(\"stupid\"))", "actions": ["score-=1"]}
+  ]
+}`
+
+func TestPolicy(t *testing.T) {
+	rs, err := LoadPolicy(strings.NewReader(testPolicy))
+	assert(t, err == nil)
+
+	d := rs.DecideString("buy cheap pills and 药 now")
+	assert(t, d.Block)
+	assert(t, d.Score == 14)
+	assert(t, len(d.Fired) == 4)
+	assert(t, len(d.Flags) == 1)
+	assert(t, d.Flags[0] == "spammy")
+	assert(t, d.Masked == "buy cheap ***** and * now")
+
+	d = rs.Decide([]byte("act fast, you idiot"))
+	assert(t, !d.Block)
+	assert(t, d.Score == 5)
+	assert(t, len(d.Fired) == 1)
+	assert(t, d.Masked == "act fast, you idiot")
+}
+
+func TestPolicyMaskOverlap(t *testing.T) {
+	// "abcd" is reported after "bc" but starts before it
+	rs, err := LoadPolicy(strings.NewReader(`{
+  "categories": {"secret": ["bc", "abcd"]},
+  "rules": [{"name": "hide", "when": "category(secret)", "actions": ["mask"]}]
+}`))
+	assert(t, err == nil)
+	assert(t, rs.DecideString("xabcdx").Masked == "x****x")
+	assert(t, rs.DecideString("bc abcd").Masked == "** ****")
+}
+
+func TestPolicyErrors(t *testing.T) {
+	for _, cfg := range []string{
+		`{"rules": [{"name": "x", "when": "word(unquoted)"}]}`,
+		`{"rules": [{"name": "x", "when": "category(a) and"}]}`,
+		`{"rules": [{"name": "x", "when": "(category(a)"}]}`,
+		`{"rules": [{"name": "x", "when": "category(a) @"}]}`,
+		`{"rules": [{"name": "x", "when": "category(a)", "actions": ["explode"]}]}`,
+		`{"rules": [{"name": "x", "when": "category(a)", "actions": ["score+=many"]}]}`,
+		`{"unknown": true}`,
+	} {
+		_, err := LoadPolicy(strings.NewReader(cfg))
+		assert(t, err != nil)
+	}
+}
@@ -0,0 +1,146 @@
+// position.go: constraints on where occurrences lie in the text.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Window is the byte range [Start, End) of a text
+type Window struct {
+	Start, End int
+}
+
+// Position constrains where the occurrences of a pattern lie in the text:
+// within its first First runes, within its last Last runes and within one
+// of Windows, each constraint being left out when zero or empty
+type Position struct {
+	First   int
+	Last    int
+	Windows []Window
+}
+
+// patternPosition is a position and the patterns it applies to
+type patternPosition struct {
+	p        Position
+	patterns []int
+}
+
+// WithPosition makes patterns match only where p allows, such as keywords
+// of a subject line with Position{Windows: []Window{{0, len(subject)}}}
+// the constraints are resolved against every text before it is scanned, and
+// when every pattern has a position only the part of the text they allow is
+// scanned, so Position{First: 100} on all patterns stops after 100 runes
+// the last position given for a pattern applies; positions need the whole
+// text, so FindReader rejects them and a matcher with positions cannot be
+// saved
+func WithPosition(p Position, patterns ...int) Option {
+	return func(o *options) {
+		o.positions = append(o.positions, patternPosition{p: p, patterns: patterns})
+	}
+}
+
+// positions holds the position of every pattern ID
+type positions struct {
+	of  []int32    // index in set of the position of every pattern, -1 for none
+	set []Position // distinct positions
+	all bool       // every pattern has a position, which bounds the scan
+}
+
+// newPositions returns the positions of each of the npatterns patterns
+func newPositions(pps []patternPosition, npatterns int) (*positions, error) {
+	ps := &positions{of: make([]int32, npatterns)}
+	for i := range ps.of {
+		ps.of[i] = -1
+	}
+	for _, pp := range pps {
+		if pp.p.First < 0 || pp.p.Last < 0 {
+			return nil, fmt.Errorf("ahocorasick: negative rune count in position %+v", pp.p)
+		}
+		for _, w := range pp.p.Windows {
+			if w.Start < 0 || w.End < w.Start {
+				return nil, fmt.Errorf("ahocorasick: invalid window %d-%d", w.Start, w.End)
+			}
+		}
+		for _, p := range pp.patterns {
+			if p < 0 || p >= npatterns {
+				return nil, fmt.Errorf("ahocorasick: positioned pattern %d out of range", p)
+			}
+			ps.of[p] = int32(len(ps.set))
+		}
+		ps.set = append(ps.set, pp.p)
+	}
+	ps.all = true
+	for _, k := range ps.of {
+		if k < 0 {
+			ps.all = false
+			break
+		}
+	}
+	return ps, nil
+}
+
+// span is a position resolved against a text: occurrences lie within
+// [lo, hi) and within one of windows, if any
+type span struct {
+	lo, hi  int
+	windows []Window
+}
+
+// resolve returns the span of every position of ps in text, and the byte
+// range [lo, hi) holding all of them
+func (ps *positions) resolve(text string) (spans []span, lo, hi int) {
+	spans = make([]span, len(ps.set))
+	lo, hi = len(text), 0
+	for k, p := range ps.set {
+		s := span{lo: 0, hi: len(text), windows: p.Windows}
+		if p.First > 0 {
+			end := 0
+			for n := 0; n < p.First && end < len(text); n++ {
+				_, size := utf8.DecodeRuneInString(text[end:])
+				end += size
+			}
+			s.hi = end
+		}
+		if p.Last > 0 {
+			start := len(text)
+			for n := 0; n < p.Last && start > 0; n++ {
+				_, size := utf8.DecodeLastRuneInString(text[:start])
+				start -= size
+			}
+			s.lo = start
+		}
+		spans[k] = s
+
+		// the part of the text the span allows
+		from, to := s.lo, s.hi
+		if len(p.Windows) > 0 {
+			wlo, whi := len(text), 0
+			for _, w := range p.Windows {
+				wlo, whi = min(wlo, w.Start), max(whi, w.End)
+			}
+			from, to = max(from, wlo), min(to, whi)
+		}
+		if from < to {
+			lo, hi = min(lo, from), max(hi, min(to, len(text)))
+		}
+	}
+	return spans, lo, hi
+}
+
+// allows reports whether the occurrence text[start:end] lies within s
+func (s *span) allows(start, end int) bool {
+	if start < s.lo || end > s.hi {
+		return false
+	}
+	if len(s.windows) == 0 {
+		return true
+	}
+	for _, w := range s.windows {
+		if w.Start <= start && end <= w.End {
+			return true
+		}
+	}
+	return false
+}
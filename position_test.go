@@ -0,0 +1,64 @@
+// position_test.go: tests for positional constraints
+
+package ahocorasick
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPosition(t *testing.T) {
+	text := "urgent: café offer inside, urgent offer ends"
+	subject := Window{0, len("urgent: café offer")}
+
+	// only "offer" is constrained, the scan covers the whole text
+	m := NewStringMatcher([]string{"urgent", "offer"},
+		WithPosition(Position{Windows: []Window{subject}}, 1))
+	got := m.FindAllString(text)
+	want := []Match{{0, 0, 6}, {1, 14, 19}, {0, 28, 34}}
+	assert(t, len(got) == len(want))
+	for i := range min(len(got), len(want)) {
+		assert(t, got[i] == want[i])
+	}
+
+	// every pattern constrained, the scan stops after the first 12 runes,
+	// "café" counting for 4 of them
+	m = NewStringMatcher([]string{"urgent", "café", "offer"},
+		WithPosition(Position{First: 12}, 0, 1, 2))
+	got = m.FindAllString(text)
+	assert(t, len(got) == 2 && got[1] == Match{1, 8, 13})
+	assert(t, m.ContainsString(text) && !m.ContainsString("..........urgent"))
+
+	// the last runes, with a window narrowing the first ones
+	m = NewStringMatcher([]string{"urgent", "offer", "ends"},
+		WithPosition(Position{Last: 17}, 0, 1, 2),
+		WithPosition(Position{Last: 17, Windows: []Window{{0, 40}}}, 2))
+	got = m.FindAllString(text)
+	want = []Match{{0, 28, 34}, {1, 35, 40}}
+	assert(t, len(got) == len(want))
+	for i := range min(len(got), len(want)) {
+		assert(t, got[i] == want[i])
+	}
+	hits := m.MatchString(text)
+	assert(t, len(hits) == 2 && hits[0] == 0 && hits[1] == 1)
+
+	// windows allowing nothing
+	m = NewStringMatcher([]string{"urgent"}, WithPosition(Position{First: 3}, 0))
+	assert(t, !m.ContainsString(text) && len(m.FindAllString(text)) == 0)
+}
+
+func TestPositionErrors(t *testing.T) {
+	_, err := Compile([]string{"a"}, WithPosition(Position{First: 1}, 1))
+	assert(t, err != nil)
+	_, err = Compile([]string{"a"}, WithPosition(Position{Last: -1}, 0))
+	assert(t, err != nil)
+	_, err = Compile([]string{"a"}, WithPosition(Position{Windows: []Window{{3, 2}}}, 0))
+	assert(t, err != nil)
+
+	m := NewStringMatcher([]string{"a"}, WithPosition(Position{First: 1}, 0))
+	assert(t, m.Save(io.Discard) == ErrNotSerializable)
+	err = m.FindReader(strings.NewReader("a"), func(Match) bool { return true })
+	assert(t, err != nil)
+	assert(t, m.Fingerprint() != NewStringMatcher([]string{"a"}).Fingerprint())
+}
@@ -0,0 +1,163 @@
+// prefilter.go: skipping input that cannot start a match while the automaton
+// is at its root.
+//
+// Most of a typical text does not start any pattern, yet the automaton would
+// still decode and look up every rune of it. When every pattern starts with
+// an ASCII byte, the bytes that can leave the root form a small set and the
+// matcher jumps straight to the next one of them:
+//   - a single start byte is located with strings.IndexByte, which the Go
+//     runtime implements with SIMD instructions on the common architectures
+//   - small sets are searched block by block: on amd64 with SSE2
+//     comparisons of 16 bytes against up to eight start bytes in assembly,
+//     elsewhere, and with the purego tag, with word-wide (SWAR) comparisons
+//     of 8 bytes against up to four start bytes in pure Go
+//   - larger sets fall back to a table lookup per byte
+// ASCII bytes never occur inside multi-byte UTF-8 sequences, so the offset
+// found is always the start of a rune and skipped bytes are never needed.
+//
+// Otherwise, for instance with CJK dictionaries, a bitmap of the runes of the
+// Basic Multilingual Plane that start a pattern drives a tight loop over the
+// text that skips everything else without touching the automaton.
+
+package ahocorasick
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPrefilterBytes is the size above which the start set is considered
+// too unselective for skipping to pay off
+const maxPrefilterBytes = 48
+
+// prefilter locates the next byte that can start a pattern
+type prefilter struct {
+	start [256]bool           // bytes labelling a transition out of the root
+	bytes []byte              // the start bytes, in ascending order
+	set   [maxBlockBytes]byte // the start bytes repeated to fill the array
+}
+
+// newPrefilter returns the prefilter of m, nil when a pattern starts with a
+// non-ASCII rune, the input is case-folded, the dictionary holds the empty pattern or the start set is
+// too large to be selective
+func newPrefilter(m *Matcher) *prefilter {
+	rs := &m.states[root]
+	if m.fold != nil || m.outputs[root] >= 0 || rs.nedges == 0 || rs.nedges > maxPrefilterBytes {
+		return nil
+	}
+	p := new(prefilter)
+	for _, e := range m.edges[rs.edges : rs.edges+rs.nedges] {
+		if e.label < 0 || e.label >= utf8.RuneSelf {
+			return nil
+		}
+		p.start[e.label] = true
+		p.bytes = append(p.bytes, byte(e.label))
+	}
+	for i := range p.set {
+		p.set[i] = p.bytes[i%len(p.bytes)]
+	}
+	return p
+}
+
+// startRunes is the set of runes of the Basic Multilingual Plane that label a transition out of the root
+type startRunes struct {
+	bits []uint64 // long enough for the largest start rune of the plane
+	fold bool     // input runes are case-folded before the lookup
+}
+
+// newStartRunes returns the start rune set of m, nil when the dictionary
+// holds the empty pattern or m has no pattern
+func newStartRunes(m *Matcher) *startRunes {
+	rs := &m.states[root]
+	if m.outputs[root] >= 0 || rs.nedges == 0 {
+		return nil
+	}
+	s := &startRunes{fold: m.fold != nil}
+	for _, e := range m.edges[rs.edges : rs.edges+rs.nedges] {
+		s.add(e.label)
+	}
+	return s
+}
+
+// add inserts r into the set, runes beyond the plane are ignored
+func (s *startRunes) add(r rune) {
+	if r < 0 || r > 0xFFFF {
+		return
+	}
+	for int(r)/64 >= len(s.bits) {
+		s.bits = append(s.bits, 0)
+	}
+	s.bits[r/64] |= 1 << (r % 64)
+}
+
+// next returns the offset of the first rune at or after i that may start a
+// pattern, len(text) if there is none; runes beyond the plane are always
+// handed to the automaton
+func (s *startRunes) next(text string, i int) int {
+	for i < len(text) {
+		r, size := decodeRune(text, i)
+		if s.fold {
+			r = foldRune(r)
+		}
+		if r > 0xFFFF || (int(r)/64 < len(s.bits) && s.bits[r/64]&(1<<(r%64)) != 0) {
+			return i
+		}
+		i += size
+	}
+	return i
+}
+
+// next returns the offset of the first byte at or after i that can start a
+// pattern, len(text) if there is none
+func (p *prefilter) next(text string, i int) int {
+	if i < len(text) && p.start[text[i]] {
+		return i // common when matches are dense
+	}
+	switch {
+	case len(p.bytes) == 1:
+		if j := strings.IndexByte(text[i:], p.bytes[0]); j >= 0 {
+			return i + j
+		}
+		return len(text)
+	case len(p.bytes) <= maxBlockBytes:
+		i += p.skipBlocks(text[i:])
+	}
+	for ; i < len(text); i++ {
+		if p.start[text[i]] {
+			return i
+		}
+	}
+	return i
+}
+
+// skip returns the offset at which matching resumes: while the automaton is
+// in state n = root no byte before the next start byte can change its state
+func (m *Matcher) skip(text string, i int, n uint32) int {
+	switch {
+	case n != root:
+		return i
+	case m.prefilter != nil:
+		return m.prefilter.next(text, i)
+	case m.startRunes != nil:
+		return m.startRunes.next(text, i)
+	}
+	return i
+}
+
+// initSkip selects how m skips input at the root
+func (m *Matcher) initSkip() {
+	if m.norm != nil {
+		return // any input rune may normalize to a start rune
+	}
+	if m.prefilter = newPrefilter(m); m.prefilter == nil {
+		m.startRunes = newStartRunes(m)
+	}
+}
+
+// decodeRune decodes the rune at offset i of text like a range loop does
+func decodeRune(text string, i int) (rune, int) {
+	if c := text[i]; c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+	return utf8.DecodeRuneInString(text[i:])
+}
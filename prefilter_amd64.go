@@ -0,0 +1,22 @@
+// prefilter_amd64.go: the vectorized search of the start bytes on amd64.
+
+//go:build amd64 && !purego && !tinygo
+
+package ahocorasick
+
+// maxBlockBytes is the largest start set searched block by block: every
+// amd64 processor has SSE2, which compares 16 bytes with each start byte
+// in one instruction
+const maxBlockBytes = 8
+
+// skipBlocks returns the length of the leading 16 byte blocks of text
+// holding none of the start bytes
+func (p *prefilter) skipBlocks(text string) int {
+	return skipBlocksSSE2(text, &p.set)
+}
+
+// skipBlocksSSE2 is skipBlocks over the start bytes of set, repeated to fill
+// it, and is implemented in prefilter_amd64.s
+//
+//go:noescape
+func skipBlocksSSE2(text string, set *[maxBlockBytes]byte) int
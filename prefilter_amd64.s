@@ -0,0 +1,67 @@
+// prefilter_amd64.s: skipping 16 byte blocks without start bytes with SSE2.
+
+//go:build amd64 && !purego && !tinygo
+
+#include "textflag.h"
+
+// broadcast fills the 16 bytes of register X with byte i of the set in DI
+#define broadcast(i, X) \
+	MOVBLZX i(DI), AX \
+	MOVL AX, X \
+	PUNPCKLBW X, X \
+	PUNPCKLWL X, X \
+	PSHUFL $0, X, X
+
+// func skipBlocksSSE2(text string, set *[8]byte) int
+TEXT ·skipBlocksSSE2(SB), NOSPLIT, $0-32
+	MOVQ text_base+0(FP), SI
+	MOVQ text_len+8(FP), BX
+	MOVQ set+16(FP), DI
+	broadcast(0, X1)
+	broadcast(1, X2)
+	broadcast(2, X3)
+	broadcast(3, X4)
+	broadcast(4, X5)
+	broadcast(5, X6)
+	broadcast(6, X7)
+	broadcast(7, X8)
+	XORQ CX, CX
+
+loop:
+	LEAQ 16(CX), DX
+	CMPQ DX, BX
+	JA   done
+	MOVOU (SI)(CX*1), X0
+
+	// X9 has 0xff in the bytes of the block equal to a start byte
+	MOVO    X0, X9
+	PCMPEQB X1, X9
+	MOVO    X0, X10
+	PCMPEQB X2, X10
+	POR     X10, X9
+	MOVO    X0, X10
+	PCMPEQB X3, X10
+	POR     X10, X9
+	MOVO    X0, X10
+	PCMPEQB X4, X10
+	POR     X10, X9
+	MOVO    X0, X10
+	PCMPEQB X5, X10
+	POR     X10, X9
+	MOVO    X0, X10
+	PCMPEQB X6, X10
+	POR     X10, X9
+	MOVO    X0, X10
+	PCMPEQB X7, X10
+	POR     X10, X9
+	PCMPEQB X8, X0
+	POR     X0, X9
+	PMOVMSKB X9, AX
+	TESTL   AX, AX
+	JNZ     done
+	MOVQ    DX, CX
+	JMP     loop
+
+done:
+	MOVQ CX, ret+24(FP)
+	RET
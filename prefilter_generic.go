@@ -0,0 +1,33 @@
+// prefilter_generic.go: the pure Go search of the start bytes, for other
+// architectures and the purego and TinyGo builds.
+
+//go:build !amd64 || purego || tinygo
+
+package ahocorasick
+
+// maxBlockBytes is the largest start set searched block by block, with
+// word-wide (SWAR) comparisons of 8 bytes against each start byte
+const maxBlockBytes = 4
+
+const (
+	swarLow  = 0x0101010101010101
+	swarHigh = 0x8080808080808080
+)
+
+// skipBlocks returns the length of the leading 8 byte words of text holding
+// none of the start bytes
+func (p *prefilter) skipBlocks(text string) int {
+	i := 0
+	for ; i+8 <= len(text); i += 8 {
+		w := uint64(text[i]) | uint64(text[i+1])<<8 | uint64(text[i+2])<<16 | uint64(text[i+3])<<24 |
+			uint64(text[i+4])<<32 | uint64(text[i+5])<<40 | uint64(text[i+6])<<48 | uint64(text[i+7])<<56
+		for _, c := range p.bytes {
+			// a byte of x is zero where w holds c
+			x := w ^ (swarLow * uint64(c))
+			if (x-swarLow)&^x&swarHigh != 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
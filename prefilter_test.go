@@ -0,0 +1,114 @@
+// prefilter_test.go: tests for skipping input at the root
+
+package ahocorasick
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPrefilterSelection(t *testing.T) {
+	assert(t, NewStringMatcher([]string{"abc", "ax"}).prefilter != nil)
+	assert(t, NewStringMatcher([]string{"abc", "中文"}).prefilter == nil)
+	assert(t, NewStringMatcher([]string{"abc", ""}).prefilter == nil)
+	assert(t, NewStringMatcher(nil).prefilter == nil)
+}
+
+func TestPrefilter(t *testing.T) {
+	dicts := [][]string{
+		{"needle", "nee", "edl"}, // a single start byte
+		{"ab", "b中", "cab", "d"}, // block by block
+		{"Mozilla", "Mac", "Safari", "Sausage", "x", "y", "z", "q"},
+	}
+	alphabet := []string{"a", "b", "c", "d", "e", "n", "l", "M", "S", "x", "中", "\xff", "\xe4\xb8", " "}
+	rng := rand.New(rand.NewSource(1))
+	for _, dict := range dicts {
+		m := NewStringMatcher(dict)
+		assert(t, m.prefilter != nil)
+		plain := NewStringMatcher(dict)
+		plain.prefilter = nil
+
+		for i := 0; i < 200; i++ {
+			text := ""
+			for j := rng.Intn(128); j > 0; j-- {
+				text += alphabet[rng.Intn(len(alphabet))]
+			}
+			got, want := m.FindAllString(text), plain.FindAllString(text)
+			assert(t, len(got) == len(want))
+			for k := range got {
+				assert(t, got[k] == want[k])
+			}
+			assert(t, len(m.MatchString(text)) == len(plain.MatchString(text)))
+			assert(t, m.ContainsString(text) == plain.ContainsString(text))
+			a, _ := m.MatchFirstString(text)
+			b, _ := plain.MatchFirstString(text)
+			assert(t, a == b)
+		}
+	}
+}
+
+func TestPrefilterBlocks(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 1; n <= maxBlockBytes; n++ {
+		dict := []string{}
+		for c := byte('a'); c < 'a'+byte(n); c++ {
+			dict = append(dict, string(c)+"!")
+		}
+		p := NewStringMatcher(dict).prefilter
+		assert(t, p != nil && len(p.bytes) == n)
+
+		for i := 0; i < 200; i++ {
+			text := make([]byte, rng.Intn(100))
+			for j := range text {
+				text[j] = 'a' + byte(rng.Intn(n+200)) // mostly bytes above the set
+			}
+			// the skipped blocks hold no start byte, and the next one does
+			// unless it is cut short by the end of the text
+			k := p.skipBlocks(string(text))
+			assert(t, k >= 0 && k <= len(text))
+			for _, c := range text[:k] {
+				assert(t, !p.start[c])
+			}
+			if k+16 <= len(text) {
+				found := false
+				for _, c := range text[k : k+16] {
+					found = found || p.start[c]
+				}
+				assert(t, found)
+			}
+		}
+	}
+}
+
+func TestPrefilterLoaded(t *testing.T) {
+	m := roundTrip(t, precomputed)
+	assert(t, m.prefilter != nil)
+	assert(t, len(m.Match(bytes)) == len(precomputed.Match(bytes)))
+}
+
+func TestStartRunes(t *testing.T) {
+	m := NewStringMatcher([]string{"abc", "中文"})
+	assert(t, m.prefilter == nil && m.startRunes != nil)
+	assert(t, NewStringMatcher([]string{"abc"}).startRunes == nil)
+	assert(t, NewStringMatcher([]string{"中", ""}).startRunes == nil)
+
+	dict := []string{"中文", "文字", "\U0001F600x", "a\xff", "\xffb"}
+	alphabet := []string{"中", "文", "字", "\U0001F600", "x", "a", "b", "\xff", "\xe4\xb8", "测"}
+	m = NewStringMatcher(dict)
+	assert(t, m.startRunes != nil)
+	plain := NewStringMatcher(dict)
+	plain.startRunes = nil
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		text := ""
+		for j := rng.Intn(32); j > 0; j-- {
+			text += alphabet[rng.Intn(len(alphabet))]
+		}
+		got, want := m.FindAllString(text), plain.FindAllString(text)
+		assert(t, len(got) == len(want))
+		for k := range got {
+			assert(t, got[k] == want[k])
+		}
+		assert(t, m.ContainsString(text) == plain.ContainsString(text))
+	}
+}
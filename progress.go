@@ -0,0 +1,87 @@
+// progress.go: progress reports and cancellation of long builds.
+
+package ahocorasick
+
+import "context"
+
+// BuildPhase is a phase of a build reported to a progress callback
+type BuildPhase int
+
+const (
+	// PhaseInsert counts the patterns inserted into the trie
+	PhaseInsert BuildPhase = iota + 1
+
+	// PhaseLinks counts the states whose fail and suffix links are computed
+	PhaseLinks
+)
+
+// BuildProgress is a progress report of a build
+type BuildProgress struct {
+	Phase BuildPhase
+	Done  int // items of the phase processed so far
+	Total int // items of the phase, -1 when unknown as with CompileSeq
+}
+
+const (
+	progressEvery = 1 << 16 // items processed between two progress reports
+	cancelEvery   = 1 << 10 // items processed between two cancellation checks
+)
+
+// WithProgress makes the build call fn every 65536 patterns inserted and
+// states linked, and once more at the end of each phase, so operators of
+// multi-minute builds of huge dictionaries see them advance; fn is called
+// from the building goroutine and should return quickly
+// links computed on first use, see WithLazyLinks, are not reported
+func WithProgress(fn func(BuildProgress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// CompileContext is like Compile but gives up as soon as ctx is done,
+// returning its error, so a build of a huge dictionary can be aborted when
+// a deploy is canceled or the configuration replaced, instead of grinding
+// away for minutes in a leaked goroutine
+// the context is checked every 1024 patterns inserted and states linked
+func CompileContext(ctx context.Context, dictionary []string, opts ...Option) (*Matcher, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts = append(opts[:len(opts):len(opts)], func(o *options) { o.ctx = ctx })
+	return Compile(dictionary, opts...)
+}
+
+// progressReporter reports the progress of a phase to the callback of the
+// options and checks their context, if any
+type progressReporter struct {
+	fn    func(BuildProgress)
+	ctx   context.Context
+	phase BuildPhase
+	total int
+}
+
+// newProgress returns the reporter of phase, total being its number of items
+func newProgress(o *options, phase BuildPhase, total int) progressReporter {
+	return progressReporter{fn: o.progress, ctx: o.ctx, phase: phase, total: total}
+}
+
+// step reports done items processed if a report is due, and returns the
+// error of the context if it is done
+func (p progressReporter) step(done int) error {
+	if p.ctx != nil && done%cancelEvery == 0 {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if p.fn != nil && done%progressEvery == 0 {
+		p.fn(BuildProgress{Phase: p.phase, Done: done, Total: p.total})
+	}
+	return nil
+}
+
+// end reports the end of the phase after done items, unless step just did
+func (p progressReporter) end(done int) {
+	if p.fn != nil && (done%progressEvery != 0 || done == 0) {
+		p.fn(BuildProgress{Phase: p.phase, Done: done, Total: done})
+	}
+}
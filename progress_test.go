@@ -0,0 +1,69 @@
+// progress_test.go: tests for build progress reports
+
+package ahocorasick
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestWithProgress(t *testing.T) {
+	words := make([]string, 3*progressEvery/2)
+	for i := range words {
+		words[i] = strconv.Itoa(i)
+	}
+	var reports []BuildProgress
+	m := NewStringMatcher(words, WithProgress(func(p BuildProgress) {
+		reports = append(reports, p)
+	}))
+	states := len(m.states) - 1
+	assert(t, len(reports) == 3+states/progressEvery)
+	assert(t, reports[0] == BuildProgress{Phase: PhaseInsert, Done: progressEvery, Total: len(words)})
+	assert(t, reports[1] == BuildProgress{Phase: PhaseInsert, Done: len(words), Total: len(words)})
+	assert(t, reports[2] == BuildProgress{Phase: PhaseLinks, Done: progressEvery, Total: states})
+	last := reports[len(reports)-1]
+	assert(t, last == BuildProgress{Phase: PhaseLinks, Done: states, Total: states})
+
+	// streamed dictionaries have no known total until the end
+	reports = nil
+	_, err := CompileSeq(seqOf(words), WithProgress(func(p BuildProgress) {
+		reports = append(reports, p)
+	}))
+	assert(t, err == nil && reports[0].Total == -1 && reports[1].Total == len(words))
+
+	reports = nil
+	NewStringMatcher(nil, WithProgress(func(p BuildProgress) { reports = append(reports, p) }))
+	assert(t, len(reports) == 2 && reports[0].Done == 0 && reports[1].Phase == PhaseLinks)
+	reports = nil
+	NewStringMatcher(words[:10], WithLazyLinks(), WithProgress(func(p BuildProgress) { reports = append(reports, p) }))
+	assert(t, len(reports) == 1)
+}
+
+func TestCompileContext(t *testing.T) {
+	words := make([]string, 3*progressEvery/2)
+	for i := range words {
+		words[i] = strconv.Itoa(i)
+	}
+	m, err := CompileContext(context.Background(), words[:100])
+	assert(t, err == nil && m.ContainsString("42"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = CompileContext(ctx, words)
+	assert(t, errors.Is(err, context.Canceled))
+
+	// canceled while inserting, then while linking
+	for _, phase := range []BuildPhase{PhaseInsert, PhaseLinks} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var last BuildProgress
+		_, err = CompileContext(ctx, words, WithProgress(func(p BuildProgress) {
+			last = p
+			if p.Phase == phase {
+				cancel()
+			}
+		}))
+		assert(t, errors.Is(err, context.Canceled) && last.Phase == phase && last.Done == progressEvery)
+	}
+}
@@ -0,0 +1,176 @@
+// reader.go: matching text streamed from an io.Reader in bounded memory.
+
+package ahocorasick
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// defaultStreamBuffer is the default buffer size of FindReader
+const defaultStreamBuffer = 64 << 10
+
+// StreamOption configures FindReader
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	bufferSize int
+	lookback   int
+}
+
+// WithBufferSize sets the size in bytes of the only buffer FindReader reads
+// into, which bounds its memory use; the default is 64 KiB, or more when
+// needed to hold the lookback
+func WithBufferSize(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.bufferSize = n
+	}
+}
+
+// WithMaxLookback sets how many bytes before the unread input FindReader
+// retains, so occurrences spanning two reads are found; the default is the
+// most bytes the longest pattern can span, and a smaller value loses the
+// occurrences longer than it that span two reads
+// with normalization an occurrence can span more input than its pattern,
+// for instance over dropped combining marks, so the lookback should then
+// be set explicitly, as with validators inspecting more of the input
+// around an occurrence than it
+func WithMaxLookback(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.lookback = n
+	}
+}
+
+// FindReader calls fn with every occurrence of every pattern in the text read
+// from r, in FindAll order with offsets counted from the start of the
+// stream, until fn returns false or r is exhausted
+// it never holds more than its buffer, whatever the size of the input: after
+// every read, occurrences ending in the new bytes are reported and only the
+// lookback is kept for the next read; the overlap policy is not applied
+// with normalization or validators, occurrences depend on the input around
+// them: those ending in the last lookback bytes are held back until more
+// input arrives, so combining marks are not split from them and validators
+// see at least the lookback on both sides, the edges of the buffer being
+// the edges of the text only at the start and the end of the stream
+// errors of r other than io.EOF are returned, and a matcher with positions,
+// resolved against whole texts, is rejected
+func (m *Matcher) FindReader(r io.Reader, fn func(Match) bool, opts ...StreamOption) error {
+	if m.positions != nil {
+		return errors.New("ahocorasick: positions need the whole text, FindReader cannot apply them")
+	}
+	m.depthOnce.Do(m.computeDepths)
+	var o streamOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	lookback := o.lookback
+	if lookback <= 0 {
+		lookback = m.maxDepth * utf8.UTFMax
+	}
+	// occurrences ending in the last hold bytes wait for the next read, and
+	// hold bytes are kept before those reported next
+	hold := 0
+	if m.norm != nil || m.validators != nil {
+		hold = lookback
+	}
+	// the buffer holds the lookback, the bytes around held occurrences, a
+	// trailing incomplete rune and at least one new rune
+	need := lookback + 2*hold + 2*utf8.UTFMax
+	if o.bufferSize == 0 {
+		o.bufferSize = max(defaultStreamBuffer, 2*need)
+	}
+	if o.bufferSize <= need {
+		return fmt.Errorf("ahocorasick: stream buffer of %d bytes cannot hold a lookback of %d bytes", o.bufferSize, lookback)
+	}
+
+	buf := make([]byte, o.bufferSize)
+	base := 0 // offset in the stream of buf[0]
+	n := 0    // bytes in buf
+	done := 0 // occurrences ending up to buf[done] were reported
+
+	// held occurrences can come back with another end, once combining marks
+	// extend them, so they are told apart by pattern and start
+	var reported map[streamKey]struct{}
+	if hold > 0 {
+		reported = make(map[streamKey]struct{})
+	}
+	for {
+		k, err := r.Read(buf[n:])
+		n += k
+		eof := errors.Is(err, io.EOF)
+		if err != nil && !eof {
+			return err
+		}
+		if k == 0 && !eof {
+			continue
+		}
+
+		text := bytesToString(buf[:n])
+		end := n
+		if !eof {
+			end -= incompleteTail(text)
+		}
+		limit := end // occurrences ending up to buf[limit] are reported
+		if !eof {
+			limit = max(end-hold, done)
+		}
+		stopped := false
+		m.find(text[:end], func(pattern int32, start, stop int) bool {
+			if stop <= done {
+				return true
+			}
+			if stop > limit {
+				// occurrences come in end order, the rest are held back
+				return false
+			}
+			if reported != nil {
+				k := streamKey{pattern, base + start}
+				if _, ok := reported[k]; ok {
+					return true
+				}
+				reported[k] = struct{}{}
+			}
+			m.hits.add(pattern)
+			if !fn(Match{Pattern: int(pattern), Start: base + start, End: base + stop}) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+		if stopped || eof {
+			return nil
+		}
+
+		keep := runeStart(text, max(limit-lookback-hold, 0))
+		n = copy(buf, buf[keep:n])
+		base += keep
+		done = limit - keep
+		for k := range reported {
+			if k.start < base {
+				delete(reported, k)
+			}
+		}
+	}
+}
+
+// streamKey identifies an occurrence reported by FindReader
+type streamKey struct {
+	pattern int32
+	start   int
+}
+
+// incompleteTail returns the length of the rune prefix text ends with, 0 when
+// its last rune is complete or invalid
+func incompleteTail(text string) int {
+	for q := len(text) - 1; q >= 0 && q > len(text)-utf8.UTFMax; q-- {
+		if utf8.RuneStart(text[q]) {
+			if utf8.FullRuneInString(text[q:]) {
+				return 0
+			}
+			return len(text) - q
+		}
+	}
+	return 0
+}
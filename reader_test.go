@@ -0,0 +1,121 @@
+// reader_test.go: tests for matching streamed text
+
+package ahocorasick
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFindReader(t *testing.T) {
+	text := strings.Repeat(string(bytes2)+"中文\xe4\xb8 Mac\xff", 20)
+	dict := append([]string{"中文", "� M", "c�"}, dictionary6...)
+	m := NewStringMatcher(dict)
+	want := m.FindAllString(text)
+	assert(t, len(want) > 0)
+
+	collect := func(r io.Reader, opts ...StreamOption) ([]Match, error) {
+		var got []Match
+		err := m.FindReader(r, func(o Match) bool {
+			got = append(got, o)
+			return true
+		}, opts...)
+		return got, err
+	}
+	readers := []func() io.Reader{
+		func() io.Reader { return strings.NewReader(text) },
+		func() io.Reader { return iotest.OneByteReader(strings.NewReader(text)) },
+		func() io.Reader { return iotest.HalfReader(strings.NewReader(text)) },
+		func() io.Reader { return iotest.DataErrReader(strings.NewReader(text)) },
+	}
+	for _, size := range []int{0, 200, 1000} {
+		for _, reader := range readers {
+			got, err := collect(reader(), WithBufferSize(size))
+			assert(t, err == nil && len(got) == len(want))
+			for i := range got {
+				assert(t, got[i] == want[i])
+			}
+		}
+	}
+
+	// stopping early
+	count := 0
+	err := m.FindReader(strings.NewReader(text), func(Match) bool {
+		count++
+		return count < 3
+	})
+	assert(t, err == nil && count == 3)
+
+	_, err = collect(strings.NewReader(text), WithBufferSize(16))
+	assert(t, err != nil)
+
+	fail := errors.New("fail")
+	_, err = collect(iotest.ErrReader(fail))
+	assert(t, errors.Is(err, fail))
+
+	got, err := collect(strings.NewReader(""))
+	assert(t, err == nil && len(got) == 0)
+}
+
+func TestFindReaderLookback(t *testing.T) {
+	m := NewStringMatcher([]string{"abcdefgh", "xy"})
+	text := strings.Repeat("-", 30) + "abcdefgh" + strings.Repeat("-", 30) + "xy"
+	count := func(opts ...StreamOption) int {
+		n := 0
+		err := m.FindReader(iotest.OneByteReader(strings.NewReader(text)), func(Match) bool {
+			n++
+			return true
+		}, opts...)
+		assert(t, err == nil)
+		return n
+	}
+	assert(t, count(WithBufferSize(48)) == 2)
+	// a lookback shorter than a pattern loses its occurrences spanning reads
+	assert(t, count(WithBufferSize(48), WithMaxLookback(4)) == 1)
+}
+
+func TestFindReaderContext(t *testing.T) {
+	// occurrences depending on what follows them are the same whatever the
+	// reads: combining marks extend them, validators look past them
+	folded := NewStringMatcher([]string{"cafe", "yy"}, WithDiacriticFolding())
+	card := NewStringMatcher([]string{"4111", "5500"}, WithValidator(Luhn, 0, 1))
+	cases := []struct {
+		m    *Matcher
+		text string
+	}{
+		{folded, "xx cafe\u0301 yy cafe"},
+		{card, "pay 4111 1111 1111 1111 or 4111 1111 1111 1112, 5500 0000 0000 0004"},
+	}
+	for _, c := range cases {
+		want := c.m.FindAllString(c.text)
+		assert(t, len(want) > 0)
+		for _, r := range []io.Reader{
+			strings.NewReader(c.text),
+			iotest.OneByteReader(strings.NewReader(c.text)),
+			iotest.HalfReader(strings.NewReader(c.text)),
+			io.MultiReader(strings.NewReader(c.text[:7]), strings.NewReader(c.text[7:])),
+		} {
+			var got []Match
+			err := c.m.FindReader(r, func(o Match) bool {
+				got = append(got, o)
+				return true
+			})
+			assert(t, err == nil && len(got) == len(want))
+			for i := range min(len(got), len(want)) {
+				assert(t, got[i] == want[i])
+			}
+		}
+	}
+}
+
+func TestIncompleteTail(t *testing.T) {
+	assert(t, incompleteTail("") == 0)
+	assert(t, incompleteTail("ab") == 0)
+	assert(t, incompleteTail("a中") == 0)
+	assert(t, incompleteTail("a\xe4") == 1)
+	assert(t, incompleteTail("a\xe4\xb8") == 2)
+	assert(t, incompleteTail("a\xff") == 0)
+}
@@ -0,0 +1,18 @@
+// rebuild.go: rebuilding a matcher in place, reusing its storage.
+
+package ahocorasick
+
+// Rebuild replaces the dictionary of m with dictionary, built with opts like
+// Compile, reusing the arrays of the current automaton and keeping the
+// build-time trie and transition table for the next call, so services
+// rebuilding similar-sized dictionaries every few minutes stop churning
+// through allocations; the options of the previous build are not kept and
+// must be given again
+// m must not be in use during the call, matchers shared between goroutines
+// should be swapped instead, as Dynamic does; on error m is left unchanged
+func (m *Matcher) Rebuild(dictionary []string, opts ...Option) error {
+	if m.builder == nil {
+		m.builder = newTrieBuilder(0)
+	}
+	return m.compile(dictionary, nil, len(dictionary), opts)
+}
@@ -0,0 +1,57 @@
+// rebuild_test.go: tests for rebuilding in place
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRebuild(t *testing.T) {
+	m := NewStringMatcher(dictionary6)
+	assert(t, len(m.MatchString(sbytes2)) > 0)
+
+	assert(t, m.Rebuild(dictionary) == nil)
+	assert(t, m.npatterns == len(dictionary))
+	want := precomputed.FindAllString(sbytes)
+	got := m.FindAllString(sbytes)
+	assert(t, len(got) == len(want))
+	for i := range got {
+		assert(t, got[i] == want[i])
+	}
+	hits := m.MatchString(sbytes)
+	assert(t, len(hits) == 4 && hits[0] == 0)
+
+	// the storage of the previous build is reused
+	states := &m.states[0]
+	assert(t, m.Rebuild([]string{"Safari", "Sausage", "Mozilla"}, WithCaseInsensitive(0)) == nil)
+	assert(t, &m.states[0] == states && m.builder != nil)
+	assert(t, m.ContainsString("SAFARI") && !m.ContainsString("Mac"))
+	hits = m.MatchString(sbytes)
+	assert(t, len(hits) == 2 && hits[0] == 2 && hits[1] == 0)
+
+	// errors leave the matcher unchanged
+	err := m.Rebuild([]string{"a"}, WithCategories(map[string][]int{"x": {3}}))
+	assert(t, err != nil && m.npatterns == 3 && m.ContainsString("Mozilla"))
+
+	// a dictionary growing between builds
+	big := make([]string, 0, 500)
+	for i := range 500 {
+		big = append(big, strings.Repeat("ab", i%7+1)+string(rune('a'+i%26))+strings.Repeat("z", i/26))
+	}
+	assert(t, m.Rebuild(big) == nil)
+	fresh := NewStringMatcher(big)
+	text := strings.Join(big[100:140], " ")
+	assert(t, len(m.FindAllString(text)) == len(fresh.FindAllString(text)))
+	assert(t, m.Fingerprint() == fresh.Fingerprint())
+}
+
+func BenchmarkRebuild(b *testing.B) {
+	m := NewStringMatcher(dictionary6)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := m.Rebuild(dictionary6); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// redact.go: masking occurrences with a report of what was masked.
+
+package ahocorasick
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redaction records a span masked by Redact and why
+type Redaction struct {
+	Pattern    int      // index of the pattern found there
+	Categories []string // categories of the pattern, see WithCategories
+	Start      int      // byte offset of the first byte in the original text
+	End        int      // byte offset just after the last byte in the original text
+	Runes      int      // number of runes masked, the same in the redacted text
+}
+
+// Redact masks every occurrence in text rune by rune with '*' and returns
+// the redacted text together with a report of every masked occurrence,
+// giving audit pipelines structured evidence of what was altered and why;
+// the report never holds the masked text itself
+// every rune covered by some occurrence is masked, whatever the overlap
+// policy, so overlapping occurrences never leave part of one in clear text,
+// and all of them are reported, ordered by start offset, the longest first
+func (m *Matcher) Redact(text []byte) ([]byte, []Redaction) {
+	clean, report := m.RedactString(bytesToString(text))
+	return []byte(clean), report
+}
+
+// RedactString is like Redact for a string input
+func (m *Matcher) RedactString(text string) (string, []Redaction) {
+	matches := m.collect(text)
+	if len(matches) == 0 {
+		return text, nil
+	}
+	m.countHits(matches)
+	report := make([]Redaction, len(matches))
+	for i, o := range matches {
+		report[i] = Redaction{
+			Pattern:    o.Pattern,
+			Categories: m.PatternCategories(o.Pattern),
+			Start:      o.Start,
+			End:        o.End,
+			Runes:      utf8.RuneCountInString(text[o.Start:o.End]),
+		}
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].Start != report[j].Start {
+			return report[i].Start < report[j].Start
+		}
+		return report[i].End > report[j].End
+	})
+
+	var b strings.Builder
+	b.Grow(len(text))
+	pos := 0
+	for _, sp := range MergeSpans(matches) {
+		b.WriteString(text[pos:sp.Start])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[sp.Start:sp.End])))
+		pos = sp.End
+	}
+	b.WriteString(text[pos:])
+	return b.String(), report
+}
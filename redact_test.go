@@ -0,0 +1,40 @@
+// redact_test.go: tests for masking with a report
+
+package ahocorasick
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	words := []string{"secret", "password", "pass", "é"}
+	m := NewStringMatcher(words, WithCategories(map[string][]int{
+		"credentials": {1, 2},
+		"weak":        {2},
+	}), WithHitCounters())
+
+	clean, report := m.RedactString("my password is secret, café")
+	assert(t, clean == "my ******** is ******, caf*")
+	assert(t, len(report) == 4)
+	assert(t, report[0].Pattern == 1 && report[0].Start == 3 && report[0].End == 11 && report[0].Runes == 8)
+	assert(t, len(report[0].Categories) == 1 && report[0].Categories[0] == "credentials")
+	// the shorter occurrence inside it is reported too
+	assert(t, report[1].Pattern == 2 && report[1].Start == 3 && report[1].End == 7)
+	assert(t, report[2].Pattern == 0 && report[2].Categories == nil)
+	assert(t, report[3].Pattern == 3 && report[3].End-report[3].Start == 2 && report[3].Runes == 1)
+
+	out, report := m.Redact([]byte("pass"))
+	assert(t, string(out) == "****" && len(report) == 1)
+	assert(t, len(report[0].Categories) == 2 && report[0].Categories[1] == "weak")
+
+	// every reported occurrence is counted
+	counts := m.Hits().Snapshot()
+	assert(t, counts[0] == 1 && counts[1] == 1 && counts[2] == 2 && counts[3] == 1)
+
+	// overlapping occurrences are masked together, whatever the policy
+	m = NewStringMatcher([]string{"abc", "bcd"}, WithOverlapPolicy(LongestWins))
+	clean, report = m.RedactString("xabcdx")
+	assert(t, clean == "x****x" && len(report) == 2)
+	assert(t, report[0].Pattern == 0 && report[1].Pattern == 1 && report[1].Start == 2)
+
+	clean, report = m.RedactString("nothing")
+	assert(t, clean == "nothing" && report == nil)
+}
@@ -0,0 +1,96 @@
+// registry.go: named matchers shared across an application.
+
+package ahocorasick
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrNotRegistered is returned by Registry.Get for an unknown name
+var ErrNotRegistered = errors.New("ahocorasick: no matcher registered under this name")
+
+// Registry is a lookup point for applications with many independently
+// managed keyword sets, such as one per language or per policy: matchers
+// are registered under a name, either built or with a function building
+// them on first use
+// it is safe for concurrent use
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]*registryEntry
+}
+
+// registryEntry is a matcher of a registry, built or not yet
+type registryEntry struct {
+	mu    sync.Mutex
+	m     *Matcher
+	build func() (*Matcher, error) // nil once built
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]*registryEntry)}
+}
+
+// Register registers m under name, replacing what was registered under it
+func (r *Registry) Register(name string, m *Matcher) {
+	r.set(name, &registryEntry{m: m})
+}
+
+// RegisterLazy registers under name a matcher built by build on the first
+// Get, so unused keyword sets cost nothing; a failed build is retried on
+// the next Get, and concurrent calls wait for a single build
+func (r *Registry) RegisterLazy(name string, build func() (*Matcher, error)) {
+	r.set(name, &registryEntry{build: build})
+}
+
+// set registers e under name
+func (r *Registry) set(name string, e *registryEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[name] = e
+}
+
+// Unregister removes name from the registry, matchers already returned by
+// Get stay usable
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, name)
+}
+
+// Get returns the matcher registered under name, building it first if it
+// was registered with RegisterLazy
+func (r *Registry) Get(name string) (*Matcher, error) {
+	r.mu.RLock()
+	e, ok := r.entries[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotRegistered, name)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.build != nil {
+		m, err := e.build()
+		if err != nil {
+			return nil, fmt.Errorf("ahocorasick: building matcher %q: %w", name, err)
+		}
+		e.m, e.build = m, nil
+	}
+	return e.m, nil
+}
+
+// Names returns the registered names in sorted order, built or not
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+// registry_test.go: tests for the matcher registry
+
+package ahocorasick
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	_, err := r.Get("en")
+	assert(t, errors.Is(err, ErrNotRegistered))
+
+	r.Register("en", precomputed)
+	m, err := r.Get("en")
+	assert(t, err == nil && m == precomputed)
+
+	var builds atomic.Int32
+	fail := true
+	r.RegisterLazy("fr", func() (*Matcher, error) {
+		builds.Add(1)
+		if fail {
+			return nil, errors.New("wordlist unavailable")
+		}
+		return Compile([]string{"bonjour"})
+	})
+	assert(t, builds.Load() == 0)
+	_, err = r.Get("fr")
+	assert(t, err != nil && builds.Load() == 1)
+
+	fail = false
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := r.Get("fr")
+			assert(t, err == nil && m.ContainsString("bonjour"))
+		}()
+	}
+	wg.Wait()
+	assert(t, builds.Load() == 2)
+
+	names := r.Names()
+	assert(t, len(names) == 2 && names[0] == "en" && names[1] == "fr")
+	r.Unregister("en")
+	_, err = r.Get("en")
+	assert(t, errors.Is(err, ErrNotRegistered) && len(r.Names()) == 1)
+}
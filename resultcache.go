@@ -0,0 +1,114 @@
+// resultcache.go: memoization of the results of repeated texts.
+
+package ahocorasick
+
+import (
+	"hash/maphash"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultCache memoizes the results of a matcher for recently seen texts, for
+// traffic where the same input comes back many times, such as a spam
+// message posted thousands of times in a chat; texts are keyed by a hash and
+// compared in full, so a collision is never a wrong result
+// cached results are served as is: hit counters do not count them and a
+// pattern disabled afterwards is still reported until Purge or expiry
+// it is safe for concurrent use
+type ResultCache struct {
+	m    *Matcher
+	ttl  time.Duration
+	seed maphash.Seed
+	now  func() time.Time // clock, replaced in tests
+
+	mu      sync.Mutex
+	entries *lru[uint64, *cachedResult]
+	hits    uint64
+	misses  uint64
+}
+
+type cachedResult struct {
+	text   string
+	hits   []int
+	expiry time.Time // zero when the result never expires
+}
+
+// NewResultCache wraps m with a cache of the results of the last size
+// distinct texts (at least 1), each kept for ttl at most, forever when ttl
+// is 0
+func NewResultCache(m *Matcher, size int, ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		m:       m,
+		ttl:     ttl,
+		seed:    maphash.MakeSeed(),
+		now:     time.Now,
+		entries: newLRU[uint64, *cachedResult](size),
+	}
+}
+
+// Match returns the patterns found in text like MatchThreadSafe of the
+// matcher, from the cache when text was seen recently; the result belongs
+// to the caller
+func (c *ResultCache) Match(text []byte) []int {
+	return c.MatchString(bytesToString(text))
+}
+
+// MatchString is like Match for a string input
+func (c *ResultCache) MatchString(text string) []int {
+	key := maphash.String(c.seed, text)
+	if hits, ok := c.lookup(key, text); ok {
+		return append([]int(nil), hits...)
+	}
+	// the text is scanned without holding the lock
+	hits := c.m.MatchThreadSafeString(text)
+	c.store(key, strings.Clone(text), append([]int(nil), hits...))
+	return hits
+}
+
+// lookup returns the cached result of text
+func (c *ResultCache) lookup(key uint64, text string) ([]int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r, ok := c.entries.get(key); ok && r.text == text && (r.expiry.IsZero() || c.now().Before(r.expiry)) {
+		c.hits++
+		return r.hits, true
+	}
+	c.misses++
+	return nil, false
+}
+
+// store caches the result of text, evicting the least recently used result
+// if needed
+func (c *ResultCache) store(key uint64, text string, hits []int) {
+	r := &cachedResult{text: text, hits: hits}
+	if c.ttl > 0 {
+		r.expiry = c.now().Add(c.ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// an expired result, a colliding text or a concurrent store is replaced
+	c.entries.put(key, r)
+}
+
+// Purge drops every cached result
+func (c *ResultCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.clear()
+}
+
+// Len returns the number of cached results
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.len()
+}
+
+// Stats returns the number of calls served from the cache and of calls that
+// scanned their text
+func (c *ResultCache) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
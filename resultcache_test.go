@@ -0,0 +1,81 @@
+// resultcache_test.go: tests for the memoization of results
+
+package ahocorasick
+
+import (
+	"hash/maphash"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResultCache(t *testing.T) {
+	c := NewResultCache(precomputed, 2, 0)
+	hits := c.Match(bytes)
+	assert(t, len(hits) == 4)
+	hits[0] = 99 // results belong to the caller
+	again := c.MatchString(sbytes)
+	assert(t, len(again) == 4 && again[0] == 0)
+	h, misses := c.Stats()
+	assert(t, h == 1 && misses == 1)
+
+	// the least recently used text is evicted
+	c.MatchString("Mac")
+	c.MatchString(sbytes)
+	c.MatchString("Safari")
+	assert(t, c.Len() == 2)
+	c.MatchString(sbytes)
+	c.MatchString("Mac")
+	h, misses = c.Stats()
+	assert(t, h == 3 && misses == 4)
+
+	c.Purge()
+	assert(t, c.Len() == 0)
+}
+
+func TestResultCacheTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := NewResultCache(precomputed, 10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.MatchString(sbytes)
+	now = now.Add(30 * time.Second)
+	c.MatchString(sbytes)
+	now = now.Add(time.Minute)
+	assert(t, len(c.MatchString(sbytes)) == 4)
+	h, misses := c.Stats()
+	assert(t, h == 1 && misses == 2)
+	assert(t, c.Len() == 1)
+}
+
+func TestResultCacheCollision(t *testing.T) {
+	c := NewResultCache(precomputed, 10, 0)
+	c.MatchString("Mac")
+	// file the result of "Mac" under the key of "Safari", as a collision would
+	key := maphash.String(c.seed, "Safari")
+	r, ok := c.entries.get(maphash.String(c.seed, "Mac"))
+	assert(t, ok)
+	c.entries.clear()
+	c.entries.put(key, r)
+	hits := c.MatchString("Safari")
+	assert(t, len(hits) == 1 && hits[0] == 3)
+	assert(t, c.Len() == 1)
+}
+
+func TestResultCacheConcurrent(t *testing.T) {
+	c := NewResultCache(precomputed, 3, 0)
+	texts := []string{sbytes, "Mac", "Safari", "Sausage", "none"}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for k := 0; k < 200; k++ {
+				text := texts[(i+k)%len(texts)]
+				assert(t, len(c.MatchString(text)) == len(precomputed.MatchThreadSafeString(text)))
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert(t, c.Len() <= 3)
+}
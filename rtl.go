@@ -0,0 +1,67 @@
+// rtl.go: normalization of Arabic and Hebrew text.
+
+package ahocorasick
+
+import (
+	"sort"
+	"unicode"
+)
+
+// tatweel is the Arabic kashida, a stretching character without meaning
+const tatweel = 0x0640
+
+// WithRTLNormalization makes Arabic and Hebrew text match regardless of how
+// it is rendered: presentation forms and ligatures are replaced by the
+// letters they stand for and tatweel (kashida) stretching is removed, so
+// banned terms cannot be evaded by decorating them
+// when stripMarks is true the optional vowel and cantillation marks (Arabic
+// harakat, Hebrew niqqud) are removed as well, so vocalized and unvocalized
+// spellings match each other
+func WithRTLNormalization(stripMarks bool) Option {
+	kind := "rtl"
+	if stripMarks {
+		kind = "rtl marks"
+	}
+	return func(o *options) {
+		o.addNormalizer(kind, func(dst []rune, _, r rune) []rune {
+			return normalizeRTL(dst, r, stripMarks)
+		})
+	}
+}
+
+// normalizeRTL is the normalizer of WithRTLNormalization
+func normalizeRTL(dst []rune, r rune, stripMarks bool) []rune {
+	switch {
+	case r < 0x0591:
+		return append(dst, r)
+	case r == tatweel:
+		return dst
+	case r >= 0xFB1D && r <= 0xFEFF:
+		i := sort.Search(len(presentationForms), func(i int) bool { return presentationForms[i].form >= r })
+		if i < len(presentationForms) && presentationForms[i].form == r {
+			for _, c := range presentationForms[i].base {
+				if !stripMarks || !isRTLMark(c) {
+					dst = append(dst, c)
+				}
+			}
+			return dst
+		}
+	case stripMarks && isRTLMark(r):
+		return dst
+	}
+	return append(dst, r)
+}
+
+// isRTLMark reports whether r is an optional Hebrew or Arabic mark: a vowel,
+// cantillation or Quranic annotation sign
+func isRTLMark(r rune) bool {
+	switch {
+	case r >= 0x0591 && r <= 0x05C7, // Hebrew points and accents
+		r >= 0x0610 && r <= 0x061A, // Arabic honorifics
+		r >= 0x064B && r <= 0x065F, // Arabic harakat
+		r == 0x0670,                // superscript alef
+		r >= 0x06D6 && r <= 0x06ED: // Quranic annotation signs
+		return unicode.Is(unicode.Mn, r)
+	}
+	return false
+}
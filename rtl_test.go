@@ -0,0 +1,33 @@
+// rtl_test.go: tests for Arabic and Hebrew normalization
+
+package ahocorasick
+
+import "testing"
+
+func TestRTLNormalization(t *testing.T) {
+	p := pipeline{func(dst []rune, _, r rune) []rune { return normalizeRTL(dst, r, false) }}
+	assert(t, p.apply("كـــتاب") == "كتاب")  // tatweel
+	assert(t, p.apply("ﻟﻤﻮ") == "لمو")       // presentation forms
+	assert(t, p.apply("ﻻ") == "لا")          // lam-alef ligature
+	assert(t, p.apply("كَتَبَ") == "كَتَبَ") // marks are kept
+	assert(t, p.apply("אַ") == "אַ")          // alef with patah
+
+	strip := pipeline{func(dst []rune, _, r rune) []rune { return normalizeRTL(dst, r, true) }}
+	assert(t, strip.apply("كَتَبَ") == "كتب")
+	assert(t, strip.apply("שָׁלוֹם") == "שלום")
+	assert(t, strip.apply("אַ") == "א")
+	assert(t, strip.apply("Hello, 中文") == "Hello, 中文")
+}
+
+func TestRTLMatching(t *testing.T) {
+	m := NewStringMatcher([]string{"كتاب", "שלום"}, WithRTLNormalization(true))
+	text := "هذا كِـتَـــاب و שָׁלוֹם"
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 2)
+	assert(t, text[matches[0].Start:matches[0].End] == "كِـتَـــاب")
+	assert(t, text[matches[1].Start:matches[1].End] == "שָׁלוֹם")
+
+	m = NewStringMatcher([]string{"كتاب"}, WithRTLNormalization(false))
+	assert(t, m.ContainsString("كـتـاب"))
+	assert(t, !m.ContainsString("كِتَاب"))
+}
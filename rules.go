@@ -0,0 +1,191 @@
+// rules.go: boolean rules evaluated over the patterns found in a text.
+
+package ahocorasick
+
+// Expr is a boolean expression over the patterns found in a text, built with
+// Pattern, Category, And, Or, Not and the threshold constructors
+type Expr interface {
+	eval(env *ruleEnv) bool
+
+	// collect calls add for every pattern the expression refers to
+	collect(categories map[string][]int, add func(int))
+}
+
+// ruleEnv is what expressions are evaluated against
+type ruleEnv struct {
+	hits       *PatternSet
+	categories map[string][]int
+}
+
+type patternExpr int
+type categoryExpr struct {
+	name string
+	min  int
+}
+type andExpr []Expr
+type orExpr []Expr
+type notExpr struct{ e Expr }
+type atLeastExpr struct {
+	min   int
+	exprs []Expr
+}
+
+// Pattern is true when the pattern with index i occurred
+func Pattern(i int) Expr {
+	return patternExpr(i)
+}
+
+// Category is true when any pattern of the named category occurred
+func Category(name string) Expr {
+	return categoryExpr{name: name, min: 1}
+}
+
+// CategoryAtLeast is true when at least n distinct patterns of the named
+// category occurred
+func CategoryAtLeast(name string, n int) Expr {
+	return categoryExpr{name: name, min: n}
+}
+
+// And is true when all of exprs are true
+func And(exprs ...Expr) Expr {
+	return andExpr(exprs)
+}
+
+// Or is true when any of exprs is true
+func Or(exprs ...Expr) Expr {
+	return orExpr(exprs)
+}
+
+// Not negates e
+func Not(e Expr) Expr {
+	return notExpr{e: e}
+}
+
+// AtLeast is true when at least n of exprs are true
+func AtLeast(n int, exprs ...Expr) Expr {
+	return atLeastExpr{min: n, exprs: exprs}
+}
+
+func (e patternExpr) eval(env *ruleEnv) bool {
+	return env.hits.Has(int(e))
+}
+
+func (e categoryExpr) eval(env *ruleEnv) bool {
+	n := 0
+	for _, p := range env.categories[e.name] {
+		if env.hits.Has(p) {
+			if n++; n >= e.min {
+				return true
+			}
+		}
+	}
+	return e.min <= 0
+}
+
+func (e andExpr) eval(env *ruleEnv) bool {
+	for _, x := range e {
+		if !x.eval(env) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e orExpr) eval(env *ruleEnv) bool {
+	for _, x := range e {
+		if x.eval(env) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e notExpr) eval(env *ruleEnv) bool {
+	return !e.e.eval(env)
+}
+
+func (e atLeastExpr) eval(env *ruleEnv) bool {
+	n := 0
+	for _, x := range e.exprs {
+		if x.eval(env) {
+			if n++; n >= e.min {
+				return true
+			}
+		}
+	}
+	return e.min <= 0
+}
+
+func (e patternExpr) collect(_ map[string][]int, add func(int)) {
+	add(int(e))
+}
+
+func (e categoryExpr) collect(categories map[string][]int, add func(int)) {
+	for _, p := range categories[e.name] {
+		add(p)
+	}
+}
+
+func (e andExpr) collect(categories map[string][]int, add func(int)) {
+	for _, x := range e {
+		x.collect(categories, add)
+	}
+}
+
+func (e orExpr) collect(categories map[string][]int, add func(int)) {
+	andExpr(e).collect(categories, add)
+}
+
+func (e notExpr) collect(categories map[string][]int, add func(int)) {
+	e.e.collect(categories, add)
+}
+
+func (e atLeastExpr) collect(categories map[string][]int, add func(int)) {
+	andExpr(e.exprs).collect(categories, add)
+}
+
+// Rule names a condition over the patterns found in a text, with the actions
+// applied by Decide when it fires
+type Rule struct {
+	Name    string
+	When    Expr
+	Actions []Action
+}
+
+// RuleSet evaluates rules over the hits of a matcher
+// categories group pattern indices under a name usable with Category
+type RuleSet struct {
+	matcher    *Matcher
+	categories map[string][]int
+	rules      []Rule
+}
+
+// NewRuleSet creates a rule set over the patterns of m, categories may be nil
+func NewRuleSet(m *Matcher, categories map[string][]int, rules ...Rule) *RuleSet {
+	return &RuleSet{matcher: m, categories: categories, rules: rules}
+}
+
+// Evaluate scans text once and returns the names of the rules that fired, in
+// the order the rules were given
+// it is safe for concurrent use
+func (rs *RuleSet) Evaluate(text []byte) []string {
+	return rs.EvaluateString(bytesToString(text))
+}
+
+// EvaluateString is like Evaluate for a string input
+func (rs *RuleSet) EvaluateString(text string) []string {
+	return rs.EvaluateSet(rs.matcher.MatchSetString(text))
+}
+
+// EvaluateSet returns the names of the rules that fire for an already
+// computed set of hits
+func (rs *RuleSet) EvaluateSet(hits *PatternSet) []string {
+	env := ruleEnv{hits: hits, categories: rs.categories}
+	var fired []string
+	for _, r := range rs.rules {
+		if r.When != nil && r.When.eval(&env) {
+			fired = append(fired, r.Name)
+		}
+	}
+	return fired
+}
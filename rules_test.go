@@ -0,0 +1,30 @@
+// rules_test.go: tests for the rule layer
+
+package ahocorasick
+
+import "testing"
+
+func TestRuleSet(t *testing.T) {
+	m := NewStringMatcher([]string{"buy", "cheap", "now", "pills", "hello"})
+	rs := NewRuleSet(m, map[string][]int{"spam": {0, 1, 2}, "drugs": {3}},
+		Rule{Name: "greeting", When: Pattern(4)},
+		Rule{Name: "spam", When: CategoryAtLeast("spam", 2)},
+		Rule{Name: "pharma", When: And(Category("drugs"), Category("spam"))},
+		Rule{Name: "clean", When: Not(Or(Category("spam"), Category("drugs")))},
+		Rule{Name: "two-of", When: AtLeast(2, Pattern(0), Pattern(3), Pattern(4))},
+	)
+
+	fired := rs.EvaluateString("hello there")
+	assert(t, len(fired) == 2)
+	assert(t, fired[0] == "greeting")
+	assert(t, fired[1] == "clean")
+
+	fired = rs.Evaluate([]byte("buy cheap pills now"))
+	assert(t, len(fired) == 3)
+	assert(t, fired[0] == "spam")
+	assert(t, fired[1] == "pharma")
+	assert(t, fired[2] == "two-of")
+
+	fired = rs.EvaluateString("buy it")
+	assert(t, len(fired) == 0)
+}
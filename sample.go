@@ -0,0 +1,82 @@
+// sample.go: screening very long inputs by scanning samples of them.
+
+package ahocorasick
+
+import "math/rand"
+
+// Sample selects the parts of a text scanned by a sampling screen: its
+// first Head bytes, its last Tail bytes and Windows windows of WindowSize
+// bytes at offsets drawn in between from Seed; bounds are moved to rune
+// starts, and negative sizes and counts are taken as zero
+// the same seed always samples the same windows, so inputs crafted to evade
+// the screen should be met with a random seed
+type Sample struct {
+	Head       int
+	Tail       int
+	Windows    int
+	WindowSize int
+	Seed       int64
+}
+
+// spans returns the byte ranges of text selected by s, the head first and
+// the tail last, or a single range covering the whole text when the sample
+// would cover all of it anyway
+func (s Sample) spans(text string) []Span {
+	n := len(text)
+	s.Head, s.Tail = max(s.Head, 0), max(s.Tail, 0)
+	if s.Windows <= 0 || s.WindowSize <= 0 {
+		s.Windows, s.WindowSize = 0, 0
+	}
+	// the sizes are compared without overflowing their sum
+	covered := s.Head >= n || s.Tail >= n-s.Head
+	if rest := n - s.Head - s.Tail; !covered && s.Windows > 0 {
+		covered = s.WindowSize >= rest || s.Windows >= (rest+s.WindowSize-1)/s.WindowSize
+	}
+	if covered {
+		return []Span{{Start: 0, End: n}}
+	}
+	spans := []Span{{Start: 0, End: runeStart(text, s.Head)}}
+	if s.Windows > 0 {
+		// windows start between the head and the tail
+		lo, hi := s.Head, n-s.Tail-s.WindowSize
+		r := rand.New(rand.NewSource(s.Seed))
+		for range s.Windows {
+			start := lo + r.Intn(hi-lo+1)
+			spans = append(spans, Span{Start: runeStart(text, start), End: runeStart(text, start+s.WindowSize)})
+		}
+	}
+	spans = append(spans, Span{Start: runeStart(text, n-s.Tail), End: n})
+	return spans
+}
+
+// ContainsSample reports whether any pattern occurs in the sample s of text,
+// every part of the sample being scanned on its own; it is a cheap first
+// pass over huge inputs, a false result does not mean the text is clean
+func (m *Matcher) ContainsSample(text []byte, s Sample) bool {
+	return m.ContainsSampleString(bytesToString(text), s)
+}
+
+// ContainsSampleString is like ContainsSample for a string input
+func (m *Matcher) ContainsSampleString(text string, s Sample) bool {
+	for _, sp := range s.spans(text) {
+		if m.ContainsString(text[sp.Start:sp.End]) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAllSampled screens text with ContainsSample and escalates to FindAll
+// over the whole text only when the sample hits, triaging huge uploads at
+// the cost of a few windows when they look clean; it returns nil otherwise
+func (m *Matcher) FindAllSampled(text []byte, s Sample) []Match {
+	return m.FindAllSampledString(bytesToString(text), s)
+}
+
+// FindAllSampledString is like FindAllSampled for a string input
+func (m *Matcher) FindAllSampledString(text string, s Sample) []Match {
+	if !m.ContainsSampleString(text, s) {
+		return nil
+	}
+	return m.FindAllString(text)
+}
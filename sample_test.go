@@ -0,0 +1,68 @@
+// sample_test.go: tests for sampling screens
+
+package ahocorasick
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSampleSpans(t *testing.T) {
+	text := strings.Repeat("x", 1000)
+	s := Sample{Head: 100, Tail: 50, Windows: 3, WindowSize: 20, Seed: 7}
+	spans := s.spans(text)
+	assert(t, len(spans) == 5)
+	assert(t, spans[0] == Span{Start: 0, End: 100})
+	assert(t, spans[4] == Span{Start: 950, End: 1000})
+	for _, sp := range spans[1:4] {
+		assert(t, sp.Start >= 100 && sp.End <= 950 && sp.End-sp.Start == 20)
+	}
+	// the same seed samples the same windows
+	again := s.spans(text)
+	for i := range spans {
+		assert(t, spans[i] == again[i])
+	}
+
+	// a sample covering the text scans all of it
+	spans = Sample{Head: 600, Tail: 600}.spans(text)
+	assert(t, len(spans) == 1 && spans[0] == Span{Start: 0, End: 1000})
+
+	// bounds are moved to rune starts
+	spans = Sample{Head: 2, Tail: 2}.spans("中文中文")
+	assert(t, spans[0] == Span{Start: 0, End: 0} && spans[1] == Span{Start: 9, End: 12})
+
+	// negative sizes and counts are taken as zero, huge ones cover the text
+	for _, s := range []Sample{
+		{Head: -5, Tail: -5, Windows: 3, WindowSize: -20},
+		{Head: 10, Tail: 10, Windows: -3, WindowSize: 20},
+		{Head: math.MinInt, Tail: math.MaxInt, Windows: math.MaxInt, WindowSize: math.MaxInt},
+	} {
+		spans = s.spans(text)
+		for _, sp := range spans {
+			assert(t, 0 <= sp.Start && sp.Start <= sp.End && sp.End <= len(text))
+		}
+	}
+	spans = Sample{Head: -5, Tail: -5, Windows: 3, WindowSize: -20}.spans(text)
+	assert(t, len(spans) == 2 && spans[0] == Span{Start: 0, End: 0} && spans[1] == Span{Start: 1000, End: 1000})
+	spans = Sample{Head: 10, Tail: 10, Windows: math.MaxInt / 2, WindowSize: math.MaxInt / 2}.spans(text)
+	assert(t, len(spans) == 1 && spans[0] == Span{Start: 0, End: 1000})
+}
+
+func TestContainsSample(t *testing.T) {
+	filler := strings.Repeat("-", 10000)
+	head := Sample{Head: 100, Tail: 100}
+	assert(t, precomputed.ContainsSampleString("Mac"+filler, head))
+	assert(t, precomputed.ContainsSampleString(filler+"Safari", head))
+	assert(t, !precomputed.ContainsSampleString(filler+"Safari"+filler, head))
+	assert(t, !precomputed.ContainsSample([]byte(filler), head))
+
+	// windows cover the middle
+	dense := strings.Repeat("Mac-------", 1000)
+	assert(t, precomputed.ContainsSampleString(filler[:100]+dense+filler[:100], Sample{Windows: 4, WindowSize: 64}))
+	assert(t, !precomputed.ContainsSampleString(filler+dense+filler, Sample{}))
+
+	found := precomputed.FindAllSampledString("Mac"+filler+"Safari"+filler, head)
+	assert(t, len(found) == 2 && found[1].Pattern == 3)
+	assert(t, precomputed.FindAllSampled([]byte(filler+"Safari"+filler), head) == nil)
+}
@@ -0,0 +1,54 @@
+// scratch.go: caller-owned matching state for allocation-free concurrent use.
+
+package ahocorasick
+
+// Scratch holds the state of one matching call: a bitset of the states
+// already reported and a buffer for the hits
+// a Scratch is not safe for concurrent use, allocate one per goroutine and
+// reuse it across calls; unlike MatchThreadSafe this involves no sync.Pool,
+// so allocation behavior is fully predictable
+type Scratch struct {
+	seen    []uint64 // one bit per deduplication key of the matcher
+	touched []uint32 // states whose bit is set, cleared at the end of a call
+	hits    []int
+}
+
+// NewScratch allocates a Scratch sized for m
+func (m *Matcher) NewScratch() *Scratch {
+	return &Scratch{
+		seen: make([]uint64, (m.dedupKeys()+63)/64),
+		hits: make([]int, 0, 8),
+	}
+}
+
+// MatchScratch is like MatchThreadSafe but keeps its state in s, the
+// returned slice is owned by s and only valid until its next use
+func (m *Matcher) MatchScratch(s *Scratch, text []byte) []int {
+	return m.MatchScratchString(s, bytesToString(text))
+}
+
+// MatchScratchString is like MatchThreadSafeString but keeps its state in s,
+// the returned slice is owned by s and only valid until its next use
+func (m *Matcher) MatchScratchString(s *Scratch, text string) []int {
+	if n := (m.dedupKeys() + 63) / 64; len(s.seen) < n {
+		// the scratch was created for a smaller matcher
+		s.seen = make([]uint64, n)
+	}
+	s.hits = m.match(s.hits[:0], text, func(st uint32, _ int32) bool {
+		w, bit := st/64, uint64(1)<<(st%64)
+		if s.seen[w]&bit != 0 {
+			return false
+		}
+		s.seen[w] |= bit
+		s.touched = append(s.touched, st)
+		return true
+	})
+
+	// only the bits set by this call are cleared, which keeps the cost
+	// proportional to the number of hits rather than to the automaton size
+	for _, st := range s.touched {
+		s.seen[st/64] = 0
+	}
+	s.touched = s.touched[:0]
+	return s.hits
+}
@@ -0,0 +1,53 @@
+// scratch_test.go: tests for caller-owned matching state
+
+package ahocorasick
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMatchScratch(t *testing.T) {
+	m := NewStringMatcher([]string{"a", "ab", "bc", "bca", "c", "caa"})
+	s := m.NewScratch()
+
+	hits := m.MatchScratch(s, []byte("abccab"))
+	assert(t, len(hits) == 4)
+	assert(t, hits[0] == 0)
+	assert(t, hits[1] == 1)
+	assert(t, hits[2] == 2)
+	assert(t, hits[3] == 4)
+
+	// the state of a previous call does not leak into the next one
+	hits = m.MatchScratchString(s, "bccb")
+	assert(t, len(hits) == 2)
+	assert(t, hits[0] == 2)
+	assert(t, hits[1] == 4)
+
+	// a scratch made for a smaller matcher grows as needed
+	hits = precomputed6.MatchScratch(s, bytes2)
+	assert(t, len(hits) == 105)
+}
+
+func TestMatchScratchConcurrently(t *testing.T) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := precomputed6.NewScratch()
+			for j := 0; j < 10; j++ {
+				assert(t, len(precomputed6.MatchScratch(s, bytes2)) == 105)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkLargeMatchScratch(b *testing.B) {
+	b.ReportAllocs()
+	s := precomputed6.NewScratch()
+	for i := 0; i < b.N; i++ {
+		precomputed6.MatchScratch(s, bytes2)
+	}
+}
@@ -0,0 +1,210 @@
+// segment.go: decomposition of a text into a concatenation of dictionary
+// words.
+
+package ahocorasick
+
+// CanSegment reports whether text is a concatenation of dictionary words,
+// an empty text being the empty concatenation
+// positions reachable from the start are marked left to right while the
+// trie is walked from every one of them, so it runs in O(n * longest word)
+func (m *Matcher) CanSegment(text []byte) bool {
+	return m.CanSegmentString(bytesToString(text))
+}
+
+// CanSegmentString is like CanSegment for a string input
+func (m *Matcher) CanSegmentString(text string) bool {
+	sc := m.segmenter(text)
+	reach := make([]bool, len(sc.text)+1)
+	reach[0] = true
+	for i := 0; i < len(sc.text); i++ {
+		if !reach[i] {
+			continue
+		}
+		sc.words(i, func(_ int32, end int) bool {
+			reach[end] = true
+			return true
+		})
+	}
+	return reach[len(sc.text)]
+}
+
+// Segmentations returns every way to write text as a concatenation of
+// dictionary words, each one as the list of its words with their offsets
+// in text; an empty text has a single, empty, segmentation
+// only positions from which the end can be reached are explored, but the
+// number of segmentations itself can grow exponentially with the length of
+// the text for dictionaries such as {"a", "aa"}
+func (m *Matcher) Segmentations(text []byte) [][]Match {
+	return m.SegmentationsString(bytesToString(text))
+}
+
+// SegmentationsString is like Segmentations for a string input
+func (m *Matcher) SegmentationsString(text string) [][]Match {
+	sc := m.segmenter(text)
+	n := len(sc.text)
+	next := make([][]Match, n)
+	for i := 0; i < n; i++ {
+		sc.words(i, func(pattern int32, end int) bool {
+			next[i] = append(next[i], Match{Pattern: int(pattern), Start: i, End: end})
+			return true
+		})
+	}
+	// good[i] reports whether the end of the text can be reached from i
+	good := make([]bool, n+1)
+	good[n] = true
+	for i := n - 1; i >= 0; i-- {
+		for _, w := range next[i] {
+			if good[w.End] {
+				good[i] = true
+				break
+			}
+		}
+	}
+	if !good[0] {
+		return nil
+	}
+
+	var all [][]Match
+	var path []Match
+	var walk func(i int)
+	walk = func(i int) {
+		if i == n {
+			all = append(all, sc.original(path))
+			return
+		}
+		for _, w := range next[i] {
+			if good[w.End] {
+				path = append(path, w)
+				walk(w.End)
+				path = path[:len(path)-1]
+			}
+		}
+	}
+	walk(0)
+	return all
+}
+
+// segmenter walks the trie over the text as the automaton sees it
+type segmenter struct {
+	m      *Matcher
+	source string     // the original text
+	text   string     // the transformed text
+	om     *OffsetMap // nil when the matcher has no transforms
+}
+
+// segmenter returns a segmenter for text
+func (m *Matcher) segmenter(text string) *segmenter {
+	sc := &segmenter{m: m, source: text, text: text}
+	if m.transformed() {
+		sc.text, sc.om = m.Transform(text)
+	}
+	return sc
+}
+
+// words calls fn with the pattern and the end offset of every dictionary
+// word starting at offset at of the transformed text, until fn returns false
+// only goto transitions are followed, the empty word is never reported
+func (sc *segmenter) words(at int, fn func(pattern int32, end int) bool) {
+	m := sc.m
+	off := m.disabledPatterns()
+	s := uint32(root)
+	for i := at; i < len(sc.text); {
+		r, size := decodeRune(sc.text, i)
+		i += size
+		c, ok := m.next(s, r)
+		if !ok {
+			return
+		}
+		s = c
+		out := m.outputs[s]
+		if out < 0 {
+			continue
+		}
+		if sc.om == nil {
+			if p := m.id(out); !off.has(p) && !fn(p, i) {
+				return
+			}
+			continue
+		}
+		// case flags are checked against the original span
+		start, end := sc.om.Original(at, i)
+		if !m.emit(out, sc.source, start, end, func(p int32, _, _ int) bool {
+			p = m.id(p)
+			return off.has(p) || fn(p, i)
+		}) {
+			return
+		}
+	}
+}
+
+// original converts words with offsets in the transformed text to a copy
+// with offsets in the original text
+func (sc *segmenter) original(words []Match) []Match {
+	out := make([]Match, len(words))
+	for k, w := range words {
+		out[k] = w
+		if sc.om != nil {
+			out[k].Start, out[k].End = sc.om.Original(w.Start, w.End)
+		}
+	}
+	return out
+}
+
+// Token is a piece of a text cut by Segment: a dictionary word, or a run of
+// text not covered by any word, whose Pattern is then -1
+type Token struct {
+	Pattern int // index of the word in the dictionary, -1 for unknown text
+	Start   int // offset of the first byte
+	End     int // offset just after the last byte
+}
+
+// Unknown reports whether the token is not a dictionary word
+func (t Token) Unknown() bool {
+	return t.Pattern < 0
+}
+
+// Segment cuts text into tokens covering it entirely, greedily taking the
+// longest dictionary word at every position and grouping the runes where no
+// word starts into unknown tokens, a simple tokenizer for domain glossaries
+// greedy cutting does not backtrack: with {"ab", "abc", "cd"} "abcd" gives
+// "abc" and the unknown "d", use Segmentations for exact decompositions
+func (m *Matcher) Segment(text []byte) []Token {
+	return m.SegmentString(bytesToString(text))
+}
+
+// SegmentString is like Segment for a string input
+func (m *Matcher) SegmentString(text string) []Token {
+	sc := m.segmenter(text)
+	var words []Match
+	for i := 0; i < len(sc.text); {
+		best := Match{Pattern: -1, End: i}
+		sc.words(i, func(pattern int32, end int) bool {
+			if end > best.End {
+				best = Match{Pattern: int(pattern), Start: i, End: end}
+			}
+			return true
+		})
+		if best.Pattern < 0 {
+			_, size := decodeRune(sc.text, i)
+			i += size
+			continue
+		}
+		words = append(words, best)
+		i = best.End
+	}
+
+	// the gaps between the words, in original offsets, are the unknown text
+	var tokens []Token
+	pos := 0
+	for _, w := range sc.original(words) {
+		if w.Start > pos {
+			tokens = append(tokens, Token{Pattern: -1, Start: pos, End: w.Start})
+		}
+		tokens = append(tokens, Token{Pattern: w.Pattern, Start: w.Start, End: w.End})
+		pos = w.End
+	}
+	if pos < len(text) {
+		tokens = append(tokens, Token{Pattern: -1, Start: pos, End: len(text)})
+	}
+	return tokens
+}
@@ -0,0 +1,81 @@
+// segment_test.go: tests for the segmentation of a text into words
+
+package ahocorasick
+
+import "testing"
+
+func TestCanSegment(t *testing.T) {
+	m := NewStringMatcher([]string{"apple", "pen", "applepen", "pine", "pineapple", "中文"})
+	assert(t, m.CanSegmentString("pineapplepenapple"))
+	assert(t, m.CanSegmentString("中文pen中文"))
+	assert(t, m.CanSegment([]byte("")))
+	assert(t, !m.CanSegmentString("pineapplepe"))
+	assert(t, !m.CanSegmentString("catsandog"))
+	assert(t, !m.CanSegmentString("pen "))
+}
+
+func TestSegmentations(t *testing.T) {
+	m := NewStringMatcher([]string{"apple", "pen", "applepen", "pine", "pineapple"})
+	all := m.SegmentationsString("pineapplepenapple")
+	assert(t, len(all) == 3)
+	// pine apple pen apple, pine applepen apple, pineapple pen apple
+	assert(t, len(all[0]) == 4 && all[0][0].Pattern == 3 && all[0][1] == Match{Pattern: 0, Start: 4, End: 9})
+	assert(t, len(all[1]) == 3 && all[1][1] == Match{Pattern: 2, Start: 4, End: 12})
+	assert(t, len(all[2]) == 3 && all[2][0] == Match{Pattern: 4, Start: 0, End: 9})
+	for _, seg := range all {
+		assert(t, seg[len(seg)-1].End == 17)
+	}
+
+	assert(t, m.SegmentationsString("catsandog") == nil)
+	all = m.Segmentations(nil)
+	assert(t, len(all) == 1 && len(all[0]) == 0)
+}
+
+func TestSegmentationsTransformed(t *testing.T) {
+	m := NewStringMatcher([]string{"cafe", "Au", "lait"}, WithCaseInsensitive(0, 2), WithDiacriticFolding())
+	all := m.SegmentationsString("CAFÉAuLAIT")
+	assert(t, len(all) == 1)
+	assert(t, all[0][0] == Match{Pattern: 0, Start: 0, End: 5})
+	assert(t, all[0][1] == Match{Pattern: 1, Start: 5, End: 7})
+	assert(t, all[0][2] == Match{Pattern: 2, Start: 7, End: 11})
+
+	// "Au" is case-sensitive
+	assert(t, !m.CanSegmentString("cafeaulait"))
+	assert(t, m.CanSegmentString("cafeAulait"))
+}
+
+func TestSegment(t *testing.T) {
+	m := NewStringMatcher([]string{"machine", "learning", "machine learning", "deep", "中文"})
+	text := "deep machine learning, 中文"
+	tokens := m.SegmentString(text)
+	assert(t, len(tokens) == 5)
+	assert(t, tokens[0] == Token{Pattern: 3, Start: 0, End: 4})
+	assert(t, tokens[1].Unknown() && text[tokens[1].Start:tokens[1].End] == " ")
+	assert(t, tokens[2] == Token{Pattern: 2, Start: 5, End: 21})
+	assert(t, tokens[3].Unknown() && text[tokens[3].Start:tokens[3].End] == ", ")
+	assert(t, tokens[4].Pattern == 4)
+	// the last unknown run
+	tokens = m.SegmentString(text + "!")
+	assert(t, len(tokens) == 6 && tokens[5].Unknown())
+
+	// greedy cutting does not backtrack
+	m = NewStringMatcher([]string{"ab", "abc", "cd"})
+	tokens = m.Segment([]byte("abcd"))
+	assert(t, len(tokens) == 2)
+	assert(t, tokens[0] == Token{Pattern: 1, Start: 0, End: 3})
+	assert(t, tokens[1] == Token{Pattern: -1, Start: 3, End: 4})
+
+	assert(t, m.SegmentString("") == nil)
+	tokens = m.SegmentString("xyz")
+	assert(t, len(tokens) == 1 && tokens[0] == Token{Pattern: -1, Start: 0, End: 3})
+}
+
+func TestSegmentTransformed(t *testing.T) {
+	m := NewStringMatcher([]string{"cafe"}, WithCaseInsensitive(0), WithDiacriticFolding())
+	text := "Café CAFÉ"
+	tokens := m.SegmentString(text)
+	assert(t, len(tokens) == 3)
+	assert(t, tokens[0] == Token{Pattern: 0, Start: 0, End: 6})
+	assert(t, tokens[1] == Token{Pattern: -1, Start: 6, End: 7})
+	assert(t, tokens[2] == Token{Pattern: 0, Start: 7, End: len(text)})
+}
@@ -0,0 +1,356 @@
+// serialize.go: saving a compiled automaton to a byte stream and loading it
+// back without rebuilding it from the dictionary.
+//
+// The format mirrors the in-memory layout of the automaton: a 16 byte header
+// (magic, version, state and edge counts) followed by the state array, the
+// output array and the edge array, every field being a little-endian 32 bit
+// integer. Links are
+// state IDs rather than pointers, so on little-endian hosts LoadBytes uses
+// the arrays directly from the byte slice without a deserialization pass.
+// Pattern metadata, when present, follows the edge array (see metadata.go).
+// The whole stream may optionally be wrapped by a compressor, which Load
+// detects from the leading bytes.
+
+package ahocorasick
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+const (
+	formatMagic   = "ACTRIE"
+	formatVersion = 3
+	headerSize    = 16
+	stateSize     = 16
+	outputSize    = 4
+	edgeSize      = 8
+)
+
+// the serialized layout is aliased as []state and []edge, so their in-memory
+// size must match the format exactly
+var (
+	_ [unsafe.Sizeof(state{}) - stateSize]struct{}
+	_ [stateSize - unsafe.Sizeof(state{})]struct{}
+	_ [unsafe.Sizeof(edge{}) - edgeSize]struct{}
+	_ [edgeSize - unsafe.Sizeof(edge{})]struct{}
+)
+
+// littleEndian reports whether the host stores integers little-endian, which
+// is required to use a serialized automaton without copying it
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+var (
+	// ErrInvalidFormat is returned by Load when the input is not a saved matcher
+	ErrInvalidFormat = errors.New("ahocorasick: invalid serialized matcher")
+
+	// ErrUnsupportedCompression is returned when a compression is selected or
+	// detected for which no codec has been registered
+	ErrUnsupportedCompression = errors.New("ahocorasick: unsupported compression")
+
+	// ErrNotSerializable is returned by Save for a matcher whose behavior
+	// depends on state the format cannot hold, such as per-pattern case flags,
+	// normalization, validators or positions
+	ErrNotSerializable = errors.New("ahocorasick: matcher cannot be serialized")
+)
+
+// Compression selects how a saved matcher is compressed
+type Compression int
+
+const (
+	CompressionNone Compression = iota // store the automaton uncompressed
+	CompressionGzip                    // gzip, always available
+	CompressionZstd                    // zstd, requires RegisterCompression
+)
+
+// codec describes how a compression is detected, written and read
+type codec struct {
+	magic     string
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[Compression]codec{
+		CompressionGzip: {
+			magic:     "\x1f\x8b",
+			newWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+			newReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		},
+		// the zstd frame magic is known so Load can report a missing codec
+		// precisely, the codec itself has to be supplied by the application
+		CompressionZstd: {magic: "\x28\xb5\x2f\xfd"},
+	}
+)
+
+// RegisterCompression installs the codec used for compression c, magic is the
+// byte sequence every compressed stream starts with and is used by Load to
+// detect it
+// this keeps the package free of dependencies while allowing e.g. a zstd
+// implementation to be plugged in by the application
+func RegisterCompression(c Compression, magic string,
+	newWriter func(w io.Writer) (io.WriteCloser, error),
+	newReader func(r io.Reader) (io.ReadCloser, error)) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c] = codec{magic: magic, newWriter: newWriter, newReader: newReader}
+}
+
+// SaveOption configures Save
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	compression Compression
+}
+
+// WithCompression makes Save compress its output with c
+func WithCompression(c Compression) SaveOption {
+	return func(o *saveOptions) {
+		o.compression = c
+	}
+}
+
+// Save writes the compiled automaton to w so it can be restored with Load
+func (m *Matcher) Save(w io.Writer, opts ...SaveOption) error {
+	var o saveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if m.transformed() || m.validators != nil || m.positions != nil {
+		return ErrNotSerializable
+	}
+	if o.compression == CompressionNone {
+		return m.encode(w)
+	}
+
+	codecsMu.RLock()
+	c, ok := codecs[o.compression]
+	codecsMu.RUnlock()
+	if !ok || c.newWriter == nil {
+		return fmt.Errorf("%w: %d", ErrUnsupportedCompression, o.compression)
+	}
+	cw, err := c.newWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := m.encode(cw); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+// LoadOption configures Load and LoadBytes
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	trusted bool
+}
+
+// WithoutValidation skips the consistency check of the loaded automaton, making
+// LoadBytes constant time. Only use it for data produced by Save that cannot
+// have been corrupted or tampered with, invalid links make matching panic
+func WithoutValidation() LoadOption {
+	return func(o *loadOptions) {
+		o.trusted = true
+	}
+}
+
+// Load restores a matcher written by Save, the compression used when saving
+// is detected automatically
+func Load(r io.Reader, opts ...LoadOption) (*Matcher, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(formatMagic))
+	if err != nil && len(head) == 0 {
+		return nil, ErrInvalidFormat
+	}
+	if string(head) == formatMagic {
+		return loadAll(br, opts)
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for _, c := range codecs {
+		if !strings.HasPrefix(string(head), c.magic) {
+			continue
+		}
+		if c.newReader == nil {
+			return nil, ErrUnsupportedCompression
+		}
+		cr, err := c.newReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer cr.Close()
+		return loadAll(cr, opts)
+	}
+	return nil, ErrInvalidFormat
+}
+
+// loadAll reads the whole uncompressed automaton into a buffer owned by the
+// returned matcher
+func loadAll(r io.Reader, opts []LoadOption) (*Matcher, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return LoadBytes(data, opts...)
+}
+
+// LoadBytes restores a matcher from the uncompressed output of Save
+// on little-endian hosts the matcher uses data in place without copying it,
+// so data must not be modified afterwards; it may be a read-only mapping
+func LoadBytes(data []byte, opts ...LoadOption) (*Matcher, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(data) < headerSize || string(data[:len(formatMagic)]) != formatMagic {
+		return nil, ErrInvalidFormat
+	}
+	version := data[len(formatMagic)]
+	if version != formatVersion && version != metaFormatVersion {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrInvalidFormat, version)
+	}
+	nstates := uint64(binary.LittleEndian.Uint32(data[8:]))
+	nedges := uint64(binary.LittleEndian.Uint32(data[12:]))
+	size := headerSize + nstates*(stateSize+outputSize) + nedges*edgeSize
+	if nstates == 0 || uint64(len(data)) < size || (version == formatVersion && uint64(len(data)) != size) {
+		return nil, ErrInvalidFormat
+	}
+	meta := data[size:]
+	data = data[:size]
+	body := data[headerSize:]
+	outputData := body[nstates*stateSize:]
+	edgeData := outputData[nstates*outputSize:]
+
+	m := new(Matcher)
+	if littleEndian && uintptr(unsafe.Pointer(&body[0]))%4 == 0 {
+		// zero-copy: the arrays are used directly from the byte slice
+		m.states = unsafe.Slice((*state)(unsafe.Pointer(&body[0])), nstates)
+		m.outputs = unsafe.Slice((*int32)(unsafe.Pointer(&outputData[0])), nstates)
+		if nedges > 0 {
+			m.edges = unsafe.Slice((*edge)(unsafe.Pointer(&edgeData[0])), nedges)
+		}
+		m.data = data
+	} else {
+		m.states = make([]state, nstates)
+		for i := range m.states {
+			b := body[i*stateSize:]
+			m.states[i] = state{
+				edges:  binary.LittleEndian.Uint32(b),
+				nedges: binary.LittleEndian.Uint32(b[4:]),
+				fail:   binary.LittleEndian.Uint32(b[8:]),
+				suffix: binary.LittleEndian.Uint32(b[12:]),
+			}
+		}
+		m.outputs = make([]int32, nstates)
+		for i := range m.outputs {
+			m.outputs[i] = int32(binary.LittleEndian.Uint32(outputData[i*outputSize:]))
+		}
+		m.edges = make([]edge, nedges)
+		for i := range m.edges {
+			b := edgeData[i*edgeSize:]
+			m.edges[i] = edge{
+				label: rune(binary.LittleEndian.Uint32(b)),
+				next:  binary.LittleEndian.Uint32(b[4:]),
+			}
+		}
+	}
+
+	if version == metaFormatVersion {
+		if err := m.decodeMetadata(meta); err != nil {
+			return nil, err
+		}
+	}
+	if !o.trusted && !m.valid() {
+		return nil, ErrInvalidFormat
+	}
+	m.initSkip()
+	return m, nil
+}
+
+// valid checks every link of the automaton so corrupt input cannot make
+// matching index out of range or loop forever: states are numbered in
+// breadth-first order, so fail and suffix links must point backwards
+func (m *Matcher) valid() bool {
+	nstates, nedges := uint64(len(m.states)), uint64(len(m.edges))
+	for i := range m.states {
+		s := &m.states[i]
+		if uint64(s.edges)+uint64(s.nedges) > nedges || m.outputs[i] < -1 {
+			return false
+		}
+		if i == root && (s.fail != root || s.suffix != root) {
+			return false // the links of the root lead back to it
+		}
+		if i > 0 && (s.fail >= uint32(i) || s.suffix >= uint32(i)) {
+			return false
+		}
+		if s.suffix != root && m.outputs[s.suffix] < 0 {
+			return false
+		}
+		if m.npatterns > 0 && int(m.outputs[i]) >= m.npatterns {
+			return false
+		}
+		if m.ids != nil && int(m.outputs[i]) >= len(m.ids) {
+			return false
+		}
+	}
+	for _, e := range m.edges {
+		if uint64(e.next) >= nstates || e.next == root {
+			return false
+		}
+	}
+	return true
+}
+
+// encode writes the header, the states and the edges of the automaton
+func (m *Matcher) encode(dst io.Writer) error {
+	w := bufio.NewWriter(dst)
+	var buf [stateSize]byte
+	copy(buf[:], formatMagic)
+	buf[len(formatMagic)] = formatVersion
+	if m.hasMetadata() {
+		buf[len(formatMagic)] = metaFormatVersion
+	}
+	binary.LittleEndian.PutUint32(buf[8:], uint32(len(m.states)))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(len(m.edges)))
+	w.Write(buf[:headerSize])
+
+	for i := range m.states {
+		s := &m.states[i]
+		b := buf[:0]
+		b = binary.LittleEndian.AppendUint32(b, s.edges)
+		b = binary.LittleEndian.AppendUint32(b, s.nedges)
+		b = binary.LittleEndian.AppendUint32(b, m.failLink(uint32(i)))
+		b = binary.LittleEndian.AppendUint32(b, m.suffixLink(uint32(i)))
+		w.Write(b)
+	}
+	for _, out := range m.outputs {
+		w.Write(binary.LittleEndian.AppendUint32(buf[:0], uint32(out)))
+	}
+	for _, e := range m.edges {
+		b := buf[:0]
+		b = binary.LittleEndian.AppendUint32(b, uint32(e.label))
+		b = binary.LittleEndian.AppendUint32(b, e.next)
+		w.Write(b)
+	}
+	if m.hasMetadata() {
+		if err := m.encodeMetadata(w); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
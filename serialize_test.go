@@ -0,0 +1,100 @@
+// serialize_test.go: tests for saving and loading matchers
+
+package ahocorasick
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func roundTrip(t *testing.T, m *Matcher, opts ...SaveOption) *Matcher {
+	var buf strings.Builder
+	if err := m.Save(&buf, opts...); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func TestSaveLoad(t *testing.T) {
+	m := roundTrip(t, NewStringMatcher([]string{"a", "ab", "bc", "bca", "c", "caa", "中文"}))
+	hits := m.Match([]byte("abccab"))
+	assert(t, len(hits) == 4)
+	assert(t, hits[0] == 0)
+	assert(t, hits[1] == 1)
+	assert(t, hits[2] == 2)
+	assert(t, hits[3] == 4)
+
+	hits = m.MatchThreadSafe([]byte("这是中文"))
+	assert(t, len(hits) == 1)
+	assert(t, hits[0] == 6)
+
+	m = roundTrip(t, NewStringMatcher([]string{}))
+	assert(t, !m.ContainsString("foo"))
+}
+
+func TestSaveLoadCompressed(t *testing.T) {
+	var plain, zipped strings.Builder
+	assert(t, precomputed6.Save(&plain) == nil)
+	assert(t, precomputed6.Save(&zipped, WithCompression(CompressionGzip)) == nil)
+	assert(t, zipped.Len() < plain.Len())
+
+	m, err := Load(strings.NewReader(zipped.String()))
+	assert(t, err == nil)
+	assert(t, len(m.MatchThreadSafe(bytes2)) == 105)
+}
+
+func TestSaveLoadErrors(t *testing.T) {
+	err := precomputed.Save(io.Discard, WithCompression(CompressionZstd))
+	assert(t, errors.Is(err, ErrUnsupportedCompression))
+
+	_, err = Load(strings.NewReader("\x28\xb5\x2f\xfd0000"))
+	assert(t, errors.Is(err, ErrUnsupportedCompression))
+
+	_, err = Load(strings.NewReader("not a matcher"))
+	assert(t, errors.Is(err, ErrInvalidFormat))
+
+	var buf strings.Builder
+	assert(t, precomputed.Save(&buf) == nil)
+	_, err = Load(strings.NewReader(buf.String()[:buf.Len()/2]))
+	assert(t, errors.Is(err, ErrInvalidFormat))
+}
+
+func TestLoadBytes(t *testing.T) {
+	var buf strings.Builder
+	assert(t, precomputed6.Save(&buf) == nil)
+	data := []byte(buf.String())
+
+	m, err := LoadBytes(data)
+	assert(t, err == nil)
+	assert(t, len(m.MatchThreadSafe(bytes2)) == 105)
+	assert(t, len(m.Match(bytes2)) == 105)
+
+	// a misaligned buffer is decoded into a copy instead of used in place
+	shifted := make([]byte, len(data)+1)
+	copy(shifted[1:], data)
+	m, err = LoadBytes(shifted[1:], WithoutValidation())
+	assert(t, err == nil)
+	assert(t, len(m.Match(bytes2)) == 105)
+
+	// a fail link pointing forward would make matching loop forever
+	corrupt := append([]byte(nil), data...)
+	corrupt[headerSize+stateSize+8] = 0xff
+	_, err = LoadBytes(corrupt)
+	assert(t, errors.Is(err, ErrInvalidFormat))
+
+	// so would links of the root leading anywhere but to itself, and a
+	// suffix link past the states is not followed to its output
+	for _, off := range []int{8, 12} {
+		corrupt = append([]byte(nil), data...)
+		binary.LittleEndian.PutUint32(corrupt[headerSize+off:], 1000)
+		_, err = LoadBytes(corrupt)
+		assert(t, errors.Is(err, ErrInvalidFormat))
+	}
+}
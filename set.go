@@ -0,0 +1,98 @@
+// set.go: matching into a compact set of pattern indices.
+
+package ahocorasick
+
+import "math/bits"
+
+// PatternSet is a compact bitset of pattern indices, the zero value is empty
+// it records which patterns occurred regardless of order and repetitions,
+// which is what boolean rule evaluation needs
+type PatternSet struct {
+	words []uint64
+}
+
+// Add inserts pattern i into the set
+func (s *PatternSet) Add(i int) {
+	w := i / 64
+	if w >= len(s.words) {
+		s.words = append(s.words, make([]uint64, w+1-len(s.words))...)
+	}
+	s.words[w] |= 1 << (uint(i) % 64)
+}
+
+// Has reports whether pattern i is in the set
+func (s *PatternSet) Has(i int) bool {
+	w := i / 64
+	return i >= 0 && w < len(s.words) && s.words[w]&(1<<(uint(i)%64)) != 0
+}
+
+// Len returns the number of patterns in the set
+func (s *PatternSet) Len() int {
+	n := 0
+	for _, w := range s.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Indices returns the patterns of the set in increasing order
+func (s *PatternSet) Indices() []int {
+	indices := make([]int, 0, s.Len())
+	for i, w := range s.words {
+		for w != 0 {
+			indices = append(indices, i*64+bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+	return indices
+}
+
+// Reset empties the set, keeping its storage for reuse
+func (s *PatternSet) Reset() {
+	clear(s.words)
+}
+
+// MatchSet searches input byte slice and returns the set of dictionary patterns it contains
+// it is safe for concurrent use
+func (m *Matcher) MatchSet(text []byte) *PatternSet {
+	return m.MatchSetString(bytesToString(text))
+}
+
+// MatchSetString searches input string and returns the set of dictionary patterns it contains
+// it is safe for concurrent use
+func (m *Matcher) MatchSetString(text string) *PatternSet {
+	s := new(PatternSet)
+	m.MatchSetInto(s, text)
+	return s
+}
+
+// MatchSetInto adds the dictionary patterns contained in text to s, so a set
+// can be reused across calls after Reset
+func (m *Matcher) MatchSetInto(s *PatternSet, text string) {
+	if m.overlap != ReportAll {
+		for _, o := range m.resolveOverlaps(m.collect(text)) {
+			s.Add(o.Pattern)
+		}
+		return
+	}
+	if m.generic() {
+		m.find(text, func(out int32, _, _ int) bool {
+			s.Add(int(out))
+			return true
+		})
+		return
+	}
+	off := m.disabledPatterns()
+	n := uint32(root)
+	for _, r := range text {
+		n = m.step(n, r)
+		if out := m.outputs[n]; out >= 0 && !off.has(out) {
+			s.Add(int(out))
+		}
+		for f := m.suffixLink(n); f != root; f = m.suffixLink(f) {
+			if out := m.outputs[f]; !off.has(out) {
+				s.Add(int(out))
+			}
+		}
+	}
+}
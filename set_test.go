@@ -0,0 +1,44 @@
+// set_test.go: tests for matching into pattern sets
+
+package ahocorasick
+
+import "testing"
+
+func TestPatternSet(t *testing.T) {
+	var s PatternSet
+	assert(t, s.Len() == 0)
+	assert(t, !s.Has(3))
+	s.Add(3)
+	s.Add(130)
+	s.Add(3)
+	assert(t, s.Has(3))
+	assert(t, s.Has(130))
+	assert(t, !s.Has(-1))
+	assert(t, s.Len() == 2)
+	indices := s.Indices()
+	assert(t, len(indices) == 2)
+	assert(t, indices[0] == 3)
+	assert(t, indices[1] == 130)
+	s.Reset()
+	assert(t, s.Len() == 0)
+}
+
+func TestMatchSet(t *testing.T) {
+	s := precomputed.MatchSet(bytes)
+	assert(t, s.Len() == 4)
+	assert(t, s.Has(0))
+	assert(t, s.Has(1))
+	assert(t, s.Has(2))
+	assert(t, s.Has(3))
+	assert(t, !s.Has(4))
+
+	s = precomputed6.MatchSetString(sbytes2)
+	assert(t, s.Len() == 105)
+
+	s.Reset()
+	precomputed.MatchSetInto(s, "Safari Mac")
+	indices := s.Indices()
+	assert(t, len(indices) == 2)
+	assert(t, indices[0] == 1)
+	assert(t, indices[1] == 3)
+}
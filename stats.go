@@ -0,0 +1,119 @@
+// stats.go: statistics of a build, for monitoring dictionary growth.
+
+package ahocorasick
+
+import (
+	"runtime"
+	"time"
+	"unicode/utf8"
+)
+
+// BuildStats describes the build of a matcher made with WithBuildStats
+type BuildStats struct {
+	Patterns    int // patterns in the dictionary
+	Runes       int // runes over all patterns, as inserted into the trie
+	States      int // states of the automaton, root included
+	Transitions int // transitions between states
+
+	Prepare time.Duration // limits, normalization, case folding and option checks
+	Trie    time.Duration // insertion of the patterns into the trie, streaming included
+	Links   time.Duration // fail and suffix links and the frozen layout
+	Setup   time.Duration // filters, prefilters and other options
+	Total   time.Duration
+
+	// AllocatedBytes and Allocations count the heap allocations made during
+	// the build, by every goroutine of the process, so they are exact for a
+	// build running alone; AllocatedBytes bounds the peak memory of the build
+	AllocatedBytes uint64
+	Allocations    uint64
+}
+
+// WithBuildStats makes the matcher record statistics of its build, returned
+// by BuildStats; measuring allocations briefly stops the world twice, which
+// is negligible next to any real build
+func WithBuildStats() Option {
+	return func(o *options) {
+		o.buildStats = true
+	}
+}
+
+// BuildStats returns the statistics of the build of m, false unless it was
+// built with WithBuildStats
+func (m *Matcher) BuildStats() (BuildStats, bool) {
+	if m.build == nil {
+		return BuildStats{}, false
+	}
+	return m.build.stats, true
+}
+
+// build phases timed by a buildRecorder
+const (
+	phasePrepare = iota
+	phaseTrie
+	phaseLinks
+	phaseSetup
+)
+
+// buildRecorder measures a build, a nil recorder measuring nothing
+type buildRecorder struct {
+	stats BuildStats
+	start time.Time
+	last  time.Time // end of the previous phase
+	mem   runtime.MemStats
+}
+
+// newBuildRecorder starts measuring a build, nil unless o asks for it
+func newBuildRecorder(o *options) *buildRecorder {
+	if !o.buildStats {
+		return nil
+	}
+	r := new(buildRecorder)
+	runtime.ReadMemStats(&r.mem)
+	r.start = time.Now()
+	r.last = r.start
+	return r
+}
+
+// lap ends phase
+func (r *buildRecorder) lap(phase int) {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	d := now.Sub(r.last)
+	r.last = now
+	switch phase {
+	case phasePrepare:
+		r.stats.Prepare += d
+	case phaseTrie:
+		r.stats.Trie += d
+	case phaseLinks:
+		r.stats.Links += d
+	case phaseSetup:
+		r.stats.Setup += d
+	}
+}
+
+// count records a pattern as inserted into the trie
+func (r *buildRecorder) count(word string) {
+	if r != nil {
+		r.stats.Runes += utf8.RuneCountInString(word)
+	}
+}
+
+// done ends the last phase and completes the statistics of m
+func (r *buildRecorder) done(m *Matcher) {
+	if r == nil {
+		return
+	}
+	r.lap(phaseSetup)
+	s := &r.stats
+	s.Total = r.last.Sub(r.start)
+	s.Patterns = m.npatterns
+	s.States, s.Transitions = len(m.states), len(m.edges)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	s.AllocatedBytes = mem.TotalAlloc - r.mem.TotalAlloc
+	s.Allocations = mem.Mallocs - r.mem.Mallocs
+}
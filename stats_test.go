@@ -0,0 +1,32 @@
+// stats_test.go: tests for build statistics
+
+package ahocorasick
+
+import "testing"
+
+func TestBuildStats(t *testing.T) {
+	_, ok := precomputed.BuildStats()
+	assert(t, !ok)
+
+	m := NewStringMatcher(dictionary, WithBuildStats())
+	s, ok := m.BuildStats()
+	assert(t, ok && s.Patterns == 5 && s.Runes == 7+3+9+6+7)
+	assert(t, s.States == len(precomputed.states) && s.Transitions == len(precomputed.edges))
+	assert(t, s.Total > 0 && s.Total == s.Prepare+s.Trie+s.Links+s.Setup)
+	assert(t, s.AllocatedBytes > 0 && s.Allocations > 0)
+
+	// runes are counted as inserted, after normalization
+	m = NewStringMatcher([]string{"a  b"}, WithWhitespaceCollapsing(), WithBuildStats())
+	s, _ = m.BuildStats()
+	assert(t, s.Runes == 3)
+
+	m, err := CompileSeq(seqOf(dictionary), WithBuildStats())
+	assert(t, err == nil)
+	s, ok = m.BuildStats()
+	assert(t, ok && s.Patterns == 5 && s.Runes == 32 && s.States == len(precomputed.states))
+
+	// a rebuild without the option drops the statistics
+	assert(t, m.Rebuild(dictionary) == nil)
+	_, ok = m.BuildStats()
+	assert(t, !ok)
+}
@@ -0,0 +1,92 @@
+// stream.go: building a matcher from patterns streamed by an iterator or a
+// channel.
+
+package ahocorasick
+
+// CompileSeq builds a matcher like Compile from the patterns yielded by seq,
+// numbered in the order they are yielded; seq has the shape of
+// iter.Seq[string], so dictionaries streamed from a database cursor are
+// inserted into the trie one at a time and never need to exist as a slice
+// WithCaseInsensitive and WithBloomFilter need the whole dictionary, with
+// them the patterns are collected first
+func CompileSeq(seq func(yield func(string) bool), opts ...Option) (*Matcher, error) {
+	o := newOptions(opts)
+	if len(o.caseInsensitive) > 0 || o.bloomFilter {
+		var words []string
+		seq(func(word string) bool {
+			words = append(words, word)
+			return true
+		})
+		return Compile(words, opts...)
+	}
+
+	m := &Matcher{build: newBuildRecorder(&o)}
+	if len(o.normalizers) > 0 {
+		m.norm = pipeline(o.normalizers)
+		m.normKinds = o.normalizerKinds
+	}
+	if len(o.equivalence) > 0 {
+		equiv, err := newEquivalence(o.equivalence)
+		if err != nil {
+			return nil, err
+		}
+		m.equiv = equiv
+	}
+	m.build.lap(phasePrepare)
+	b := newTrieBuilder(0)
+	limits := limitChecker{o: &o}
+	progress := newProgress(&o, PhaseInsert, -1)
+	var err error
+	seq(func(word string) bool {
+		if err = limits.check(m.npatterns, word); err != nil {
+			return false
+		}
+		if m.norm != nil {
+			word = m.norm.apply(word)
+		}
+		if m.equiv != nil {
+			word = m.equiv.canonical(word)
+		}
+		b.insert(word, int32(m.npatterns))
+		if err = limits.checkNodes(m.npatterns, word, len(b.trie)); err != nil {
+			return false
+		}
+		m.build.count(word)
+		m.npatterns++
+		err = progress.step(m.npatterns)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	progress.end(m.npatterns)
+	po, err := newPatternOptions(&o, m.npatterns)
+	if err != nil {
+		return nil, err
+	}
+	m.build.lap(phaseTrie)
+	if err := b.finish(m, &o); err != nil {
+		return nil, err
+	}
+	if m.equiv != nil {
+		m.addAliases()
+	}
+	m.build.lap(phaseLinks)
+	fitBackend(m, &o)
+	m.setup(&o, po, m.npatterns)
+	m.build.done(m)
+	return m, nil
+}
+
+// CompileChan builds a matcher like CompileSeq from the patterns received on
+// ch until it is closed; when an error stops the build early the remaining
+// patterns are not drained, so producers should be able to give up
+func CompileChan(ch <-chan string, opts ...Option) (*Matcher, error) {
+	return CompileSeq(func(yield func(string) bool) {
+		for word := range ch {
+			if !yield(word) {
+				return
+			}
+		}
+	}, opts...)
+}
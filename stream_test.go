@@ -0,0 +1,73 @@
+// stream_test.go: tests for building matchers from streamed patterns
+
+package ahocorasick
+
+import (
+	"errors"
+	"testing"
+)
+
+// seqOf yields the words of a slice, like slices.Values
+func seqOf(words []string) func(yield func(string) bool) {
+	return func(yield func(string) bool) {
+		for _, w := range words {
+			if !yield(w) {
+				return
+			}
+		}
+	}
+}
+
+func TestCompileSeq(t *testing.T) {
+	m, err := CompileSeq(seqOf(dictionary6))
+	assert(t, err == nil)
+	assert(t, len(m.states) == len(precomputed6.states))
+	assert(t, len(m.Match(bytes2)) == 105)
+	assert(t, len(m.FindAll(bytes2)) == len(precomputed6.FindAll(bytes2)))
+
+	m, err = CompileSeq(seqOf(nil))
+	assert(t, err == nil && !m.ContainsString("anything"))
+
+	m, err = CompileSeq(seqOf([]string{"café"}), WithDiacriticFolding(), WithHitCounters())
+	assert(t, err == nil && m.ContainsString("cafe"))
+	m.MatchString("cafe")
+	assert(t, m.Hits().Snapshot()[0] == 1)
+
+	// options needing the whole dictionary collect it first
+	m, err = CompileSeq(seqOf([]string{"Go"}), WithCaseInsensitive(0))
+	assert(t, err == nil && m.ContainsString("GO"))
+}
+
+func TestCompileSeqLimits(t *testing.T) {
+	yielded := 0
+	seq := func(yield func(string) bool) {
+		for _, w := range dictionary6 {
+			yielded++
+			if !yield(w) {
+				return
+			}
+		}
+	}
+	_, err := CompileSeq(seq, WithMaxPatterns(3))
+	assert(t, errors.Is(err, ErrLimitExceeded))
+	assert(t, yielded == 4)
+
+	_, err = CompileSeq(seqOf([]string{"ab", "abcdef"}), WithMaxPatternLength(4))
+	var le *LimitError
+	assert(t, errors.As(err, &le) && le.Index == 1)
+}
+
+func TestCompileChan(t *testing.T) {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, w := range dictionary {
+			ch <- w
+		}
+	}()
+	m, err := CompileChan(ch)
+	assert(t, err == nil)
+	hits := m.MatchString(sbytes)
+	assert(t, len(hits) == 4)
+	assert(t, hits[0] == 0 && hits[3] == 3)
+}
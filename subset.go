@@ -0,0 +1,107 @@
+// subset.go: matching restricted to a subset of the patterns at query time.
+
+package ahocorasick
+
+// Subset is a view of a matcher that only reports the patterns of a subset,
+// so per-tenant policies can share one compiled automaton; the other
+// patterns are ignored as if they were not in the dictionary, including by
+// the overlap policy
+// a Subset is immutable and safe for concurrent use
+type Subset struct {
+	m        *Matcher
+	allowed  *PatternSet // nil when no index restriction applies
+	excluded uint64      // categories whose patterns are ignored
+}
+
+// Subset returns a view of the matcher restricted to the allowed patterns,
+// indices out of range are ignored; a pattern duplicated in the dictionary
+// is reported under its last index, which must be allowed
+func (m *Matcher) Subset(allowed []int) *Subset {
+	s := &Subset{m: m, allowed: new(PatternSet)}
+	for _, p := range allowed {
+		if p >= 0 {
+			s.allowed.Add(p)
+		}
+	}
+	return s
+}
+
+// allows reports whether pattern p is reported by the view
+func (s *Subset) allows(p int) bool {
+	if s.allowed != nil && !s.allowed.Has(p) {
+		return false
+	}
+	return s.excluded == 0 || s.m.categories.masks[p]&s.excluded == 0
+}
+
+// MatchSubset is like Match but only reports the allowed patterns, use
+// Subset to restrict many calls to the same patterns
+func (m *Matcher) MatchSubset(text []byte, allowed []int) []int {
+	return m.Subset(allowed).Match(text)
+}
+
+// MatchSubsetString is like MatchSubset for a string input
+func (m *Matcher) MatchSubsetString(text string, allowed []int) []int {
+	return m.Subset(allowed).MatchString(text)
+}
+
+// Match returns the allowed patterns occurring in text, each once, in the
+// order Match of the matcher would report them
+func (s *Subset) Match(text []byte) []int {
+	return s.MatchString(bytesToString(text))
+}
+
+// MatchString is like Match for a string input
+func (s *Subset) MatchString(text string) []int {
+	var seen PatternSet
+	var hits []int
+	for _, o := range s.collect(text) {
+		if !seen.Has(o.Pattern) {
+			seen.Add(o.Pattern)
+			s.m.hits.add(int32(o.Pattern))
+			hits = append(hits, o.Pattern)
+		}
+	}
+	return hits
+}
+
+// Contains reports whether any allowed pattern occurs in text
+func (s *Subset) Contains(text []byte) bool {
+	return s.ContainsString(bytesToString(text))
+}
+
+// ContainsString is like Contains for a string input
+func (s *Subset) ContainsString(text string) bool {
+	found := false
+	s.m.find(text, func(out int32, _, _ int) bool {
+		found = s.allows(int(out))
+		return !found
+	})
+	return found
+}
+
+// FindAll returns every occurrence of the allowed patterns in text, like
+// FindAll of the matcher
+func (s *Subset) FindAll(text []byte) []Match {
+	return s.FindAllString(bytesToString(text))
+}
+
+// FindAllString is like FindAll for a string input
+func (s *Subset) FindAllString(text string) []Match {
+	matches := s.collect(text)
+	s.m.countHits(matches)
+	return matches
+}
+
+// collect returns the occurrences of the allowed patterns after overlap
+// resolution
+func (s *Subset) collect(text string) []Match {
+	var matches []Match
+	s.m.find(text, func(out int32, start, end int) bool {
+		if s.allows(int(out)) {
+			matches = append(matches, Match{Pattern: int(out), Start: start, End: end})
+		}
+		return true
+	})
+	return s.m.resolveOverlaps(matches)
+}
@@ -0,0 +1,46 @@
+// subset_test.go: tests for matching restricted to a subset of patterns
+
+package ahocorasick
+
+import "testing"
+
+func TestSubset(t *testing.T) {
+	full := precomputed.MatchString(sbytes)
+	assert(t, len(full) == 4)
+
+	s := precomputed.Subset([]int{1, 3, 4, 99, -1})
+	hits := s.MatchString(sbytes)
+	assert(t, len(hits) == 2)
+	assert(t, hits[0] == 1)
+	assert(t, hits[1] == 3)
+	assert(t, s.Contains(bytes))
+	assert(t, !precomputed.Subset([]int{4}).ContainsString(sbytes))
+	assert(t, len(precomputed.Subset(nil).Match(bytes)) == 0)
+
+	matches := s.FindAll(bytes)
+	assert(t, len(matches) == 3)
+	for _, o := range matches {
+		assert(t, o.Pattern == 1 || o.Pattern == 3)
+	}
+
+	hits = precomputed.MatchSubsetString(sbytes, []int{2})
+	assert(t, len(hits) == 1 && hits[0] == 2)
+	hits = precomputed.MatchSubset(bytes, []int{0, 2})
+	assert(t, len(hits) == 2 && hits[0] == 0 && hits[1] == 2)
+}
+
+func TestSubsetOverlap(t *testing.T) {
+	// disallowed patterns do not shadow allowed ones
+	m := NewStringMatcher([]string{"New York", "York"}, WithOverlapPolicy(LongestWins))
+	assert(t, len(m.MatchString("New York")) == 1)
+	hits := m.Subset([]int{1}).MatchString("New York")
+	assert(t, len(hits) == 1 && hits[0] == 1)
+}
+
+func TestSubsetHits(t *testing.T) {
+	m := NewStringMatcher(dictionary, WithHitCounters())
+	m.Subset([]int{0}).MatchString(sbytes)
+	counts := m.Hits().Snapshot()
+	assert(t, counts[0] == 1)
+	assert(t, counts[1] == 0 && counts[2] == 0 && counts[3] == 0)
+}
@@ -0,0 +1,148 @@
+// suggest.go: "did you mean" lookups over the dictionary of a matcher.
+
+package ahocorasick
+
+import "sort"
+
+// Suggestion is a dictionary entry close to the word given to Suggest
+type Suggestion struct {
+	Pattern  int    // index of the entry in the dictionary
+	Word     string // the entry after normalization
+	Distance int    // edit distance between the word and the entry
+}
+
+// Suggest returns the dictionary entries within maxDistance edits
+// (insertions, deletions or substitutions of a rune) of word, closest first
+// and by pattern index on ties
+// the trie is walked depth-first while one row of the edit distance matrix
+// is kept per level, so branches that cannot get close enough are pruned
+// the word goes through the same normalization as the patterns and is
+// compared ignoring case to the case-insensitive ones
+func (m *Matcher) Suggest(word string, maxDistance int) []Suggestion {
+	if maxDistance < 0 {
+		return nil
+	}
+	if m.norm != nil {
+		word = m.norm.apply(word)
+	}
+	query := []rune(word)
+	for i, r := range query {
+		if m.fold != nil {
+			r = foldRune(r)
+		}
+		if m.equiv != nil {
+			r = m.equiv.canonicalRune(r)
+		}
+		query[i] = r
+	}
+
+	w := suggestWalk{m: m, word: word, query: query, max: maxDistance, off: m.disabledPatterns()}
+	row := make([]int, len(query)+1)
+	for i := range row {
+		row[i] = i
+	}
+	w.visit(root, row)
+
+	sort.Slice(w.found, func(i, j int) bool {
+		a, b := w.found[i], w.found[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		return a.Pattern < b.Pattern
+	})
+	return w.found
+}
+
+// suggestWalk holds the state of a Suggest traversal
+type suggestWalk struct {
+	m     *Matcher
+	word  string // the normalized word
+	query []rune // the runes of word, folded if the matcher folds case
+	max   int
+	off   patternMask // patterns disabled at the start of the walk
+	path  []rune
+	found []Suggestion
+}
+
+// visit reports state s if it ends a pattern close enough to the query, then
+// descends into its children; row holds the distances between the path to s
+// and every prefix of the query
+func (w *suggestWalk) visit(s uint32, row []int) {
+	if d := row[len(w.query)]; d <= w.max && w.m.outputs[s] >= 0 {
+		w.report(w.m.outputs[s], d)
+	}
+	st := &w.m.states[s]
+	for _, e := range w.m.edges[st.edges : st.edges+st.nedges] {
+		if w.m.alias(e.label) {
+			continue // the same child as the transition over the representative
+		}
+		label := e.label
+		if w.m.fold != nil {
+			label = foldRune(label)
+		}
+		next := make([]int, len(row))
+		next[0] = row[0] + 1
+		best := next[0]
+		for i, r := range w.query {
+			cost := 1
+			if r == label {
+				cost = 0
+			}
+			next[i+1] = min(row[i]+cost, row[i+1]+1, next[i]+1)
+			best = min(best, next[i+1])
+		}
+		// every cell only grows further down the trie
+		if best > w.max {
+			continue
+		}
+		w.path = append(w.path, e.label)
+		w.visit(e.next, next)
+		w.path = w.path[:len(w.path)-1]
+	}
+}
+
+// report records the pattern out ending at the current path, d being the
+// distance between the path and the query; with case folding every pattern
+// of the chain is checked, the case-sensitive ones against the unfolded word
+func (w *suggestWalk) report(out int32, d int) {
+	f := w.m.fold
+	if f == nil {
+		if id := w.m.id(out); !w.off.has(id) {
+			w.found = append(w.found, Suggestion{Pattern: int(id), Word: string(w.path), Distance: d})
+		}
+		return
+	}
+	for p := out; p >= 0; p = f.next[p] {
+		if w.off.has(w.m.id(p)) {
+			continue
+		}
+		dp := d
+		if f.sensitive[p] {
+			if dp = editDistance(w.word, f.words[p]); dp > w.max {
+				continue
+			}
+		}
+		w.found = append(w.found, Suggestion{Pattern: int(w.m.id(p)), Word: f.words[p], Distance: dp})
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for _, r := range ra {
+		diag := row[0]
+		row[0]++
+		for j, c := range rb {
+			cost := 1
+			if r == c {
+				cost = 0
+			}
+			diag, row[j+1] = row[j+1], min(diag+cost, row[j+1]+1, row[j]+1)
+		}
+	}
+	return row[len(rb)]
+}
@@ -0,0 +1,45 @@
+// suggest_test.go: tests for Suggest
+
+package ahocorasick
+
+import "testing"
+
+func TestSuggest(t *testing.T) {
+	m := NewStringMatcher([]string{"apple", "apply", "ample", "maple", "banana", "中文测试"})
+	s := m.Suggest("appel", 2)
+	assert(t, len(s) == 2)
+	assert(t, s[0] == Suggestion{Pattern: 0, Word: "apple", Distance: 2})
+	assert(t, s[1] == Suggestion{Pattern: 1, Word: "apply", Distance: 2})
+	assert(t, len(m.Suggest("appel", 3)) == 4)
+
+	s = m.Suggest("apply", 1)
+	assert(t, len(s) == 2)
+	assert(t, s[0].Pattern == 1 && s[0].Distance == 0)
+	assert(t, s[1].Pattern == 0 && s[1].Distance == 1)
+
+	s = m.Suggest("中文侧试", 1)
+	assert(t, len(s) == 1)
+	assert(t, s[0].Word == "中文测试" && s[0].Distance == 1)
+
+	assert(t, len(m.Suggest("zzz", 1)) == 0)
+	assert(t, m.Suggest("apple", -1) == nil)
+}
+
+func TestSuggestTransformed(t *testing.T) {
+	m := NewStringMatcher([]string{"Résumé", "Berlin", "resume"}, WithCaseInsensitive(0), WithDiacriticFolding())
+	s := m.Suggest("RESUME", 0)
+	assert(t, len(s) == 1)
+	assert(t, s[0] == Suggestion{Pattern: 0, Word: "Resume", Distance: 0})
+
+	// the case-sensitive entries are compared exactly
+	s = m.Suggest("Resume", 1)
+	assert(t, len(s) == 2)
+	assert(t, s[0] == Suggestion{Pattern: 0, Word: "Resume", Distance: 0})
+	assert(t, s[1] == Suggestion{Pattern: 2, Word: "resume", Distance: 1})
+
+	s = m.Suggest("berln", 1)
+	assert(t, len(s) == 0)
+	s = m.Suggest("Berln", 1)
+	assert(t, len(s) == 1)
+	assert(t, s[0].Pattern == 1)
+}
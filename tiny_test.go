@@ -0,0 +1,41 @@
+// tiny_test.go: tests for the build without the JSON features
+
+package ahocorasick
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// tinyImports are the packages the build without the JSON features imports,
+// syscall left out as only some platforms map files with it; fmt brings
+// reflect along, os, crypto/sha256 and compress/gzip serve files,
+// fingerprints and compressed saves
+var tinyImports = []string{
+	"bufio", "compress/gzip", "context", "crypto/sha256", "encoding/binary",
+	"encoding/csv", "encoding/hex", "errors", "fmt", "hash/maphash", "io",
+	"io/fs", "math", "math/bits", "math/rand", "os", "path", "runtime", "sort",
+	"strconv", "strings", "sync", "sync/atomic", "time", "unicode",
+	"unicode/utf8", "unsafe",
+}
+
+func TestTinyImports(t *testing.T) {
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	out, err := exec.Command(gobin, "list", "-tags", "ahocorasick_tiny", "-f", `{{join .Imports " "}}`, ".").Output()
+	if err != nil {
+		t.Skip("go list failed: ", err)
+	}
+	var got []string
+	for _, pkg := range strings.Fields(string(out)) {
+		if pkg != "syscall" {
+			got = append(got, pkg)
+		}
+	}
+	if strings.Join(got, " ") != strings.Join(tinyImports, " ") {
+		t.Errorf("the tiny build imports %v, want %v", got, tinyImports)
+	}
+}
@@ -0,0 +1,148 @@
+// tree.go: scanning every file of a directory tree concurrently.
+
+package ahocorasick
+
+import (
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// TreeOptions configures ScanTree
+type TreeOptions struct {
+	// Include lists the globs, in the syntax of path.Match, a file must
+	// match to be scanned, either with its path or its base name; all
+	// files are scanned when it is empty
+	Include []string
+
+	// Exclude lists the globs of the files and directories to skip, matched
+	// like Include; excluding a directory skips everything below it
+	Exclude []string
+
+	Workers     int   // files scanned at once, GOMAXPROCS when not positive
+	MaxFileSize int64 // larger files are skipped, no limit when zero
+
+	Stream []StreamOption // options of the FindReader call scanning a file
+}
+
+// FileResult holds the occurrences found in a file
+type FileResult struct {
+	Path    string // path in the scanned file system
+	Size    int64
+	Matches []Match
+	Err     error // error reading the file or listing the directory
+}
+
+// ScanTree walks the tree of fsys below root, os.DirFS and embed.FS
+// included, and scans the files selected by opts concurrently with
+// FindReader, so memory stays bounded whatever their sizes; it returns the
+// files with occurrences or errors, sorted by path, and the number of files
+// scanned
+// errors reading a file or a directory are reported in its result without
+// stopping the walk; only invalid globs and a missing root are returned
+// symbolic links are not followed
+func (m *Matcher) ScanTree(fsys fs.FS, root string, opts TreeOptions) ([]FileResult, int, error) {
+	for _, glob := range append(append([]string(nil), opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, 0, err
+		}
+	}
+	if _, err := fs.Stat(fsys, root); err != nil {
+		return nil, 0, err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		mu      sync.Mutex
+		results []FileResult
+		scanned int
+		wg      sync.WaitGroup
+	)
+	report := func(res FileResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if res.Err == nil {
+			scanned++
+		}
+		if len(res.Matches) > 0 || res.Err != nil {
+			results = append(results, res)
+		}
+	}
+	files := make(chan FileResult)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for res := range files {
+				res.Matches, res.Err = m.scanFile(fsys, res.Path, opts.Stream)
+				report(res)
+			}
+		}()
+	}
+
+	fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			report(FileResult{Path: name, Err: err})
+			return nil
+		}
+		if name != root && globMatch(opts.Exclude, name) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || len(opts.Include) > 0 && !globMatch(opts.Include, name) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			report(FileResult{Path: name, Err: err})
+			return nil
+		}
+		if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
+			return nil
+		}
+		files <- FileResult{Path: name, Size: info.Size()}
+		return nil
+	})
+	close(files)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results, scanned, nil
+}
+
+// scanFile returns the occurrences in file name of fsys
+func (m *Matcher) scanFile(fsys fs.FS, name string, opts []StreamOption) ([]Match, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var matches []Match
+	err = m.FindReader(f, func(o Match) bool {
+		matches = append(matches, o)
+		return true
+	}, opts...)
+	return matches, err
+}
+
+// globMatch reports whether name or its base name matches one of globs,
+// which are known to be valid
+func globMatch(globs []string, name string) bool {
+	base := path.Base(name)
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, base); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// tree_test.go: tests for scanning directory trees
+
+package ahocorasick
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestScanTree(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":          {Data: []byte("password := secret")},
+		"src/util.go":          {Data: []byte("nothing here")},
+		"src/notes.txt":        {Data: []byte("the secret")},
+		"src/vendor/lib.go":    {Data: []byte("secret")},
+		"docs/readme.md":       {Data: []byte("no password in docs")},
+		"big.go":               {Data: []byte(strings.Repeat("x", 100) + "secret")},
+		"deep/a/b/c/nested.go": {Data: []byte("secret password")},
+	}
+	m := NewStringMatcher([]string{"secret", "password"})
+
+	results, scanned, err := m.ScanTree(fsys, ".", TreeOptions{})
+	assert(t, err == nil && scanned == 7 && len(results) == 6)
+	for i := 1; i < len(results); i++ {
+		assert(t, results[i-1].Path < results[i].Path)
+	}
+	assert(t, results[0].Path == "big.go" && results[0].Size == 106)
+	assert(t, results[0].Matches[0] == Match{Pattern: 0, Start: 100, End: 106})
+	assert(t, results[1].Path == "deep/a/b/c/nested.go" && len(results[1].Matches) == 2)
+
+	// globs match the path or the base name, excluded directories are skipped
+	results, scanned, err = m.ScanTree(fsys, ".", TreeOptions{
+		Include:     []string{"*.go"},
+		Exclude:     []string{"vendor", "deep/a"},
+		Workers:     2,
+		MaxFileSize: 50,
+	})
+	assert(t, err == nil && scanned == 2 && len(results) == 1)
+	assert(t, results[0].Path == "src/main.go" && len(results[0].Matches) == 2)
+
+	results, scanned, err = m.ScanTree(fsys, "src", TreeOptions{Include: []string{"src/*.txt"}})
+	assert(t, err == nil && scanned == 1 && len(results) == 1 && results[0].Path == "src/notes.txt")
+
+	// read errors are reported per file
+	broken := brokenFS{fsys}
+	results, _, err = m.ScanTree(broken, ".", TreeOptions{Include: []string{"*.md", "main.go"}})
+	assert(t, err == nil && len(results) == 2)
+	assert(t, results[0].Path == "docs/readme.md" && results[0].Err != nil)
+	assert(t, results[1].Path == "src/main.go" && results[1].Err == nil)
+
+	_, _, err = m.ScanTree(fsys, ".", TreeOptions{Exclude: []string{"["}})
+	assert(t, errors.Is(err, path.ErrBadPattern))
+	_, _, err = m.ScanTree(fsys, "missing", TreeOptions{})
+	assert(t, errors.Is(err, fs.ErrNotExist))
+}
+
+// brokenFS fails to open markdown files
+type brokenFS struct{ fs.FS }
+
+func (b brokenFS) Open(name string) (fs.File, error) {
+	if path.Ext(name) == ".md" {
+		return nil, errors.New("broken")
+	}
+	return b.FS.Open(name)
+}
@@ -0,0 +1,43 @@
+// truncate.go: shortening texts without cutting through an occurrence.
+
+package ahocorasick
+
+import "unicode/utf8"
+
+// TruncateSafe returns the longest prefix of text of at most maxBytes bytes
+// that ends on a rune boundary and does not cut through an occurrence of a
+// pattern: when the limit falls inside one the prefix stops before it, so a
+// preview of filtered content never shows part of a flagged word that the
+// filter could no longer recognize
+// the whole text is returned when it fits, and an empty one when maxBytes
+// is not positive or the limit falls inside an occurrence starting the text
+func (m *Matcher) TruncateSafe(text string, maxBytes int) string {
+	if len(text) <= maxBytes {
+		return text
+	}
+	if maxBytes <= 0 {
+		return ""
+	}
+	cut := runeStart(text, maxBytes)
+
+	window := text
+	if !m.generic() {
+		// an occurrence through the cut ends at most the longest pattern
+		// after it
+		m.depthOnce.Do(m.computeDepths)
+		if end := cut + m.maxDepth*utf8.UTFMax; end < len(text) {
+			window = text[:runeStart(text, end)]
+		}
+	}
+	matches := m.collect(window)
+	for moved := true; moved; {
+		// backing off before an occurrence can land inside an earlier one
+		moved = false
+		for k := len(matches) - 1; k >= 0; k-- {
+			if o := matches[k]; o.Start < cut && cut < o.End {
+				cut, moved = o.Start, true
+			}
+		}
+	}
+	return text[:cut]
+}
@@ -0,0 +1,43 @@
+// truncate_test.go: tests for truncation around occurrences
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateSafe(t *testing.T) {
+	m := NewStringMatcher([]string{"secret", "cretin", "key"})
+	text := "the secretin key"
+
+	assert(t, m.TruncateSafe(text, 100) == text)
+	assert(t, m.TruncateSafe(text, len(text)) == text)
+	assert(t, m.TruncateSafe(text, 0) == "")
+	assert(t, m.TruncateSafe(text, 3) == "the")
+	assert(t, m.TruncateSafe(text, 4) == "the ")
+
+	// inside "cretin", which starts inside "secret"
+	assert(t, m.TruncateSafe(text, 10) == "the ")
+	assert(t, m.TruncateSafe(text, 11) == "the ")
+	assert(t, m.TruncateSafe(text, 8) == "the ")
+	assert(t, m.TruncateSafe(text, 14) == "the secretin ")
+	assert(t, m.TruncateSafe("secret", 3) == "")
+
+	// never inside a rune
+	assert(t, m.TruncateSafe("ab€cd", 4) == "ab")
+	assert(t, m.TruncateSafe("ab€cd", 5) == "ab€")
+
+	// occurrences spanning more input than their pattern
+	f := NewStringMatcher([]string{"top secret"}, WithWhitespaceCollapsing())
+	assert(t, f.TruncateSafe("a top     secret", 12) == "a ")
+
+	// a truncated text loses its occurrences in whole
+	for n := 0; n <= len(text); n++ {
+		prefix := m.TruncateSafe(text, n)
+		assert(t, len(prefix) <= n && strings.HasPrefix(text, prefix))
+		for _, o := range m.FindAllString(prefix) {
+			assert(t, text[o.Start:o.End] == prefix[o.Start:o.End])
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// validate.go: post-validation of occurrences before they are reported.
+
+package ahocorasick
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Validator decides whether the occurrence text[start:end] of a pattern is
+// reported; it sees the whole text, so a pattern can match a cheap anchor,
+// such as a card number prefix, and the validator check what surrounds it
+// validators are called from matching calls and must be safe for
+// concurrent use
+type Validator func(text string, start, end int) bool
+
+// patternValidator is a validator and the patterns it applies to
+type patternValidator struct {
+	v        Validator
+	kind     string // what v checks, for Fingerprint
+	patterns []int
+}
+
+// WithValidator makes every matching call report an occurrence of one of
+// patterns only if v accepts it, so detectors can combine the automaton with
+// token checks; a pattern with several validators needs all of them to
+// accept, and a matcher with validators cannot be saved
+func WithValidator(v Validator, patterns ...int) Option {
+	return withValidator("func", v, patterns)
+}
+
+// withValidator is WithValidator for a validator of a known kind
+func withValidator(kind string, v Validator, patterns []int) Option {
+	return func(o *options) {
+		o.validators = append(o.validators, patternValidator{v: v, kind: kind, patterns: patterns})
+	}
+}
+
+// newValidators returns the validators of each of the npatterns patterns
+func newValidators(pvs []patternValidator, npatterns int) ([][]Validator, error) {
+	validators := make([][]Validator, npatterns)
+	for _, pv := range pvs {
+		for _, p := range pv.patterns {
+			if p < 0 || p >= npatterns {
+				return nil, fmt.Errorf("ahocorasick: validated pattern %d out of range", p)
+			}
+			validators[p] = append(validators[p], pv.v)
+		}
+	}
+	return validators, nil
+}
+
+// accepts reports whether the validators of pattern accept an occurrence
+func (m *Matcher) accepts(pattern int32, text string, start, end int) bool {
+	for _, v := range m.validators[pattern] {
+		if !v(text, start, end) {
+			return false
+		}
+	}
+	return true
+}
+
+// Luhn is a Validator accepting an occurrence that starts a card number:
+// the run of digits from its start, possibly grouped by spaces or hyphens,
+// is not preceded by another digit and begins with 12 to 19 digits passing
+// the Luhn checksum and ending a group, so an expiry date or another number
+// following the card does not hide it
+func Luhn(text string, start, _ int) bool {
+	if start > 0 && isDigit(text[start-1]) {
+		return false
+	}
+	digits := make([]byte, 0, 19)
+	for i := start; i < len(text); i++ {
+		switch c := text[i]; {
+		case isDigit(c):
+			if len(digits) == cap(digits) {
+				return false
+			}
+			digits = append(digits, c-'0')
+		case c == ' ' || c == '-':
+			if luhnValid(digits) {
+				return true
+			}
+		default:
+			return luhnValid(digits)
+		}
+	}
+	return luhnValid(digits)
+}
+
+// luhnValid reports whether digits are 12 to 19 digits passing the Luhn
+// checksum
+func luhnValid(digits []byte) bool {
+	if len(digits) < 12 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i, d := range digits {
+		if (len(digits)-i)%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += int(d)
+	}
+	return sum%10 == 0
+}
+
+// WholeWord is a Validator accepting an occurrence that is not glued to a
+// word: the runes just before and just after it, if any, are neither
+// letters, digits nor underscores, so "ass" does not match in "class"
+func WholeWord(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text[end:])
+	return end == len(text) || !isWordRune(r)
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
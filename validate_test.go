@@ -0,0 +1,66 @@
+// validate_test.go: tests for the validation of occurrences
+
+package ahocorasick
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLuhn(t *testing.T) {
+	valid := func(text string) bool { return Luhn(text, 0, 1) }
+	assert(t, valid("4111111111111111"))
+	assert(t, valid("4111 1111 1111 1111 exp"))
+	assert(t, valid("4111-1111-1111-1111"))
+	assert(t, !valid("4111111111111112"))
+	assert(t, !valid("411111"))
+	assert(t, !valid("41111111111111111111111"))
+	assert(t, !Luhn("94111111111111111", 1, 2))
+
+	// more digit groups may follow the card, but not split its last group
+	assert(t, valid("4111 1111 1111 1111 12-25"))
+	assert(t, valid("4111-1111-1111-1111-0000-0000"))
+	assert(t, !valid("4111 1111 1111 111112"))
+	assert(t, !valid("4111 1111 1111 1112 12-25"))
+}
+
+func TestWithValidator(t *testing.T) {
+	words := []string{"4111", "5500", "card"}
+	m := NewStringMatcher(words, WithValidator(Luhn, 0, 1))
+	text := "card 4111 1111 1111 1111 and 4111 0000 0000 0000, 5500 0000 0000 0004"
+
+	found := m.FindAllString(text)
+	assert(t, len(found) == 3)
+	assert(t, found[0].Pattern == 2)
+	assert(t, found[1].Pattern == 0 && found[1].Start == 5)
+	assert(t, found[2].Pattern == 1 && text[found[2].Start:found[2].End] == "5500")
+
+	assert(t, len(m.MatchString(text)) == 3)
+	assert(t, !m.ContainsString("4111 0000 0000 0000"))
+	i, ok := m.MatchFirstString("4111 0000 0000 0000 card")
+	assert(t, ok && i == 2)
+
+	// every validator of a pattern must accept
+	never := func(string, int, int) bool { return false }
+	m = NewStringMatcher(words, WithValidator(Luhn, 0), WithValidator(never, 0))
+	assert(t, !m.ContainsString("4111111111111111"))
+
+	// validators see the whole text when it is scanned in parallel
+	long := strings.Repeat("x", 3*minParallelChunk) + "4111111111111111"
+	m = NewStringMatcher(words, WithValidator(Luhn, 0))
+	assert(t, len(m.MatchParallelString(long, 4)) == 1)
+
+	assert(t, errors.Is(m.Save(new(strings.Builder)), ErrNotSerializable))
+
+	_, err := Compile(words, WithValidator(Luhn, 3))
+	assert(t, err != nil)
+}
+
+func TestWholeWord(t *testing.T) {
+	m := NewStringMatcher([]string{"ass", "café"}, WithValidator(WholeWord, 0, 1))
+	assert(t, len(m.FindAllString("class assess ass_ 1ass")) == 0)
+	found := m.FindAllString("ass, (ass) café")
+	assert(t, len(found) == 3 && found[0].Start == 0 && found[1].Start == 6)
+	assert(t, !m.ContainsString("cafés") && m.ContainsString("au café"))
+}
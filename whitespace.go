@@ -0,0 +1,27 @@
+// whitespace.go: matching across any run of whitespace.
+
+package ahocorasick
+
+import "unicode"
+
+// WithWhitespaceCollapsing makes every run of whitespace, including
+// non-breaking and ideographic spaces, tabs and line breaks, match a single
+// space, in the patterns and in the input
+// reported spans still reference the original text, with the whole run of
+// whitespace inside them
+func WithWhitespaceCollapsing() Option {
+	return func(o *options) {
+		o.addNormalizer("whitespace", collapseWhitespace)
+	}
+}
+
+// collapseWhitespace is the normalizer of WithWhitespaceCollapsing
+func collapseWhitespace(dst []rune, prev, r rune) []rune {
+	if !unicode.IsSpace(r) {
+		return append(dst, r)
+	}
+	if prev == ' ' {
+		return dst
+	}
+	return append(dst, ' ')
+}
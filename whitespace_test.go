@@ -0,0 +1,20 @@
+// whitespace_test.go: tests for whitespace collapsing
+
+package ahocorasick
+
+import "testing"
+
+func TestWhitespaceCollapsing(t *testing.T) {
+	p := pipeline{collapseWhitespace}
+	assert(t, p.apply("a \t\n\u00a0b\u3000c") == "a b c")
+
+	m := NewStringMatcher([]string{"Man Of  Steel", "Of "}, WithWhitespaceCollapsing())
+	text := "The Man Of\n\t Steel"
+	matches := m.FindAllString(text)
+	assert(t, len(matches) == 2)
+	assert(t, matches[0].Pattern == 1)
+	assert(t, text[matches[0].Start:matches[0].End] == "Of\n\t ")
+	assert(t, matches[1].Pattern == 0)
+	assert(t, text[matches[1].Start:matches[1].End] == "Man Of\n\t Steel")
+	assert(t, !m.ContainsString("ManOfSteel"))
+}